@@ -13,13 +13,17 @@ package main
 
 import (
 	"GoStats/stats"
+	"flag"
 	"fmt"
 	"math/rand"
-	"time"
 )
 
 const NUM_SAMPLES = 5
 
+// The random source, seeded from the command line so a run can be reproduced.
+var seed = flag.Int64("seed", 1, "seed of the random source")
+var rnd *rand.Rand
+
 func incrementalDemo() {
 	var d stats.Stats
 	fmt.Printf("\n**** Descriptive Statistics, Incremental Updates **\n")
@@ -27,7 +31,7 @@ func incrementalDemo() {
 	fmt.Printf("var d stats.Stats\n")
 	fmt.Printf("** update it with new values:\n")
 	for i := 0; i < NUM_SAMPLES; i++ {
-		x := rand.Float64()*100.0 - 25.0 // uniform samples in {-25, 75}
+		x := rnd.Float64()*100.0 - 25.0 // uniform samples in {-25, 75}
 		fmt.Printf("d.Update(%v)\n", x)
 		d.Update(x)
 	}
@@ -84,7 +88,7 @@ func batchDemo() {
 func makeArray(size int, width, min float64) []float64 {
 	a := make([]float64, size)
 	for i := 0; i < size; i++ {
-		x := rand.Float64()*width + min // uniform samples in {-25, 75}
+		x := rnd.Float64()*width + min // uniform samples in {-25, 75}
 		a[i] = x
 	}
 	return a
@@ -107,8 +111,9 @@ func normalDistributionDemo() {
 	fmt.Printf("\nGenerating %v normal samples. The descriptive statistics ", maxTrials)
 	fmt.Printf("converge to the appropriate values: mean→0, variance→1, skew→0, kurtosis→0. \n")
 	var d stats.Stats
+	normal := stats.NewNormal(0.0, 1.0)
 	for i := 0; i <= maxTrials; i++ {
-		y := rand.NormFloat64()
+		y := normal.Rand(rnd)
 		d.Update(y)
 		if i != 0 && i%printEvery == 0 {
 			mean := d.Mean()
@@ -124,7 +129,9 @@ func normalDistributionDemo() {
 func main() {
 	fmt.Printf("GoStats Demo\n\n")
 
-	rand.Seed(int64(time.Now().Nanosecond()))
+	flag.Parse()
+	rnd = rand.New(rand.NewSource(*seed))
+	fmt.Printf("random seed = %v (rerun with -seed=%v to reproduce)\n", *seed, *seed)
 
 	incrementalDemo()
 	batchDemo()
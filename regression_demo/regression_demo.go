@@ -12,13 +12,17 @@ package main
 
 import (
 	"GoStats/stats"
+	"flag"
 	"fmt"
 	"math/rand"
-	"time"
 )
 
 const NUM_SAMPLES = 5
 
+// The random source, seeded from the command line so a run can be reproduced.
+var seed = flag.Int64("seed", 1, "seed of the random source")
+var rnd *rand.Rand
+
 func incrementalRegressionDemo() {
 	var r stats.Regression
 	fmt.Printf("\n**** Linear Regression, Incremental Updates **\n")
@@ -27,7 +31,7 @@ func incrementalRegressionDemo() {
 	fmt.Printf("** update it with new values:\n")
 	for i := 0; i < NUM_SAMPLES; i++ {
 		x := float64(i) * 3.0
-		y := rand.Float64()*100.0 - 25.0 // uniform samples in {-25, 75}
+		y := rnd.Float64()*100.0 - 25.0 // uniform samples in {-25, 75}
 		fmt.Printf("r.Update(%v, %v)\n", x, y)
 		r.Update(x, y)
 	}
@@ -44,7 +48,7 @@ func incrementalRegressionDemo() {
 func makeArray(size int, width, min float64) []float64 {
 	a := make([]float64, size)
 	for i := 0; i < size; i++ {
-		x := rand.Float64()*width + min // uniform samples in {-25, 75}
+		x := rnd.Float64()*width + min // uniform samples in {-25, 75}
 		a[i] = x
 	}
 	return a
@@ -86,7 +90,9 @@ func batchRegressionDemo() {
 func main() {
 	fmt.Printf("GoStats Demo\n\n")
 
-	rand.Seed(int64(time.Now().Nanosecond()))
+	flag.Parse()
+	rnd = rand.New(rand.NewSource(*seed))
+	fmt.Printf("random seed = %v (rerun with -seed=%v to reproduce)\n", *seed, *seed)
 
 	incrementalRegressionDemo()
 	batchRegressionDemo()
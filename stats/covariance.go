@@ -0,0 +1,162 @@
+package stats
+
+//
+// covariance.go
+//
+// Author:   Gary Boone
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// Changes:
+//           20261017   initial version
+//
+// Source:
+// https://github.com/GaryBoone/GoStats
+//
+// Covariance accumulates the covariance and Pearson correlation of paired values x and y,
+// along with the mean and variance of each. Unlike Regression's RSquared(), the
+// correlation keeps its sign.
+//
+// A Covariance is built on a Regression, which keeps the same running means and centered
+// co-moments, so they're updated with Welford's method and merged with the pairwise
+// formulas of Chan et al. See:
+// http://mathworld.wolfram.com/Covariance.html
+// http://mathworld.wolfram.com/CorrelationCoefficient.html
+//
+
+import (
+	"math"
+)
+
+// structure to contain the number of pairs, the means of x and y, and the centered sums
+// of squares and cross products, in a Regression
+type Covariance struct {
+	r Regression
+}
+
+//
+//
+// Accessor Functions
+//
+//
+
+func (c *Covariance) Count() int {
+	return c.r.Count()
+}
+
+func (c *Covariance) Size() int {
+	return c.r.Size()
+}
+
+func (c *Covariance) MeanX() float64 {
+	return c.r.meanX
+}
+
+func (c *Covariance) MeanY() float64 {
+	return c.r.meanY
+}
+
+//
+//
+// Incremental Functions
+//
+//
+
+// Update the covariance with a new pair of values.
+func (c *Covariance) Update(x, y float64) {
+	c.r.Update(x, y)
+}
+
+// Update the covariance with arrays of x and y values.
+func (c *Covariance) UpdateArray(xData, yData []float64) {
+	c.r.UpdateArray(xData, yData)
+}
+
+// Merge the pairs accumulated in another Covariance into this one. The result is the same
+// as if all of the other's pairs had been passed to Update().
+func (c *Covariance) Merge(other Covariance) {
+	c.r.Merge(other.r)
+}
+
+// The covariances and variances are NaN for fewer than 2 pairs, as in Stats.
+func (c *Covariance) PopulationCovariance() float64 {
+	return c.populationMoment(c.r.cxy)
+}
+
+func (c *Covariance) SampleCovariance() float64 {
+	return c.sampleMoment(c.r.cxy)
+}
+
+// The Pearson correlation coefficient r, from -1 to 1. It's NaN if either x or y is
+// constant.
+func (c *Covariance) Correlation() float64 {
+	if c.r.m2x == 0.0 || c.r.m2y == 0.0 {
+		return math.NaN()
+	}
+	r := c.r.cxy / math.Sqrt(c.r.m2x*c.r.m2y)
+	// rounding can carry a perfect correlation just past 1
+	return math.Max(-1.0, math.Min(1.0, r))
+}
+
+func (c *Covariance) PopulationVarianceX() float64 {
+	return c.populationMoment(c.r.m2x)
+}
+
+func (c *Covariance) SampleVarianceX() float64 {
+	return c.sampleMoment(c.r.m2x)
+}
+
+func (c *Covariance) PopulationVarianceY() float64 {
+	return c.populationMoment(c.r.m2y)
+}
+
+func (c *Covariance) SampleVarianceY() float64 {
+	return c.sampleMoment(c.r.m2y)
+}
+
+// The centered co-moment m divided by n or n - 1.
+func (c *Covariance) populationMoment(m float64) float64 {
+	if c.r.n <= 1.0 {
+		return math.NaN()
+	}
+	return m / c.r.n
+}
+
+func (c *Covariance) sampleMoment(m float64) float64 {
+	if c.r.n <= 1.0 {
+		return math.NaN()
+	}
+	return m / (c.r.n - 1.0)
+}
+
+//
+//
+// Batch Functions
+//
+//
+
+func sumCrossDeltas(xData, yData []float64) (scd float64) {
+	if len(xData) != len(yData) {
+		panic("array lengths differ in sumCrossDeltas()")
+	}
+	meanX := StatsMean(xData)
+	meanY := StatsMean(yData)
+	for i := range xData {
+		scd += (xData[i] - meanX) * (yData[i] - meanY)
+	}
+	return
+}
+
+// The sample covariance of x and y, as R's cov(x, y).
+func StatsCovariance(xData, yData []float64) float64 {
+	n := float64(len(xData))
+	scd := sumCrossDeltas(xData, yData)
+	return scd / (n - 1.0)
+}
+
+// The Pearson correlation coefficient of x and y, as R's cor(x, y).
+func StatsCorrelation(xData, yData []float64) float64 {
+	scd := sumCrossDeltas(xData, yData)
+	r := scd / math.Sqrt(sumSquaredDeltas(xData)*sumSquaredDeltas(yData))
+	return math.Max(-1.0, math.Min(1.0, r))
+}
@@ -0,0 +1,129 @@
+package stats
+
+//
+// covariance_test.go
+//
+// Author:   Gary Boone
+//
+// Test:
+//   go test stats.go stats_test.go specfunc.go distributions.go regression.go covariance.go covariance_test.go
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// R test code:
+// x <- c(2000, 2001, 2002, 2003, 2004)
+// y <- c(9.34, 8.50, 7.62, 6.93, 6.60)
+// cov(x, y); cor(x, y); var(x); var(y)
+//
+// x <- c(1.0, -2.0, 13.0, 47.0, 115.0, -0.03, -123.4, 23.0, -23.04, 12.3)
+// y <- c(2.5, 0.4, 3.3, 1.2, -4.1, 2.2, 7.9, 0.0, 3.6, -1.5)
+// cov(x, y); cor(x, y); var(x); var(y)
+//
+
+import (
+	"testing"
+)
+
+const COV_TOL = 1e-12
+
+var covX = []float64{1.0, -2.0, 13.0, 47.0, 115.0, -0.03, -123.4, 23.0, -23.04, 12.3}
+var covY = []float64{2.5, 0.4, 3.3, 1.2, -4.1, 2.2, 7.9, 0.0, 3.6, -1.5}
+
+// With no updates, these are the results on initialization
+func TestCovariance0(t *testing.T) {
+	var c Covariance
+	checkInt(c.Count(), 0, "Count", t)
+	checkFloat64(c.MeanX(), 0.0, COV_TOL, "MeanX", t)
+	checkFloat64(c.MeanY(), 0.0, COV_TOL, "MeanY", t)
+	checkNaN(c.PopulationCovariance(), "PopulationCovariance", t)
+	checkNaN(c.SampleCovariance(), "SampleCovariance", t)
+	checkNaN(c.Correlation(), "Correlation", t)
+	checkNaN(c.PopulationVarianceX(), "PopulationVarianceX", t)
+	checkNaN(c.SampleVarianceY(), "SampleVarianceY", t)
+}
+
+func TestCovariance1(t *testing.T) {
+	var c Covariance
+	c.Update(2.3, 4.5)
+	checkInt(c.Count(), 1, "Count", t)
+	checkFloat64(c.MeanX(), 2.3, COV_TOL, "MeanX", t)
+	checkFloat64(c.MeanY(), 4.5, COV_TOL, "MeanY", t)
+	checkNaN(c.PopulationCovariance(), "PopulationCovariance", t)
+	checkNaN(c.SampleCovariance(), "SampleCovariance", t)
+	checkNaN(c.PopulationVarianceX(), "PopulationVarianceX", t)
+	checkNaN(c.PopulationVarianceY(), "PopulationVarianceY", t)
+	checkNaN(c.Correlation(), "Correlation", t)
+}
+
+func TestCovariance5(t *testing.T) {
+	var c Covariance
+	c.UpdateArray([]float64{2000, 2001, 2002, 2003, 2004}, []float64{9.34, 8.50, 7.62, 6.93, 6.60})
+	checkInt(c.Count(), 5, "Count", t)
+	checkFloat64(c.MeanX(), 2002.0, COV_TOL, "MeanX", t)
+	checkFloat64(c.MeanY(), 7.798, COV_TOL, "MeanY", t)
+	checkFloat64(c.PopulationCovariance(), -1.41, COV_TOL, "PopulationCovariance", t)
+	checkFloat64(c.SampleCovariance(), -1.7625, COV_TOL, "SampleCovariance", t)
+	// the square root of Regression's RSquared(), with the sign of the slope
+	checkFloat64(c.Correlation(), -0.98808131549320235887, COV_TOL, "Correlation", t)
+	checkFloat64(c.SampleVarianceX(), 2.5, COV_TOL, "SampleVarianceX", t)
+	checkFloat64(c.PopulationVarianceX(), 2.0, COV_TOL, "PopulationVarianceX", t)
+	checkFloat64(c.SampleVarianceY(), 1.27272, COV_TOL, "SampleVarianceY", t)
+	checkFloat64(c.PopulationVarianceY(), 1.018176, COV_TOL, "PopulationVarianceY", t)
+}
+
+func TestCovariance10(t *testing.T) {
+	var c Covariance
+	c.UpdateArray(covX, covY)
+	checkInt(c.Count(), 10, "Count", t)
+	checkFloat64(c.MeanX(), 6.283, COV_TOL, "MeanX", t)
+	checkFloat64(c.MeanY(), 1.55, COV_TOL, "MeanY", t)
+	checkFloat64(c.PopulationCovariance(), -154.42065, COV_TOL, "PopulationCovariance", t)
+	checkFloat64(c.SampleCovariance(), -171.5785, COV_TOL, "SampleCovariance", t)
+	checkFloat64(c.Correlation(), -0.89531527480064161512, COV_TOL, "Correlation", t)
+	checkFloat64(c.SampleVarianceX(), 3516.88129, COV_TOL, "SampleVarianceX", t)
+	checkFloat64(c.SampleVarianceY(), 10.442777777777777778, COV_TOL, "SampleVarianceY", t)
+	checkFloat64(c.PopulationVarianceY(), 9.3985, COV_TOL, "PopulationVarianceY", t)
+}
+
+// A perfect linear relation gives a correlation of exactly 1 or -1.
+func TestCovariancePerfect(t *testing.T) {
+	var c, d Covariance
+	for i := 0; i < 10; i++ {
+		x := 0.1 * float64(i)
+		c.Update(x, 3.0*x+1.0)
+		d.Update(x, -0.7*x)
+	}
+	checkFloat64(c.Correlation(), 1.0, COV_TOL, "Correlation", t)
+	checkFloat64(d.Correlation(), -1.0, COV_TOL, "Correlation", t)
+}
+
+// Merging the covariances of the parts gives the covariance of the whole.
+func TestCovarianceMerge(t *testing.T) {
+	var all Covariance
+	all.UpdateArray(covX, covY)
+	for split := 0; split <= len(covX); split++ {
+		var c1, c2 Covariance
+		c1.UpdateArray(covX[:split], covY[:split])
+		c2.UpdateArray(covX[split:], covY[split:])
+		c1.Merge(c2)
+		checkInt(c1.Count(), all.Count(), "Merge Count", t)
+		checkFloat64(c1.MeanX(), all.MeanX(), COV_TOL, "Merge MeanX", t)
+		checkFloat64(c1.MeanY(), all.MeanY(), COV_TOL, "Merge MeanY", t)
+		checkFloat64(c1.SampleCovariance(), all.SampleCovariance(), COV_TOL, "Merge SampleCovariance", t)
+		checkFloat64(c1.Correlation(), all.Correlation(), COV_TOL, "Merge Correlation", t)
+		checkFloat64(c1.SampleVarianceX(), all.SampleVarianceX(), COV_TOL, "Merge SampleVarianceX", t)
+		checkFloat64(c1.SampleVarianceY(), all.SampleVarianceY(), COV_TOL, "Merge SampleVarianceY", t)
+	}
+}
+
+//
+//
+// Test batch functions
+//
+//
+
+func TestStatsCovariance(t *testing.T) {
+	checkFloat64(StatsCovariance(covX, covY), -171.5785, COV_TOL, "StatsCovariance", t)
+	checkFloat64(StatsCorrelation(covX, covY), -0.89531527480064161512, COV_TOL, "StatsCorrelation", t)
+	checkNaN(StatsCorrelation([]float64{1, 2, 3}, []float64{4, 4, 4}), "StatsCorrelation constant", t)
+}
@@ -0,0 +1,190 @@
+package stats
+
+//
+// covmatrix.go
+//
+// Author:   Gary Boone
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// Changes:
+//           20261017   initial version
+//
+// Source:
+// https://github.com/GaryBoone/GoStats
+//
+// CovarianceMatrix accumulates the mean vector and the covariance and correlation
+// matrices of k-dimensional observations, as R's colMeans(), cov() and cor() of a matrix
+// with one observation per row.
+//
+// It's the multivariate form of the Welford update used by Stats. For an observation v,
+//   delta = v - mean
+//   mean += delta / n
+//   C += delta (v - mean)'
+// where C is the matrix of centered sums of cross products. Accumulated matrices are
+// merged with the pairwise formula of Chan et al.,
+//   C = Ca + Cb + delta delta' na nb / n
+//
+
+import (
+	"math"
+)
+
+// structure to contain the number of observations, their mean vector and the centered
+// sums of cross products
+//
+//	c[i][j] = sum((v[i] - mean[i])*(v[j] - mean[j]))
+type CovarianceMatrix struct {
+	k    int
+	n    float64
+	mean []float64
+	c    [][]float64
+}
+
+// Create a CovarianceMatrix for observations of dimension k.
+func NewCovarianceMatrix(k int) *CovarianceMatrix {
+	if k < 1 {
+		panic("dimension must be at least 1 in NewCovarianceMatrix()")
+	}
+	m := &CovarianceMatrix{k: k, mean: make([]float64, k)}
+	m.c = make([][]float64, k)
+	for i := range m.c {
+		m.c[i] = make([]float64, k)
+	}
+	return m
+}
+
+//
+//
+// Accessor Functions
+//
+//
+
+func (m *CovarianceMatrix) Count() int {
+	return int(m.n)
+}
+
+func (m *CovarianceMatrix) Size() int {
+	return int(m.n)
+}
+
+// The dimension k of the observations.
+func (m *CovarianceMatrix) Dimension() int {
+	return m.k
+}
+
+// The mean of each component of the observations.
+func (m *CovarianceMatrix) Mean() []float64 {
+	mean := make([]float64, m.k)
+	copy(mean, m.mean)
+	return mean
+}
+
+//
+//
+// Incremental Functions
+//
+//
+
+// Update the matrices with a new observation, which must have k components.
+func (m *CovarianceMatrix) Update(v []float64) {
+	if len(v) != m.k {
+		panic("wrong dimension in Update()")
+	}
+	m.n++
+	delta := make([]float64, m.k)
+	for i, x := range v {
+		delta[i] = x - m.mean[i]
+		m.mean[i] += delta[i] / m.n
+	}
+	// fill the upper triangle and mirror it, so that the matrix stays exactly symmetric
+	for i := range m.c {
+		for j := i; j < m.k; j++ {
+			m.c[i][j] += delta[i] * (v[j] - m.mean[j])
+			m.c[j][i] = m.c[i][j]
+		}
+	}
+}
+
+// Update the matrices with each of the given observations.
+func (m *CovarianceMatrix) UpdateArray(data [][]float64) {
+	for _, v := range data {
+		m.Update(v)
+	}
+}
+
+// Merge the observations accumulated in another CovarianceMatrix of the same dimension
+// into this one. The result is the same as if all of the other's observations had been
+// passed to Update().
+func (m *CovarianceMatrix) Merge(other *CovarianceMatrix) {
+	if other.k != m.k {
+		panic("dimensions differ in Merge()")
+	}
+	if other.n == 0 {
+		return
+	}
+	na, nb := m.n, other.n
+	n := na + nb
+	delta := make([]float64, m.k)
+	for i := range delta {
+		delta[i] = other.mean[i] - m.mean[i]
+		m.mean[i] += delta[i] * nb / n
+	}
+	f := na * nb / n
+	for i := range m.c {
+		for j := range m.c[i] {
+			m.c[i][j] += other.c[i][j] + delta[i]*delta[j]*f
+		}
+	}
+	m.n = n
+}
+
+// The covariance matrix with divisor d. With fewer than 2 observations, its elements are
+// NaN, as with Covariance.
+func (m *CovarianceMatrix) scaled(d float64) [][]float64 {
+	cov := make([][]float64, m.k)
+	for i := range cov {
+		cov[i] = make([]float64, m.k)
+		for j := range cov[i] {
+			if m.n <= 1.0 {
+				cov[i][j] = math.NaN()
+			} else {
+				cov[i][j] = m.c[i][j] / d
+			}
+		}
+	}
+	return cov
+}
+
+// The covariance matrix with divisor n. With fewer than 2 observations, its elements
+// are NaN.
+func (m *CovarianceMatrix) PopulationCovariance() [][]float64 {
+	return m.scaled(m.n)
+}
+
+// The covariance matrix with divisor n - 1, as R's cov(). With fewer than 2
+// observations, its elements are NaN.
+func (m *CovarianceMatrix) SampleCovariance() [][]float64 {
+	return m.scaled(m.n - 1.0)
+}
+
+// The matrix of Pearson correlation coefficients, as R's cor(). The diagonal is 1. A
+// constant component has NaN correlations, including with itself.
+func (m *CovarianceMatrix) Correlation() [][]float64 {
+	cor := make([][]float64, m.k)
+	for i := range cor {
+		cor[i] = make([]float64, m.k)
+		for j := range cor[i] {
+			switch {
+			case m.c[i][i] == 0.0 || m.c[j][j] == 0.0:
+				cor[i][j] = math.NaN()
+			case i == j:
+				cor[i][j] = 1.0
+			default:
+				r := m.c[i][j] / math.Sqrt(m.c[i][i]*m.c[j][j])
+				cor[i][j] = math.Max(-1.0, math.Min(1.0, r))
+			}
+		}
+	}
+	return cor
+}
@@ -0,0 +1,135 @@
+package stats
+
+//
+// covmatrix_test.go
+//
+// Author:   Gary Boone
+//
+// Test:
+//   go test stats.go stats_test.go specfunc.go distributions.go regression.go covariance.go covariance_test.go covmatrix.go covmatrix_test.go
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// R test code:
+// x <- c(1.0, -2.0, 13.0, 47.0, 115.0, -0.03, -123.4, 23.0, -23.04, 12.3)
+// y <- c(2.5, 0.4, 3.3, 1.2, -4.1, 2.2, 7.9, 0.0, 3.6, -1.5)
+// z <- c(0.5, 1.5, -2.0, 3.25, 8.0, -1.0, 0.0, 2.5, 4.0, -3.5)
+// m <- cbind(x, y, z)
+// colMeans(m); cov(m); cor(m)
+//
+// Each pair of components is also checked against Covariance.
+//
+
+import (
+	"testing"
+)
+
+var covZ = []float64{0.5, 1.5, -2.0, 3.25, 8.0, -1.0, 0.0, 2.5, 4.0, -3.5}
+
+// the observations of x, y and z, one per row
+func covRows() [][]float64 {
+	rows := make([][]float64, len(covX))
+	for i := range rows {
+		rows[i] = []float64{covX[i], covY[i], covZ[i]}
+	}
+	return rows
+}
+
+// With no updates, these are the results on initialization
+func TestCovarianceMatrix0(t *testing.T) {
+	m := NewCovarianceMatrix(3)
+	checkInt(m.Count(), 0, "Count", t)
+	checkInt(m.Dimension(), 3, "Dimension", t)
+	for _, v := range m.Mean() {
+		checkFloat64(v, 0.0, COV_TOL, "Mean", t)
+	}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			checkNaN(m.PopulationCovariance()[i][j], "PopulationCovariance", t)
+			checkNaN(m.SampleCovariance()[i][j], "SampleCovariance", t)
+			checkNaN(m.Correlation()[i][j], "Correlation", t)
+		}
+	}
+}
+
+// With one observation, the covariances are NaN, as with Covariance.
+func TestCovarianceMatrix1(t *testing.T) {
+	m := NewCovarianceMatrix(2)
+	m.Update([]float64{covX[0], covY[0]})
+	var c Covariance
+	c.Update(covX[0], covY[0])
+	checkInt(m.Count(), 1, "Count", t)
+	checkFloat64(m.Mean()[0], c.MeanX(), COV_TOL, "Mean x", t)
+	checkNaN(c.PopulationCovariance(), "Covariance PopulationCovariance", t)
+	checkNaN(c.SampleCovariance(), "Covariance SampleCovariance", t)
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 2; j++ {
+			checkNaN(m.PopulationCovariance()[i][j], "PopulationCovariance", t)
+			checkNaN(m.SampleCovariance()[i][j], "SampleCovariance", t)
+			checkNaN(m.Correlation()[i][j], "Correlation", t)
+		}
+	}
+}
+
+func TestCovarianceMatrix10(t *testing.T) {
+	m := NewCovarianceMatrix(3)
+	m.UpdateArray(covRows())
+	checkInt(m.Count(), 10, "Count", t)
+	mean := m.Mean()
+	checkFloat64(mean[0], 6.283, COV_TOL, "Mean x", t)
+	checkFloat64(mean[1], 1.55, COV_TOL, "Mean y", t)
+	checkFloat64(mean[2], 1.325, COV_TOL, "Mean z", t)
+	cov := m.SampleCovariance()
+	checkFloat64(cov[0][0], 3516.88129, COV_TOL, "SampleCovariance xx", t)
+	checkFloat64(cov[0][1], -171.5785, COV_TOL, "SampleCovariance xy", t)
+	checkFloat64(cov[1][0], -171.5785, COV_TOL, "SampleCovariance yx", t)
+	checkFloat64(cov[0][2], 98.146694444444444444, COV_TOL, "SampleCovariance xz", t)
+	checkFloat64(cov[2][2], 11.000694444444444444, COV_TOL, "SampleCovariance zz", t)
+	checkFloat64(m.PopulationCovariance()[0][1], -154.42065, COV_TOL, "PopulationCovariance xy", t)
+
+	// every pair agrees with Covariance
+	data := [][]float64{covX, covY, covZ}
+	cor := m.Correlation()
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			var c Covariance
+			c.UpdateArray(data[i], data[j])
+			checkFloat64(cov[i][j], c.SampleCovariance(), COV_TOL, "SampleCovariance", t)
+			checkFloat64(cor[i][j], c.Correlation(), COV_TOL, "Correlation", t)
+		}
+	}
+}
+
+// Merging the matrices of the parts gives the matrices of the whole.
+func TestCovarianceMatrixMerge(t *testing.T) {
+	rows := covRows()
+	all := NewCovarianceMatrix(3)
+	all.UpdateArray(rows)
+	for split := 0; split <= len(rows); split++ {
+		m1, m2 := NewCovarianceMatrix(3), NewCovarianceMatrix(3)
+		m1.UpdateArray(rows[:split])
+		m2.UpdateArray(rows[split:])
+		m1.Merge(m2)
+		checkInt(m1.Count(), all.Count(), "Merge Count", t)
+		for i := 0; i < 3; i++ {
+			checkFloat64(m1.Mean()[i], all.Mean()[i], COV_TOL, "Merge Mean", t)
+			for j := 0; j < 3; j++ {
+				checkFloat64(m1.SampleCovariance()[i][j], all.SampleCovariance()[i][j], COV_TOL,
+					"Merge SampleCovariance", t)
+				checkFloat64(m1.Correlation()[i][j], all.Correlation()[i][j], COV_TOL,
+					"Merge Correlation", t)
+			}
+		}
+	}
+}
+
+// A constant component has no correlation with the others.
+func TestCovarianceMatrixConstant(t *testing.T) {
+	m := NewCovarianceMatrix(2)
+	m.UpdateArray([][]float64{{1.0, 5.0}, {2.0, 5.0}, {4.0, 5.0}})
+	cor := m.Correlation()
+	checkFloat64(cor[0][0], 1.0, COV_TOL, "Correlation", t)
+	checkNaN(cor[0][1], "Correlation", t)
+	checkNaN(cor[1][1], "Correlation", t)
+	checkFloat64Abs(m.SampleCovariance()[1][1], 0.0, COV_TOL, "SampleCovariance", t)
+}
@@ -0,0 +1,290 @@
+package stats
+
+//
+// ddsketch.go
+//
+// Author:   Gary Boone
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// Changes:
+//           20261017   initial version
+//
+// Source:
+// https://github.com/GaryBoone/GoStats
+//
+// DDSketch is a mergeable quantile sketch with a guaranteed relative error, suited to
+// values such as latencies that span several orders of magnitude. See:
+// C. Masson, J.E. Rim and H.K. Lee, DDSketch: a fast and fully-mergeable quantile sketch
+// with relative-error guarantees, PVLDB 12(12), 2019.
+// https://arxiv.org/abs/1908.10693
+//
+// For a relative accuracy alpha, let gamma = (1 + alpha) / (1 - alpha). A positive value
+// x is counted in the bucket
+//   i = ceil(log(x) / log(gamma))
+// which holds the values in (gamma^(i-1), gamma^i]. Every value in the bucket is within a
+// relative error alpha of the bucket's estimate 2 gamma^i / (gamma + 1), so the quantile
+// estimates are within alpha of the true quantiles, relatively. Negative values are
+// counted by magnitude in a second set of buckets, and zeros are counted separately.
+//
+// The quantile at q is the value of rank floor(q (n - 1)) among the sorted values, from 0,
+// as in the paper. The estimates are clamped to the exact min and max, which are the
+// quantiles at 0 and 1.
+//
+// The buckets of each sign are kept in an array spanning the lowest to the highest bucket
+// used. Like a slice grown by append(), the array has room to grow, here at either end,
+// so adding a bucket takes amortized constant time. The span of each is bounded by
+// maxBuckets. When a new value would exceed it, the
+// buckets of the smallest magnitudes are collapsed into the lowest bucket that remains,
+// as in the paper. So the accuracy guarantee is kept for the largest values, which are
+// usually the interesting ones, such as p99 latencies, while the smallest values are
+// overestimated in magnitude. With alpha = 0.01, 2048 buckets cover a range of magnitudes
+// of 10^17, so collapsing is rare in practice.
+//
+// The count, sum, min and max are exact, and are those of a Stats of the same values.
+// NaNs and infinities have no bucket, so they panic.
+//
+
+import (
+	"math"
+)
+
+// the buckets of one sign, counts[i] being the count of bucket offset + i. counts is
+// buf[start:start+len(counts)], and the rest of buf is zero.
+type ddStore struct {
+	counts []float64
+	offset int
+	total  float64
+	buf    []float64
+	start  int
+}
+
+// structure to contain the buckets and the exact stats of the values
+type DDSketch struct {
+	alpha, gamma, logGamma float64
+	maxBuckets             int
+	pos, neg               ddStore // the positive values and the magnitudes of the negative ones
+	zero                   float64 // the count of zeros
+	stats                  Stats
+}
+
+// Create a DDSketch with relative accuracy alpha, 0 < alpha < 1, and at most maxBuckets
+// buckets each for the positive and negative values.
+func NewDDSketch(alpha float64, maxBuckets int) *DDSketch {
+	if !(alpha > 0.0 && alpha < 1.0) {
+		panic("alpha must be in (0, 1) in NewDDSketch()")
+	}
+	if maxBuckets < 1 {
+		panic("maxBuckets must be at least 1 in NewDDSketch()")
+	}
+	gamma := (1.0 + alpha) / (1.0 - alpha)
+	return &DDSketch{alpha: alpha, gamma: gamma, logGamma: math.Log(gamma), maxBuckets: maxBuckets}
+}
+
+//
+//
+// Accessor Functions
+//
+//
+
+func (s *DDSketch) Count() int {
+	return s.stats.Count()
+}
+
+func (s *DDSketch) Size() int {
+	return s.stats.Size()
+}
+
+func (s *DDSketch) Sum() float64 {
+	return s.stats.Sum()
+}
+
+func (s *DDSketch) Min() float64 {
+	return s.stats.Min()
+}
+
+func (s *DDSketch) Max() float64 {
+	return s.stats.Max()
+}
+
+func (s *DDSketch) Mean() float64 {
+	return s.stats.Mean()
+}
+
+func (s *DDSketch) RelativeAccuracy() float64 {
+	return s.alpha
+}
+
+// The number of buckets, counting every bucket in the span of the positive and of the
+// negative values, and the zero bucket if there are zeros.
+func (s *DDSketch) Buckets() int {
+	n := len(s.pos.counts) + len(s.neg.counts)
+	if s.zero > 0 {
+		n++
+	}
+	return n
+}
+
+//
+//
+// Incremental Functions
+//
+//
+
+// Update the sketch with a new value, which must be finite.
+func (s *DDSketch) Update(x float64) {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		panic("NaN or infinite value in Update()")
+	}
+	s.stats.Update(x)
+	switch {
+	case x > 0.0:
+		s.pos.add(s.index(x), 1.0, s.maxBuckets)
+	case x < 0.0:
+		s.neg.add(s.index(-x), 1.0, s.maxBuckets)
+	default:
+		s.zero++
+	}
+}
+
+// Update the sketch with the given array of values.
+func (s *DDSketch) UpdateArray(data []float64) {
+	for _, v := range data {
+		s.Update(v)
+	}
+}
+
+// Merge the values counted by another DDSketch with the same relative accuracy into this
+// one. The buckets are added, so unless buckets are collapsed, the result is exactly the
+// sketch of all of the values. The bucket limit of this sketch is kept.
+func (s *DDSketch) Merge(other *DDSketch) {
+	if other.gamma != s.gamma {
+		panic("relative accuracies differ in Merge()")
+	}
+	// copy first, in case other is s
+	pos := append([]float64(nil), other.pos.counts...)
+	neg := append([]float64(nil), other.neg.counts...)
+	for i, c := range pos {
+		if c > 0 {
+			s.pos.add(other.pos.offset+i, c, s.maxBuckets)
+		}
+	}
+	for i, c := range neg {
+		if c > 0 {
+			s.neg.add(other.neg.offset+i, c, s.maxBuckets)
+		}
+	}
+	s.zero += other.zero
+	s.stats.Merge(other.stats)
+}
+
+// The bucket of the positive value x.
+func (s *DDSketch) index(x float64) int {
+	return int(math.Ceil(math.Log(x) / s.logGamma))
+}
+
+// The estimate of the values in bucket i.
+func (s *DDSketch) value(i int) float64 {
+	return 2.0 * math.Exp(float64(i)*s.logGamma) / (s.gamma + 1.0)
+}
+
+// The estimate of the quantile at q, 0 <= q <= 1, within a relative error alpha of the
+// value of rank floor(q (n - 1)) among the sorted values. With no values, it's NaN.
+func (s *DDSketch) Quantile(q float64) float64 {
+	if !(q >= 0.0 && q <= 1.0) {
+		panic("quantile must be in [0, 1] in Quantile()")
+	}
+	if s.stats.n == 0 {
+		return math.NaN()
+	}
+	if q == 0.0 {
+		return s.stats.min
+	}
+	if q == 1.0 {
+		return s.stats.max
+	}
+	rank := math.Floor(q * (s.stats.n - 1.0))
+	var x float64
+	switch {
+	case rank < s.neg.total:
+		// the most negative values are in the highest buckets
+		x = -s.value(s.neg.indexAtRank(s.neg.total - 1.0 - rank))
+	case rank < s.neg.total+s.zero:
+		x = 0.0
+	default:
+		x = s.value(s.pos.indexAtRank(rank - s.neg.total - s.zero))
+	}
+	return math.Max(s.stats.min, math.Min(s.stats.max, x))
+}
+
+// Add count c to bucket i, keeping the span of the buckets within maxBuckets by
+// collapsing the lowest ones.
+func (b *ddStore) add(i int, c float64, maxBuckets int) {
+	b.total += c
+	if len(b.counts) == 0 {
+		b.buf = []float64{c}
+		b.start = 0
+		b.counts = b.buf
+		b.offset = i
+		return
+	}
+	lo, hi := b.offset, b.offset+len(b.counts)-1
+	if i < lo {
+		lo = i
+	}
+	if i > hi {
+		hi = i
+	}
+	if hi-lo+1 > maxBuckets {
+		lo = hi - maxBuckets + 1
+	}
+	if i < lo {
+		i = lo
+	}
+	if lo != b.offset || hi != b.offset+len(b.counts)-1 {
+		b.respan(lo, hi)
+	}
+	b.counts[i-b.offset] += c
+}
+
+// Change the span of the buckets to [lo, hi], collapsing the buckets below lo into it.
+// When the span outgrows buf, buf is reallocated with room for the span to double in the
+// direction it grew.
+func (b *ddStore) respan(lo, hi int) {
+	collapsed := 0.0
+	for j := 0; j < lo-b.offset && j < len(b.counts); j++ {
+		collapsed += b.counts[j]
+		b.counts[j] = 0.0
+	}
+	span := hi - lo + 1
+	start := b.start + lo - b.offset
+	if start < 0 || start+span > len(b.buf) {
+		buf := make([]float64, 2*span)
+		newStart := 0
+		if lo < b.offset {
+			newStart = span
+		}
+		for j, v := range b.counts {
+			if k := b.offset + j - lo; k >= 0 {
+				buf[newStart+k] = v
+			}
+		}
+		b.buf, start = buf, newStart
+	}
+	b.start = start
+	b.counts = b.buf[start : start+span]
+	b.offset = lo
+	b.counts[0] += collapsed
+}
+
+// The bucket of the value of the given rank, from 0, among the values of the store.
+func (b *ddStore) indexAtRank(rank float64) int {
+	sum := 0.0
+	for j, v := range b.counts {
+		sum += v
+		if sum > rank {
+			return b.offset + j
+		}
+	}
+	return b.offset + len(b.counts) - 1
+}
@@ -0,0 +1,219 @@
+package stats
+
+//
+// ddsketch_test.go
+//
+// Author:   Gary Boone
+//
+// Test:
+//   go test stats.go stats_test.go ddsketch.go ddsketch_test.go
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// The quantile estimates are checked against the guarantee: each is within the relative
+// accuracy alpha of the value of rank floor(q (n - 1)) among the sorted values.
+//
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+var ddQuantiles = []float64{0.0, 0.001, 0.01, 0.1, 0.25, 0.5, 0.75, 0.9, 0.99, 0.999, 1.0}
+
+// With no updates, these are the results on initialization
+func TestDDSketch0(t *testing.T) {
+	s := NewDDSketch(0.01, 2048)
+	checkInt(s.Count(), 0, "Count", t)
+	checkInt(s.Buckets(), 0, "Buckets", t)
+	checkFloat64(s.RelativeAccuracy(), 0.01, TOL, "RelativeAccuracy", t)
+	checkNaN(s.Quantile(0.5), "Quantile", t)
+}
+
+func TestDDSketch10(t *testing.T) {
+	s := NewDDSketch(0.01, 2048)
+	a := []float64{1.0, -2.0, 13.0, 47.0, 115.0, -0.03, -123.4, 23.0, -23.04, 12.3}
+	s.UpdateArray(a)
+
+	// the count, sum, min and max are those of Stats
+	var d Stats
+	d.UpdateArray(a)
+	checkInt(s.Count(), d.Count(), "Count", t)
+	checkFloat64(s.Sum(), d.Sum(), TOL, "Sum", t)
+	checkFloat64(s.Min(), d.Min(), TOL, "Min", t)
+	checkFloat64(s.Max(), d.Max(), TOL, "Max", t)
+	checkFloat64(s.Mean(), d.Mean(), TOL, "Mean", t)
+
+	checkFloat64(s.Quantile(0.0), -123.4, TOL, "Quantile 0", t)
+	checkFloat64(s.Quantile(1.0), 115.0, TOL, "Quantile 1", t)
+	checkDDSketchAccuracy(s, a, "10", t)
+}
+
+// Zeros and values of both signs.
+func TestDDSketchZeros(t *testing.T) {
+	s := NewDDSketch(0.02, 2048)
+	a := []float64{0.0, -5.0, 0.0, 3.0, 0.0, -0.5, 7.0, 0.0}
+	s.UpdateArray(a)
+	checkFloat64Abs(s.Quantile(0.5), 0.0, TOL, "Quantile 0.5", t)
+	checkDDSketchAccuracy(s, a, "Zeros", t)
+}
+
+// Latencies spanning several orders of magnitude.
+func TestDDSketchLogNormal(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	a := make([]float64, 100000)
+	for i := range a {
+		a[i] = math.Exp(rnd.NormFloat64() * 3.0)
+	}
+	for _, alpha := range []float64{0.001, 0.01, 0.05} {
+		s := NewDDSketch(alpha, 1<<15)
+		s.UpdateArray(a)
+		checkDDSketchAccuracy(s, a, "LogNormal", t)
+	}
+}
+
+func TestDDSketchNormal(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	a := make([]float64, 100000)
+	for i := range a {
+		a[i] = rnd.NormFloat64() * 10.0
+	}
+	s := NewDDSketch(0.01, 2048)
+	s.UpdateArray(a)
+	checkDDSketchAccuracy(s, a, "Normal", t)
+}
+
+// Without collapsing, merging is exact.
+func TestDDSketchMerge(t *testing.T) {
+	rnd := rand.New(rand.NewSource(3))
+	all := NewDDSketch(0.01, 2048)
+	merged := NewDDSketch(0.01, 2048)
+	var d Stats
+	for shard := 0; shard < 10; shard++ {
+		s := NewDDSketch(0.01, 2048)
+		for i := 0; i < 1000; i++ {
+			x := rnd.NormFloat64() * math.Pow(10.0, float64(shard-5))
+			s.Update(x)
+			all.Update(x)
+			d.Update(x)
+		}
+		merged.Merge(s)
+	}
+	checkInt(merged.Count(), 10000, "Count", t)
+	checkInt(merged.Buckets(), all.Buckets(), "Buckets", t)
+	checkFloat64(merged.Sum(), d.Sum(), 1e-12, "Sum", t)
+	checkFloat64(merged.Min(), d.Min(), TOL, "Min", t)
+	checkFloat64(merged.Max(), d.Max(), TOL, "Max", t)
+	for _, q := range ddQuantiles {
+		if merged.Quantile(q) != all.Quantile(q) {
+			t.Errorf("Found %v, but expected %v for test Merge Quantile %v", merged.Quantile(q), all.Quantile(q), q)
+		}
+	}
+}
+
+// With too few buckets, those of the smallest magnitudes are collapsed. The guarantee
+// still holds for the largest values. With alpha = 0.01, 400 buckets cover the magnitudes
+// from 1e6 down to 1e6 / gamma^399, about 335.
+func TestDDSketchCollapse(t *testing.T) {
+	alpha := 0.01
+	s := NewDDSketch(alpha, 400)
+	a := make([]float64, 0, 2000)
+	for i := 0; i < 1000; i++ {
+		// from 1e-6 to 1e6
+		x := math.Pow(10.0, -6.0+12.0*float64(i)/999.0)
+		a = append(a, x, -x)
+	}
+	s.UpdateArray(a)
+	checkInt(s.Count(), 2000, "Count", t)
+	checkInt(s.Buckets(), 800, "Buckets", t)
+	sort.Float64s(a)
+	for _, q := range []float64{0.0, 0.01, 0.1, 0.9, 0.99, 1.0} {
+		x := a[int(q*float64(len(a)-1))]
+		checkFloat64(s.Quantile(q), x, alpha, "Collapse Quantile", t)
+	}
+	// near zero, the magnitudes are overestimated as those of the lowest remaining bucket
+	lowest := 1e6 / math.Pow((1.0+alpha)/(1.0-alpha), 399.0)
+	checkFloat64(s.Quantile(0.5), -lowest, 2.0*alpha, "Collapse Quantile 0.5", t)
+}
+
+// NaNs and infinities have no bucket.
+func TestDDSketchNonFinite(t *testing.T) {
+	for _, x := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Found no panic for test Update(%v)", x)
+				}
+			}()
+			NewDDSketch(0.01, 2048).Update(x)
+		}()
+	}
+}
+
+// Buckets added in increasing or decreasing order give the same sketch as in random
+// order, and the array grows in amortized constant time, with few reallocations.
+func TestDDSketchGrowth(t *testing.T) {
+	gamma := 1.01 / 0.99
+	increasing := make([]float64, 1000)
+	decreasing := make([]float64, 1000)
+	for i := range increasing {
+		increasing[i] = math.Pow(gamma, float64(i)-500.0)
+		decreasing[len(decreasing)-1-i] = increasing[i]
+	}
+	shuffled := append([]float64(nil), increasing...)
+	rand.New(rand.NewSource(1)).Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	random := NewDDSketch(0.01, 2048)
+	random.UpdateArray(shuffled)
+	for _, a := range [][]float64{increasing, decreasing} {
+		var s *DDSketch
+		allocs := testing.AllocsPerRun(1, func() {
+			s = NewDDSketch(0.01, 2048)
+			s.UpdateArray(a)
+		})
+		if allocs > 20 {
+			t.Errorf("Found %v allocations, but expected at most 20 for test Growth", allocs)
+		}
+		checkInt(s.Buckets(), random.Buckets(), "Growth Buckets", t)
+		for _, q := range []float64{0.0, 0.01, 0.25, 0.5, 0.75, 0.99, 1.0} {
+			checkFloat64(s.Quantile(q), random.Quantile(q), 1e-15, "Growth Quantile", t)
+		}
+	}
+}
+
+//
+//
+// Benchmark tests
+//
+//
+
+func BenchmarkDDSketchUpdate(b *testing.B) {
+	s := NewDDSketch(0.01, 2048)
+	for i := 0; i < b.N; i++ {
+		s.Update(float64(i%1000) + 1.0)
+	}
+}
+
+//
+//
+// Assertion functions used for tests
+//
+//
+
+// check that each quantile estimate is within a relative error alpha of the value of rank
+// floor(q (n - 1)), allowing for rounding at the bucket boundaries
+func checkDDSketchAccuracy(s *DDSketch, data []float64, test string, t *testing.T) {
+	data = append([]float64(nil), data...)
+	sort.Float64s(data)
+	for _, q := range ddQuantiles {
+		x := data[int(math.Floor(q*float64(len(data)-1)))]
+		est := s.Quantile(q)
+		if math.Abs(est-x) > s.RelativeAccuracy()*math.Abs(x)*(1.0+1e-12) {
+			t.Errorf("Found %v, but expected %v within %v for test %v Quantile %v", est, x,
+				s.RelativeAccuracy(), test, q)
+		}
+	}
+}
@@ -0,0 +1,663 @@
+package stats
+
+//
+// discrete.go
+//
+// Author:   Gary Boone
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// Changes:
+//           20261017   initial version
+//
+// Source:
+// https://github.com/GaryBoone/GoStats
+//
+// Discrete probability distributions on the integers, with the parameters of R's d/p/q
+// functions:
+//
+//   Binomial(n, p)                 dbinom, pbinom, qbinom
+//   Poisson(lambda)                dpois, ppois, qpois
+//   Geometric(p)                   dgeom, pgeom, qgeom
+//   NegativeBinomial(size, p)      dnbinom, pnbinom, qnbinom
+//   Hypergeometric(m, n, k)        dhyper, phyper, qhyper
+//
+// The geometric and negative binomial distributions count the failures before the first
+// and the size-th success. The hypergeometric distribution counts the white balls among
+// k drawn without replacement from an urn of m white and n black balls.
+//
+// Each has the probability PMF(k) and its log LogPMF(k), the CDF(k) = P(X <= k), the
+// Survival(k) = P(X > k), the Quantile(p), the smallest k with CDF(k) >= p, the Mean()
+// and Variance(), and Rand(src), a variate drawn using the given random source. As for
+// the continuous distributions, the smaller tail is computed directly. The binomial,
+// Poisson and negative binomial CDFs are incomplete beta and gamma functions, and the
+// hypergeometric CDF is summed from the smaller tail. The quantiles are found by a search
+// from the Cornish-Fisher approximation.
+//
+// The binomial and Poisson variates are drawn by inversion when the mean is small and
+// otherwise by Hormann's transformed rejection, in constant expected time. The geometric
+// variates invert the exponential distribution, and the negative binomial variates are
+// Poisson with a gamma-distributed mean. Only the hypergeometric variates are found as
+// the quantile of a uniform variate, which is much slower.
+//
+
+import (
+	"math"
+	"math/rand"
+)
+
+// The methods shared by the discrete distributions.
+type DiscreteDistribution interface {
+	PMF(k int) float64
+	LogPMF(k int) float64
+	CDF(k int) float64
+	Survival(k int) float64
+	Quantile(p float64) int
+	Mean() float64
+	Variance() float64
+	Rand(src rand.Source) int
+}
+
+// The Cornish-Fisher approximation of the quantile at p, 0 < p < 1, from the mean,
+// variance and skewness, a guess for discreteQuantile().
+func cornishFisherGuess(mean, variance, skew, p float64) float64 {
+	z := normalQuantile(p)
+	return math.Floor(mean + math.Sqrt(variance)*(z+skew*(z*z-1.0)/6.0) + 0.5)
+}
+
+// The smallest k in [lo, hi] whose CDF is at least p, 0 < p < 1, given the tails of a
+// discrete distribution on [lo, hi] and a guess. As in R, the comparison is made on the
+// smaller tail, with a fuzz of 64 epsilon for rounding. The quantile is bracketed by
+// steps that double from the guess, then found by bisection.
+func discreteQuantile(p float64, tails func(int) (float64, float64), guess float64, lo, hi int) int {
+	reached := func(k int) bool {
+		cdf, survival := tails(k)
+		if p <= 0.5 {
+			return cdf >= p*(1.0-64.0*epsilon)
+		}
+		return survival <= (1.0-p)*(1.0+64.0*epsilon)
+	}
+	k := lo
+	switch {
+	case guess >= float64(hi):
+		k = hi
+	case guess > float64(lo):
+		k = int(guess)
+	}
+	// the CDF at below is under p, and at above, it's reached p
+	var below, above int
+	if reached(k) {
+		above = k
+		for step := 1; ; step *= 2 {
+			if above-lo < step {
+				below = lo - 1
+				break
+			}
+			below = above - step
+			if !reached(below) {
+				break
+			}
+			above = below
+		}
+	} else {
+		below = k
+		for step := 1; ; step *= 2 {
+			if hi-below <= step {
+				above = hi
+				break
+			}
+			above = below + step
+			if reached(above) {
+				break
+			}
+			below = above
+		}
+	}
+	for above-below > 1 {
+		mid := below + (above-below)/2
+		if reached(mid) {
+			above = mid
+		} else {
+			below = mid
+		}
+	}
+	return above
+}
+
+//
+//
+// Binomial
+//
+//
+
+// The binomial distribution of the successes in n trials with the probability p of success.
+type Binomial struct {
+	n int
+	p float64
+}
+
+func NewBinomial(n int, p float64) *Binomial {
+	if n < 0 || !(p >= 0.0 && p <= 1.0) {
+		panic("n must be nonnegative and p in [0, 1] in NewBinomial()")
+	}
+	return &Binomial{n, p}
+}
+
+func (d *Binomial) PMF(k int) float64 {
+	return math.Exp(d.LogPMF(k))
+}
+
+func (d *Binomial) LogPMF(k int) float64 {
+	return logBinomialProbability(float64(k), float64(d.n), d.p, 1.0-d.p)
+}
+
+// P(X <= k) = I_{1-p}(n - k, k + 1), and its complement.
+func (d *Binomial) tails(k int) (p, q float64) {
+	switch {
+	case k < 0:
+		return 0.0, 1.0
+	case k >= d.n:
+		return 1.0, 0.0
+	}
+	return incompleteBeta(float64(d.n-k), float64(k+1), 1.0-d.p, d.p)
+}
+
+func (d *Binomial) CDF(k int) float64 {
+	p, _ := d.tails(k)
+	return p
+}
+
+func (d *Binomial) Survival(k int) float64 {
+	_, q := d.tails(k)
+	return q
+}
+
+func (d *Binomial) Quantile(p float64) int {
+	checkProbability(p, "Binomial.Quantile()")
+	switch {
+	case p == 0.0:
+		return 0
+	case p == 1.0:
+		return d.n
+	}
+	skew := (1.0 - 2.0*d.p) / math.Sqrt(d.Variance())
+	return discreteQuantile(p, d.tails, cornishFisherGuess(d.Mean(), d.Variance(), skew, p), 0, d.n)
+}
+
+func (d *Binomial) Mean() float64 {
+	return float64(d.n) * d.p
+}
+
+func (d *Binomial) Variance() float64 {
+	return float64(d.n) * d.p * (1.0 - d.p)
+}
+
+// A variate drawn for the smaller of p and 1 - p, and reflected if needed.
+func (d *Binomial) Rand(src rand.Source) int {
+	rnd := generator(src)
+	if d.p > 0.5 {
+		return d.n - binomialRand(d.n, 1.0-d.p, rnd)
+	}
+	return binomialRand(d.n, d.p, rnd)
+}
+
+// A binomial variate with p <= 0.5. For n p < 10, it's found by inversion, searching up
+// from 0 until the CDF reaches a uniform variate. Otherwise it's drawn by the transformed
+// rejection with squeeze of Hormann, the BTRS algorithm, whose expected cost is constant.
+// See:
+// W. Hormann, The generation of binomial random variates, Journal of Statistical
+// Computation and Simulation 46, 1993.
+func binomialRand(n int, p float64, rnd *rand.Rand) int {
+	q := 1.0 - p
+	nf := float64(n)
+	if nf*p < 10.0 {
+		// P(k) = P(k - 1) (n - k + 1)/k p/q
+		u := rnd.Float64()
+		k, pk := 0, math.Pow(q, nf)
+		for u > pk && k < n {
+			u -= pk
+			k++
+			pk *= float64(n-k+1) / float64(k) * p / q
+		}
+		return k
+	}
+	spq := math.Sqrt(nf * p * q)
+	b := 1.15 + 2.53*spq
+	a := -0.0873 + 0.0248*b + 0.01*p
+	c := nf*p + 0.5
+	vr := 0.92 - 4.2/b
+	alpha := (2.83 + 5.1/b) * spq
+	lpq := math.Log(p / q)
+	m := math.Floor((nf + 1.0) * p)
+	h := logGamma(m+1.0) + logGamma(nf-m+1.0)
+	for {
+		u := rnd.Float64() - 0.5
+		v := rnd.Float64()
+		us := 0.5 - math.Abs(u)
+		k := math.Floor((2.0*a/us+b)*u + c)
+		if k < 0.0 || k > nf {
+			continue
+		}
+		if us >= 0.07 && v <= vr {
+			return int(k)
+		}
+		if math.Log(v*alpha/(a/(us*us)+b)) <= h-logGamma(k+1.0)-logGamma(nf-k+1.0)+(k-m)*lpq {
+			return int(k)
+		}
+	}
+}
+
+//
+//
+// Poisson
+//
+//
+
+// The Poisson distribution of the events with the mean lambda.
+type Poisson struct {
+	lambda float64
+}
+
+func NewPoisson(lambda float64) *Poisson {
+	if !(lambda >= 0.0) || math.IsInf(lambda, 1) {
+		panic("lambda must be nonnegative and finite in NewPoisson()")
+	}
+	return &Poisson{lambda}
+}
+
+func (d *Poisson) PMF(k int) float64 {
+	return math.Exp(d.LogPMF(k))
+}
+
+func (d *Poisson) LogPMF(k int) float64 {
+	return logPoissonProbability(float64(k), d.lambda)
+}
+
+// P(X <= k) = Q(k + 1, lambda), and its complement.
+func (d *Poisson) tails(k int) (p, q float64) {
+	if k < 0 {
+		return 0.0, 1.0
+	}
+	q, p = incompleteGamma(float64(k)+1.0, d.lambda)
+	return p, q
+}
+
+func (d *Poisson) CDF(k int) float64 {
+	p, _ := d.tails(k)
+	return p
+}
+
+func (d *Poisson) Survival(k int) float64 {
+	_, q := d.tails(k)
+	return q
+}
+
+// The quantile at p. At p = 1, there is no finite quantile, so it's math.MaxInt.
+func (d *Poisson) Quantile(p float64) int {
+	checkProbability(p, "Poisson.Quantile()")
+	switch {
+	case p == 0.0:
+		return 0
+	case p == 1.0:
+		return math.MaxInt
+	}
+	guess := cornishFisherGuess(d.lambda, d.lambda, 1.0/math.Sqrt(d.lambda), p)
+	return discreteQuantile(p, d.tails, guess, 0, math.MaxInt)
+}
+
+func (d *Poisson) Mean() float64 {
+	return d.lambda
+}
+
+func (d *Poisson) Variance() float64 {
+	return d.lambda
+}
+
+func (d *Poisson) Rand(src rand.Source) int {
+	return poissonRand(d.lambda, generator(src))
+}
+
+// A Poisson variate. For lambda < 10, it's found by inversion, searching up from 0 until
+// the CDF reaches a uniform variate. Otherwise it's drawn by the transformed rejection
+// with squeeze of Hormann, the PTRS algorithm, whose expected cost is constant. See:
+// W. Hormann, The transformed rejection method for generating Poisson random variables,
+// Insurance: Mathematics and Economics 12(1), 1993.
+func poissonRand(lambda float64, rnd *rand.Rand) int {
+	if lambda < 10.0 {
+		// P(k) = P(k - 1) lambda/k
+		u := rnd.Float64()
+		k, pk := 0, math.Exp(-lambda)
+		for u > pk && pk > 0.0 {
+			u -= pk
+			k++
+			pk *= lambda / float64(k)
+		}
+		return k
+	}
+	logLambda := math.Log(lambda)
+	b := 0.931 + 2.53*math.Sqrt(lambda)
+	a := -0.059 + 0.02483*b
+	invAlpha := 1.1239 + 1.1328/(b-3.4)
+	vr := 0.9277 - 3.6224/(b-2.0)
+	for {
+		u := rnd.Float64() - 0.5
+		v := rnd.Float64()
+		us := 0.5 - math.Abs(u)
+		k := math.Floor((2.0*a/us+b)*u + lambda + 0.43)
+		if us >= 0.07 && v <= vr {
+			return int(k)
+		}
+		if k < 0.0 || (us < 0.013 && v > us) {
+			continue
+		}
+		if math.Log(v*invAlpha/(a/(us*us)+b)) <= -lambda+k*logLambda-logGamma(k+1.0) {
+			return int(k)
+		}
+	}
+}
+
+//
+//
+// Geometric
+//
+//
+
+// The geometric distribution of the failures before the first success, with the
+// probability p of success.
+type Geometric struct {
+	p float64
+}
+
+func NewGeometric(p float64) *Geometric {
+	if !(p > 0.0 && p <= 1.0) {
+		panic("p must be in (0, 1] in NewGeometric()")
+	}
+	return &Geometric{p}
+}
+
+func (d *Geometric) PMF(k int) float64 {
+	return math.Exp(d.LogPMF(k))
+}
+
+func (d *Geometric) LogPMF(k int) float64 {
+	switch {
+	case k < 0:
+		return math.Inf(-1)
+	case k == 0:
+		return math.Log(d.p)
+	}
+	return math.Log(d.p) + float64(k)*math.Log1p(-d.p)
+}
+
+// P(X > k) = (1 - p)^(k + 1), and its complement.
+func (d *Geometric) tails(k int) (p, q float64) {
+	if k < 0 {
+		return 0.0, 1.0
+	}
+	logQ := float64(k+1) * math.Log1p(-d.p)
+	return -math.Expm1(logQ), math.Exp(logQ)
+}
+
+func (d *Geometric) CDF(k int) float64 {
+	p, _ := d.tails(k)
+	return p
+}
+
+func (d *Geometric) Survival(k int) float64 {
+	_, q := d.tails(k)
+	return q
+}
+
+// The quantile at p, from the inverse of the continuous (1 - p)^(k + 1). At p = 1, there
+// is no finite quantile, so it's math.MaxInt.
+func (d *Geometric) Quantile(p float64) int {
+	checkProbability(p, "Geometric.Quantile()")
+	switch {
+	case p == 0.0 || d.p == 1.0:
+		return 0
+	case p == 1.0:
+		return math.MaxInt
+	}
+	guess := math.Ceil(math.Log1p(-p)/math.Log1p(-d.p) - 1.0)
+	return discreteQuantile(p, d.tails, guess, 0, math.MaxInt)
+}
+
+func (d *Geometric) Mean() float64 {
+	return (1.0 - d.p) / d.p
+}
+
+func (d *Geometric) Variance() float64 {
+	return (1.0 - d.p) / (d.p * d.p)
+}
+
+// A variate by inversion, the floor of an exponential variate over -log(1 - p). Like the
+// Quantile(), it's capped at math.MaxInt.
+func (d *Geometric) Rand(src rand.Source) int {
+	rnd := generator(src)
+	if d.p == 1.0 {
+		return 0
+	}
+	x := rnd.ExpFloat64() / -math.Log1p(-d.p)
+	if x >= float64(math.MaxInt) {
+		return math.MaxInt
+	}
+	return int(x)
+}
+
+//
+//
+// Negative Binomial
+//
+//
+
+// The negative binomial distribution of the failures before the size-th success, with
+// the probability p of success. The size needn't be an integer.
+type NegativeBinomial struct {
+	size, p float64
+}
+
+func NewNegativeBinomial(size, p float64) *NegativeBinomial {
+	if !(size > 0.0) || math.IsInf(size, 1) || !(p > 0.0 && p <= 1.0) {
+		panic("size must be positive and p in (0, 1] in NewNegativeBinomial()")
+	}
+	return &NegativeBinomial{size, p}
+}
+
+func (d *NegativeBinomial) PMF(k int) float64 {
+	return math.Exp(d.LogPMF(k))
+}
+
+// As dnbinom() in R, size/(size + k) times the binomial probability of size successes in
+// size + k trials.
+func (d *NegativeBinomial) LogPMF(k int) float64 {
+	if k < 0 {
+		return math.Inf(-1)
+	}
+	x := float64(k)
+	return math.Log(d.size/(d.size+x)) + logBinomialProbability(d.size, d.size+x, d.p, 1.0-d.p)
+}
+
+// P(X <= k) = I_p(size, k + 1), and its complement.
+func (d *NegativeBinomial) tails(k int) (p, q float64) {
+	if k < 0 {
+		return 0.0, 1.0
+	}
+	return incompleteBeta(d.size, float64(k)+1.0, d.p, 1.0-d.p)
+}
+
+func (d *NegativeBinomial) CDF(k int) float64 {
+	p, _ := d.tails(k)
+	return p
+}
+
+func (d *NegativeBinomial) Survival(k int) float64 {
+	_, q := d.tails(k)
+	return q
+}
+
+// The quantile at p. At p = 1, there is no finite quantile, so it's math.MaxInt.
+func (d *NegativeBinomial) Quantile(p float64) int {
+	checkProbability(p, "NegativeBinomial.Quantile()")
+	switch {
+	case p == 0.0 || d.p == 1.0:
+		return 0
+	case p == 1.0:
+		return math.MaxInt
+	}
+	skew := (2.0 - d.p) / math.Sqrt(d.size*(1.0-d.p))
+	return discreteQuantile(p, d.tails, cornishFisherGuess(d.Mean(), d.Variance(), skew, p), 0, math.MaxInt)
+}
+
+func (d *NegativeBinomial) Mean() float64 {
+	return d.size * (1.0 - d.p) / d.p
+}
+
+func (d *NegativeBinomial) Variance() float64 {
+	return d.size * (1.0 - d.p) / (d.p * d.p)
+}
+
+// A variate as a Poisson variate whose mean is a gamma variate with shape size and rate
+// p/(1 - p).
+func (d *NegativeBinomial) Rand(src rand.Source) int {
+	rnd := generator(src)
+	if d.p == 1.0 {
+		return 0
+	}
+	return poissonRand(math.Exp(logGammaRand(d.size, rnd))*(1.0-d.p)/d.p, rnd)
+}
+
+//
+//
+// Hypergeometric
+//
+//
+
+// The hypergeometric distribution of the white balls among k drawn without replacement
+// from an urn of m white and n black balls.
+type Hypergeometric struct {
+	m, n, k int
+}
+
+func NewHypergeometric(m, n, k int) *Hypergeometric {
+	if m < 0 || n < 0 || k < 0 || k > m+n {
+		panic("m and n must be nonnegative and k in [0, m + n] in NewHypergeometric()")
+	}
+	return &Hypergeometric{m, n, k}
+}
+
+// The smallest and largest possible counts.
+func (d *Hypergeometric) support() (lo, hi int) {
+	lo, hi = 0, d.k
+	if d.k > d.n {
+		lo = d.k - d.n
+	}
+	if d.m < d.k {
+		hi = d.m
+	}
+	return
+}
+
+func (d *Hypergeometric) PMF(x int) float64 {
+	return math.Exp(d.LogPMF(x))
+}
+
+// As dhyper() in R, from the binomial probabilities of x of the m white balls, k - x of
+// the n black balls, and k of all the balls, each drawn with probability k/(m + n).
+func (d *Hypergeometric) LogPMF(x int) float64 {
+	lo, hi := d.support()
+	switch {
+	case x < lo || x > hi:
+		return math.Inf(-1)
+	case d.k == 0 || d.k == d.m+d.n:
+		return 0.0
+	}
+	total := float64(d.m + d.n)
+	p := float64(d.k) / total
+	q := float64(d.m+d.n-d.k) / total
+	return logBinomialProbability(float64(x), float64(d.m), p, q) +
+		logBinomialProbability(float64(d.k-x), float64(d.n), p, q) -
+		logBinomialProbability(float64(d.k), total, p, q)
+}
+
+// P(X <= x) and P(X > x). The smaller tail, judged by the mean, is summed from x outward
+// using the ratios of successive probabilities, until the terms are negligible.
+func (d *Hypergeometric) tails(x int) (p, q float64) {
+	lo, hi := d.support()
+	switch {
+	case x < lo:
+		return 0.0, 1.0
+	case x >= hi:
+		return 1.0, 0.0
+	}
+	m, n, k := float64(d.m), float64(d.n), float64(d.k)
+	term, sum := 1.0, 1.0
+	if float64(x) < d.Mean() {
+		// P(j - 1) / P(j) = j (n - k + j) / ((m - j + 1) (k - j + 1))
+		for j := x; j > lo; j-- {
+			fj := float64(j)
+			term *= fj * (n - k + fj) / ((m - fj + 1.0) * (k - fj + 1.0))
+			sum += term
+			if term < epsilon*sum {
+				break
+			}
+		}
+		p = d.PMF(x) * sum
+		return p, 1.0 - p
+	}
+	// P(j + 1) / P(j) = (m - j) (k - j) / ((j + 1) (n - k + j + 1))
+	for j := x + 1; j < hi; j++ {
+		fj := float64(j)
+		term *= (m - fj) * (k - fj) / ((fj + 1.0) * (n - k + fj + 1.0))
+		sum += term
+		if term < epsilon*sum {
+			break
+		}
+	}
+	q = d.PMF(x+1) * sum
+	return 1.0 - q, q
+}
+
+func (d *Hypergeometric) CDF(x int) float64 {
+	p, _ := d.tails(x)
+	return p
+}
+
+func (d *Hypergeometric) Survival(x int) float64 {
+	_, q := d.tails(x)
+	return q
+}
+
+func (d *Hypergeometric) Quantile(p float64) int {
+	checkProbability(p, "Hypergeometric.Quantile()")
+	lo, hi := d.support()
+	switch {
+	case p == 0.0:
+		return lo
+	case p == 1.0:
+		return hi
+	}
+	return discreteQuantile(p, d.tails, cornishFisherGuess(d.Mean(), d.Variance(), 0.0, p), lo, hi)
+}
+
+func (d *Hypergeometric) Mean() float64 {
+	if d.m+d.n == 0 {
+		return 0.0
+	}
+	return float64(d.k) * float64(d.m) / float64(d.m+d.n)
+}
+
+func (d *Hypergeometric) Variance() float64 {
+	total := float64(d.m + d.n)
+	if total <= 1.0 {
+		return 0.0
+	}
+	k := float64(d.k)
+	return k * float64(d.m) / total * float64(d.n) / total * (total - k) / (total - 1.0)
+}
+
+// A variate by inversion, as the quantile of a uniform variate. Each one takes a quantile
+// search, and so several CDF evaluations, each summing a tail, so this is slow for
+// simulations of large urns.
+func (d *Hypergeometric) Rand(src rand.Source) int {
+	return d.Quantile(generator(src).Float64())
+}
@@ -0,0 +1,217 @@
+package stats
+
+//
+// discrete_test.go
+//
+// Author:   Gary Boone
+//
+// Test:
+//   go test stats.go stats_test.go specfunc.go distributions.go distributions_test.go discrete.go discrete_test.go
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// R test code:
+// print(c(pbinom(3, 10, 0.5), dbinom(0, 1000, 0.5), ppois(2, 3)), digits = 17)
+// print(c(dhyper(1, 5, 5, 3), phyper(1, 5, 5, 3)), digits = 17)
+//
+// The probabilities are also checked against their combinatorial formulas, and the CDFs
+// against sums of the probabilities.
+//
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// log(n choose k)
+func logChoose(n, k int) float64 {
+	return logGamma(float64(n+1)) - logGamma(float64(k+1)) - logGamma(float64(n-k+1))
+}
+
+// Check the CDF and survival against the sums of the PMF from the lower end of the
+// support, lo, up to hi, and that the quantiles are the smallest values with CDFs at
+// least p.
+func checkDiscrete(d DiscreteDistribution, lo, hi int, test string, t *testing.T) {
+	sum := 0.0
+	for k := lo; k <= hi; k++ {
+		sum += d.PMF(k)
+		checkFloat64(d.CDF(k), sum, 1e-12, test+" CDF", t)
+		if sum < 0.5 {
+			checkFloat64(d.Survival(k), 1.0-sum, 1e-12, test+" Survival", t)
+		}
+		checkFloat64Abs(d.CDF(k)+d.Survival(k), 1.0, 1e-15, test+" CDF + Survival", t)
+		if d.PMF(k) > 1e-300 {
+			checkFloat64(d.LogPMF(k), math.Log(d.PMF(k)), 1e-12, test+" LogPMF", t)
+		}
+	}
+	checkFloat64(d.CDF(lo-1), 0.0, 0.0, test+" CDF below", t)
+	checkFloat64(d.Survival(lo-1), 1.0, 0.0, test+" Survival below", t)
+	checkFloat64(d.PMF(lo-1), 0.0, 0.0, test+" PMF below", t)
+	for _, p := range []float64{1e-10, 0.001, 0.025, 0.1, 0.3, 0.5, 0.7, 0.9, 0.975, 0.999} {
+		k := d.Quantile(p)
+		if d.CDF(k) < p*(1.0-1e-12) || d.CDF(k-1) >= p {
+			t.Errorf("Found %v with CDFs %v and %v, but expected a quantile at %v for test %v",
+				k, d.CDF(k-1), d.CDF(k), p, test+" Quantile")
+		}
+	}
+	checkInt(d.Quantile(0.0), lo, test+" Quantile 0", t)
+}
+
+// Check that variates from a seeded source are reproduced, and that their mean and
+// variance are within 5 standard errors of the distribution's.
+func checkDiscreteRand(d DiscreteDistribution, test string, t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	var s Stats
+	counts := make(map[int]int)
+	for i := 0; i < 20000; i++ {
+		k := d.Rand(rnd)
+		s.Update(float64(k))
+		counts[k]++
+	}
+	// the count of each value is binomial, with a standard error of about sqrt(20000 P(k))
+	for k := d.Quantile(0.001); k <= d.Quantile(0.999); k++ {
+		expected := 20000.0 * d.PMF(k)
+		checkFloat64Abs(float64(counts[k]), expected, 5.0*math.Sqrt(expected)+1.0, test+" Rand frequency", t)
+	}
+	checkFloat64Abs(s.Mean(), d.Mean(), 5.0*math.Sqrt(d.Variance()/20000.0), test+" Rand mean", t)
+	checkFloat64(s.SampleVariance(), d.Variance(), 0.05, test+" Rand variance", t)
+	// a plain source gives the same variates as a *rand.Rand
+	a, b := rand.NewSource(3), rand.New(rand.NewSource(3))
+	for i := 0; i < 10; i++ {
+		checkInt(d.Rand(a), d.Rand(b), test+" Rand reproduced", t)
+	}
+}
+
+func TestBinomial(t *testing.T) {
+	checkFloat64(NewBinomial(10, 0.5).CDF(3), 0.171875, DIST_TOL, "Binomial CDF", t)
+	checkFloat64(NewBinomial(1000, 0.5).PMF(0), math.Pow(2.0, -1000.0), DIST_TOL, "Binomial PMF 0", t)
+	checkFloat64(NewBinomial(1000, 0.5).CDF(0), math.Pow(2.0, -1000.0), DIST_TOL, "Binomial CDF 0", t)
+	checkFloat64(NewBinomial(1000, 0.5).Survival(999), math.Pow(2.0, -1000.0), DIST_TOL, "Binomial Survival", t)
+	checkInt(NewBinomial(10, 0.5).Quantile(0.5), 5, "Binomial Quantile", t)
+	for _, c := range []struct {
+		n int
+		p float64
+	}{{10, 0.3}, {25, 0.5}, {40, 0.97}, {1, 0.2}} {
+		d := NewBinomial(c.n, c.p)
+		for k := 0; k <= c.n; k++ {
+			pmf := math.Exp(logChoose(c.n, k) + float64(k)*math.Log(c.p) + float64(c.n-k)*math.Log1p(-c.p))
+			checkFloat64(d.PMF(k), pmf, 1e-12, "Binomial PMF", t)
+		}
+		checkDiscrete(d, 0, c.n, "Binomial", t)
+		checkFloat64(d.CDF(c.n), 1.0, 0.0, "Binomial CDF n", t)
+		checkInt(d.Quantile(1.0), c.n, "Binomial Quantile 1", t)
+		checkFloat64(d.Mean(), float64(c.n)*c.p, TOL, "Binomial Mean", t)
+		checkFloat64(d.Variance(), float64(c.n)*c.p*(1.0-c.p), TOL, "Binomial Variance", t)
+	}
+	// with p at the ends, a point mass
+	checkFloat64(NewBinomial(5, 0.0).PMF(0), 1.0, 0.0, "Binomial PMF p=0", t)
+	checkFloat64(NewBinomial(5, 1.0).PMF(5), 1.0, 0.0, "Binomial PMF p=1", t)
+	checkInt(NewBinomial(5, 1.0).Quantile(0.5), 5, "Binomial Quantile p=1", t)
+	checkDiscreteRand(NewBinomial(20, 0.3), "Binomial", t)
+	checkDiscreteRand(NewBinomial(100000, 0.01), "Binomial", t)
+	checkDiscreteRand(NewBinomial(40, 0.8), "Binomial", t)
+	checkDiscreteRand(NewBinomial(300, 0.45), "Binomial", t)
+}
+
+func TestPoisson(t *testing.T) {
+	checkFloat64(NewPoisson(3.0).CDF(2), 8.5*math.Exp(-3.0), DIST_TOL, "Poisson CDF", t)
+	for _, lambda := range []float64{0.1, 3.0, 12.5, 100.0} {
+		d := NewPoisson(lambda)
+		for k := 0; k < 150; k++ {
+			pmf := math.Exp(-lambda + float64(k)*math.Log(lambda) - logGamma(float64(k+1)))
+			checkFloat64(d.PMF(k), pmf, 1e-12, "Poisson PMF", t)
+		}
+		checkDiscrete(d, 0, 150, "Poisson", t)
+		checkFloat64(d.Mean(), lambda, TOL, "Poisson Mean", t)
+		checkFloat64(d.Variance(), lambda, TOL, "Poisson Variance", t)
+	}
+	// far into the tails, the probabilities keep their relative precision
+	d := NewPoisson(1e6)
+	checkFloat64(d.PMF(1000000)*math.Sqrt(2.0*math.Pi*1e6), 1.0-1.0/12e6, 1e-12, "Poisson PMF mode", t)
+	checkFloat64(d.Survival(2000000), d.PMF(2000001)/(1.0-1e6/2000002.0), 1e-6, "Poisson Survival tail", t)
+	if d.Quantile(1.0) != math.MaxInt {
+		t.Errorf("Found %v, but expected math.MaxInt for test Poisson Quantile 1", d.Quantile(1.0))
+	}
+	checkInt(NewPoisson(0.0).Quantile(0.5), 0, "Poisson Quantile lambda=0", t)
+	checkDiscreteRand(NewPoisson(4.0), "Poisson", t)
+	checkDiscreteRand(NewPoisson(1e4), "Poisson", t)
+	checkDiscreteRand(NewPoisson(10.0), "Poisson", t)
+	checkDiscreteRand(NewPoisson(57.3), "Poisson", t)
+}
+
+func TestGeometric(t *testing.T) {
+	d := NewGeometric(0.2)
+	for k := 0; k < 100; k++ {
+		checkFloat64(d.PMF(k), 0.2*math.Pow(0.8, float64(k)), 1e-12, "Geometric PMF", t)
+		checkFloat64(d.Survival(k), math.Pow(0.8, float64(k+1)), 1e-12, "Geometric Survival", t)
+	}
+	checkDiscrete(d, 0, 100, "Geometric", t)
+	checkFloat64(d.Mean(), 4.0, TOL, "Geometric Mean", t)
+	checkFloat64(d.Variance(), 20.0, TOL, "Geometric Variance", t)
+	checkDiscrete(NewGeometric(1e-4), 0, 50, "Geometric", t)
+	checkInt(NewGeometric(1.0).Quantile(0.9), 0, "Geometric Quantile p=1", t)
+	checkDiscreteRand(d, "Geometric", t)
+}
+
+func TestNegativeBinomial(t *testing.T) {
+	// with size 1, it's geometric
+	g := NewGeometric(0.35)
+	d := NewNegativeBinomial(1.0, 0.35)
+	for k := 0; k < 50; k++ {
+		checkFloat64(d.PMF(k), g.PMF(k), 1e-12, "NegativeBinomial PMF geometric", t)
+		checkFloat64(d.CDF(k), g.CDF(k), 1e-12, "NegativeBinomial CDF geometric", t)
+	}
+	d = NewNegativeBinomial(4.0, 0.3)
+	for k := 0; k < 100; k++ {
+		pmf := math.Exp(logChoose(k+3, k) + 4.0*math.Log(0.3) + float64(k)*math.Log(0.7))
+		checkFloat64(d.PMF(k), pmf, 1e-12, "NegativeBinomial PMF", t)
+	}
+	checkDiscrete(d, 0, 100, "NegativeBinomial", t)
+	checkDiscrete(NewNegativeBinomial(2.5, 0.6), 0, 60, "NegativeBinomial", t)
+	checkFloat64(d.Mean(), 4.0*0.7/0.3, TOL, "NegativeBinomial Mean", t)
+	checkFloat64(d.Variance(), 4.0*0.7/0.09, TOL, "NegativeBinomial Variance", t)
+	checkDiscreteRand(d, "NegativeBinomial", t)
+}
+
+func TestHypergeometric(t *testing.T) {
+	d := NewHypergeometric(5, 5, 3)
+	checkFloat64(d.PMF(1), 5.0/12.0, DIST_TOL, "Hypergeometric PMF", t)
+	checkFloat64(d.CDF(1), 0.5, DIST_TOL, "Hypergeometric CDF", t)
+	for _, c := range [][3]int{{5, 5, 3}, {10, 30, 12}, {30, 10, 25}, {7, 2, 8}, {100, 900, 200}} {
+		d := NewHypergeometric(c[0], c[1], c[2])
+		lo, hi := d.support()
+		for x := lo; x <= hi; x++ {
+			pmf := math.Exp(logChoose(c[0], x) + logChoose(c[1], c[2]-x) - logChoose(c[0]+c[1], c[2]))
+			checkFloat64(d.PMF(x), pmf, 1e-11, "Hypergeometric PMF", t)
+		}
+		checkDiscrete(d, lo, hi, "Hypergeometric", t)
+		checkFloat64(d.CDF(hi), 1.0, 0.0, "Hypergeometric CDF hi", t)
+		checkInt(d.Quantile(1.0), hi, "Hypergeometric Quantile 1", t)
+	}
+	d = NewHypergeometric(10, 30, 12)
+	checkFloat64(d.Mean(), 3.0, TOL, "Hypergeometric Mean", t)
+	checkFloat64(d.Variance(), 12.0*0.25*0.75*28.0/39.0, TOL, "Hypergeometric Variance", t)
+	checkDiscreteRand(d, "Hypergeometric", t)
+	// a single possible count
+	checkFloat64(NewHypergeometric(4, 0, 3).PMF(3), 1.0, 0.0, "Hypergeometric PMF single", t)
+	checkInt(NewHypergeometric(4, 0, 3).Quantile(0.5), 3, "Hypergeometric Quantile single", t)
+}
+
+func TestDiscretePanics(t *testing.T) {
+	checkPanic := func(f func(), test string) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Found no panic for test %v", test)
+			}
+		}()
+		f()
+	}
+	checkPanic(func() { NewBinomial(-1, 0.5) }, "NewBinomial")
+	checkPanic(func() { NewBinomial(3, 1.5) }, "NewBinomial p")
+	checkPanic(func() { NewPoisson(-1.0) }, "NewPoisson")
+	checkPanic(func() { NewGeometric(0.0) }, "NewGeometric")
+	checkPanic(func() { NewNegativeBinomial(0.0, 0.5) }, "NewNegativeBinomial")
+	checkPanic(func() { NewHypergeometric(3, 3, 7) }, "NewHypergeometric")
+	checkPanic(func() { NewPoisson(1.0).Quantile(-0.1) }, "Quantile")
+}
@@ -0,0 +1,820 @@
+package stats
+
+//
+// distributions.go
+//
+// Author:   Gary Boone
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// Changes:
+//           20261017   initial version
+//
+// Source:
+// https://github.com/GaryBoone/GoStats
+//
+// Continuous probability distributions, with the parameters of R's d/p/q functions:
+//
+//   Normal(mean, sd)               dnorm, pnorm, qnorm
+//   StudentsT(df)                  dt, pt, qt
+//   ChiSquared(df)                 dchisq, pchisq, qchisq
+//   F(df1, df2)                    df, pf, qf
+//   Gamma(shape, rate)             dgamma, pgamma, qgamma
+//   Beta(shape1, shape2)           dbeta, pbeta, qbeta
+//   Exponential(rate)              dexp, pexp, qexp
+//   LogNormal(meanlog, sdlog)      dlnorm, plnorm, qlnorm
+//   Weibull(shape, scale)          dweibull, pweibull, qweibull
+//
+// Each has the density PDF(x) and its log LogPDF(x), the CDF(x) = P(X <= x), the
+// Survival(x) = P(X > x), which is computed directly so that small upper tail
+// probabilities keep their precision, the Quantile(p), the inverse of the CDF, and the
+// Mean() and Variance(). The CDFs are built on the incomplete gamma and beta functions of
+// specfunc.go, and the quantiles without closed forms are found by Newton's method.
+// Rand(src) draws a variate using the given random source, so that runs can be
+// reproduced. A *rand.Rand is itself a source; one created once and passed to every draw
+// is used directly, so the draws don't allocate.
+//
+// These turn the package's statistics into p-values. For example, the two-sided p-value
+// of a t statistic with df degrees of freedom is 2 * NewStudentsT(df).Survival(math.Abs(t)).
+//
+
+import (
+	"math"
+	"math/rand"
+)
+
+// The methods shared by the continuous distributions.
+type ContinuousDistribution interface {
+	PDF(x float64) float64
+	LogPDF(x float64) float64
+	CDF(x float64) float64
+	Survival(x float64) float64
+	Quantile(p float64) float64
+	Mean() float64
+	Variance() float64
+	Rand(src rand.Source) float64
+}
+
+// The generator that draws from the source. A *rand.Rand is used as it is, and any other
+// source is wrapped in a new one.
+func generator(src rand.Source) *rand.Rand {
+	if rnd, ok := src.(*rand.Rand); ok {
+		return rnd
+	}
+	return rand.New(src)
+}
+
+func checkProbability(p float64, caller string) {
+	if !(p >= 0.0 && p <= 1.0) {
+		panic("probability must be in [0, 1] in " + caller)
+	}
+}
+
+//
+//
+// Normal
+//
+//
+
+// The normal distribution with the given mean and standard deviation.
+type Normal struct {
+	mean, sd float64
+}
+
+func NewNormal(mean, sd float64) *Normal {
+	if !(sd > 0.0) {
+		panic("sd must be positive in NewNormal()")
+	}
+	return &Normal{mean, sd}
+}
+
+func (d *Normal) PDF(x float64) float64 {
+	return math.Exp(d.LogPDF(x))
+}
+
+func (d *Normal) LogPDF(x float64) float64 {
+	z := (x - d.mean) / d.sd
+	return -0.5*z*z - math.Log(d.sd) - lnSqrt2Pi
+}
+
+func (d *Normal) CDF(x float64) float64 {
+	p, _ := normalCDF((x - d.mean) / d.sd)
+	return p
+}
+
+func (d *Normal) Survival(x float64) float64 {
+	_, q := normalCDF((x - d.mean) / d.sd)
+	return q
+}
+
+func (d *Normal) Quantile(p float64) float64 {
+	checkProbability(p, "Normal.Quantile()")
+	return d.mean + d.sd*normalQuantile(p)
+}
+
+func (d *Normal) Mean() float64 {
+	return d.mean
+}
+
+func (d *Normal) Variance() float64 {
+	return d.sd * d.sd
+}
+
+func (d *Normal) Rand(src rand.Source) float64 {
+	return d.mean + d.sd*generator(src).NormFloat64()
+}
+
+//
+//
+// Student's t
+//
+//
+
+// Student's t distribution with df > 0 degrees of freedom, which needn't be an integer.
+type StudentsT struct {
+	df float64
+}
+
+func NewStudentsT(df float64) *StudentsT {
+	if !(df > 0.0) {
+		panic("df must be positive in NewStudentsT()")
+	}
+	return &StudentsT{df}
+}
+
+func (d *StudentsT) PDF(x float64) float64 {
+	return math.Exp(d.LogPDF(x))
+}
+
+func (d *StudentsT) LogPDF(x float64) float64 {
+	n := d.df
+	r := x / math.Sqrt(n)
+	l := math.Log1p(r * r)
+	if math.Abs(r) > tHugeRatio {
+		// r^2 would overflow
+		l = 2.0 * logRatio(x, n)
+	}
+	return -logBeta(0.5*n, 0.5) - 0.5*math.Log(n) - 0.5*(n+1.0)*l
+}
+
+// Beyond this |x|/sqrt(df), x^2/df is dropped against 1 in the t density and tails.
+const tHugeRatio = 1e150
+
+// log(|x|/sqrt(df)), which doesn't overflow with small df.
+func logRatio(x, df float64) float64 {
+	return math.Log(math.Abs(x)) - 0.5*math.Log(df)
+}
+
+// The probabilities below and above x. The tail beyond |x| is
+// I_{df/(df + x^2)}(df/2, 1/2) / 2. With 1 degree of freedom, it's the Cauchy tail
+// atan(1/|x|) / pi, which stays accurate where x^2 overflows. As pt() in R, with more than
+// 4e5 degrees of freedom, where the continued fraction loses precision, it's the normal
+// approximation of Abramowitz and Stegun 26.7.8. Far in the tails of small df, where x^2
+// overflows, it's the leading term of the beta series, (df/x^2)^(df/2) / (df B(df/2, 1/2)).
+func (d *StudentsT) tails(x float64) (p, q float64) {
+	var tail float64
+	r := x / math.Sqrt(d.df)
+	switch {
+	case d.df == 1.0:
+		tail = math.Atan(1.0/math.Abs(x)) / math.Pi
+	case d.df > 4e5:
+		v := 1.0 / (4.0 * d.df)
+		_, tail = normalCDF(math.Abs(x) * (1.0 - v) / math.Sqrt(1.0+2.0*x*x*v))
+	case math.Abs(r) > tHugeRatio:
+		a := 0.5 * d.df
+		tail = 0.5 * math.Exp(-d.df*logRatio(x, d.df)-math.Log(a)-logBeta(a, 0.5))
+	default:
+		yb, xb := oddsProbabilities(r * r)
+		tail, _ = incompleteBeta(0.5*d.df, 0.5, xb, yb)
+		tail *= 0.5
+	}
+	if x > 0.0 {
+		return 1.0 - tail, tail
+	}
+	return tail, 1.0 - tail
+}
+
+func (d *StudentsT) CDF(x float64) float64 {
+	p, _ := d.tails(x)
+	return p
+}
+
+func (d *StudentsT) Survival(x float64) float64 {
+	_, q := d.tails(x)
+	return q
+}
+
+// The quantile at p. With 1 and 2 degrees of freedom, it has closed forms. Otherwise,
+// the upper quantile of the smaller tail is found by Newton's method, from the normal
+// quantile. With df < 1, the tails are so heavy that the quantile can be beyond the
+// largest float64, and then it's -Inf or +Inf.
+func (d *StudentsT) Quantile(p float64) float64 {
+	checkProbability(p, "StudentsT.Quantile()")
+	switch {
+	case p == 0.0:
+		return math.Inf(-1)
+	case p == 1.0:
+		return math.Inf(1)
+	case p == 0.5:
+		return 0.0
+	case d.df == 1.0:
+		// Cauchy
+		if p < 0.5 {
+			return -1.0 / math.Tan(math.Pi*p)
+		}
+		return 1.0 / math.Tan(math.Pi*(1.0-p))
+	case d.df == 2.0:
+		return (2.0*p - 1.0) / math.Sqrt(2.0*p*(1.0-p))
+	}
+	// by symmetry, the x > 0 with the upper tail q = min(p, 1 - p)
+	q := math.Min(p, 1.0-p)
+	x := math.Inf(1)
+	if _, tail := d.tails(math.MaxFloat64); d.df > 1.0 || tail <= q {
+		x = invertTail(q, true, d.tails, d.PDF, 0.0, math.Inf(1), -normalQuantile(q))
+	}
+	if p < 0.5 {
+		return -x
+	}
+	return x
+}
+
+// The mean, 0 for df > 1, and NaN otherwise.
+func (d *StudentsT) Mean() float64 {
+	if d.df > 1.0 {
+		return 0.0
+	}
+	return math.NaN()
+}
+
+// The variance, df/(df - 2) for df > 2, +Inf for 1 < df <= 2, and NaN otherwise.
+func (d *StudentsT) Variance() float64 {
+	switch {
+	case d.df > 2.0:
+		return d.df / (d.df - 2.0)
+	case d.df > 1.0:
+		return math.Inf(1)
+	}
+	return math.NaN()
+}
+
+// A variate, as a standard normal over the root of a chi-squared variate over its df.
+func (d *StudentsT) Rand(src rand.Source) float64 {
+	rnd := generator(src)
+	z := rnd.NormFloat64()
+	return z / math.Sqrt(2.0*math.Exp(logGammaRand(0.5*d.df, rnd))/d.df)
+}
+
+//
+//
+// Gamma
+//
+//
+
+// The gamma distribution with the given shape and rate, 1/scale.
+type Gamma struct {
+	shape, rate float64
+}
+
+func NewGamma(shape, rate float64) *Gamma {
+	if !(shape > 0.0) || !(rate > 0.0) {
+		panic("shape and rate must be positive in NewGamma()")
+	}
+	return &Gamma{shape, rate}
+}
+
+func (d *Gamma) PDF(x float64) float64 {
+	return math.Exp(d.LogPDF(x))
+}
+
+func (d *Gamma) LogPDF(x float64) float64 {
+	switch {
+	case x < 0.0:
+		return math.Inf(-1)
+	case x == 0.0:
+		switch {
+		case d.shape < 1.0:
+			return math.Inf(1)
+		case d.shape == 1.0:
+			return math.Log(d.rate)
+		}
+		return math.Inf(-1)
+	}
+	// x^a e^-x / Gamma(a) at rate*x, divided by x
+	return logGammaPrefactor(d.shape, d.rate*x) - math.Log(x)
+}
+
+func (d *Gamma) CDF(x float64) float64 {
+	p, _ := incompleteGamma(d.shape, d.rate*x)
+	return p
+}
+
+func (d *Gamma) Survival(x float64) float64 {
+	_, q := incompleteGamma(d.shape, d.rate*x)
+	return q
+}
+
+// The quantile at p, by Newton's method from the Wilson-Hilferty approximation.
+func (d *Gamma) Quantile(p float64) float64 {
+	checkProbability(p, "Gamma.Quantile()")
+	switch {
+	case p == 0.0:
+		return 0.0
+	case p == 1.0:
+		return math.Inf(1)
+	}
+	return gammaQuantile(d.shape, p) / d.rate
+}
+
+// The quantile at p of the gamma distribution with rate 1.
+func gammaQuantile(shape, p float64) float64 {
+	// Wilson-Hilferty: (x/shape)^(1/3) is nearly normal
+	v := 1.0 / (9.0 * shape)
+	x := shape * math.Pow(1.0-v+normalQuantile(p)*math.Sqrt(v), 3.0)
+	if !(x > 0.0) || shape < 1.0 {
+		// for the lower tail, P(a, x) ~ x^a / Gamma(a + 1)
+		x = math.Exp((math.Log(p) + logGamma(shape+1.0)) / shape)
+	}
+	cdf := func(x float64) (float64, float64) { return incompleteGamma(shape, x) }
+	pdf := func(x float64) float64 { return math.Exp(logGammaPrefactor(shape, x)) / x }
+	return invertCDF(p, cdf, pdf, 0.0, math.Inf(1), x)
+}
+
+func (d *Gamma) Mean() float64 {
+	return d.shape / d.rate
+}
+
+func (d *Gamma) Variance() float64 {
+	return d.shape / (d.rate * d.rate)
+}
+
+func (d *Gamma) Rand(src rand.Source) float64 {
+	return math.Exp(logGammaRand(d.shape, generator(src))) / d.rate
+}
+
+// The log of a gamma variate with rate 1, by the method of Marsaglia and Tsang. For
+// shapes below 1, a variate with shape + 1 is multiplied by U^(1/shape), whose log is
+// kept so that tiny variates don't underflow. See:
+// G. Marsaglia and W.W. Tsang, A simple method for generating gamma variables,
+// ACM Trans. Math. Softw. 26(3), 2000.
+func logGammaRand(shape float64, rnd *rand.Rand) float64 {
+	if shape < 1.0 {
+		u := 1.0 - rnd.Float64() // in (0, 1]
+		return logGammaRand(shape+1.0, rnd) + math.Log(u)/shape
+	}
+	d := shape - 1.0/3.0
+	c := 1.0 / math.Sqrt(9.0*d)
+	for {
+		x := rnd.NormFloat64()
+		v := 1.0 + c*x
+		if v <= 0.0 {
+			continue
+		}
+		v = v * v * v
+		u := rnd.Float64()
+		x2 := x * x
+		if u < 1.0-0.0331*x2*x2 || math.Log(u) < 0.5*x2+d*(1.0-v+math.Log(v)) {
+			return math.Log(d * v)
+		}
+	}
+}
+
+//
+//
+// Chi-squared
+//
+//
+
+// The chi-squared distribution with df > 0 degrees of freedom, the gamma distribution
+// with shape df/2 and rate 1/2.
+type ChiSquared struct {
+	df    float64
+	gamma Gamma
+}
+
+func NewChiSquared(df float64) *ChiSquared {
+	if !(df > 0.0) {
+		panic("df must be positive in NewChiSquared()")
+	}
+	return &ChiSquared{df, Gamma{0.5 * df, 0.5}}
+}
+
+func (d *ChiSquared) PDF(x float64) float64 {
+	return d.gamma.PDF(x)
+}
+
+func (d *ChiSquared) LogPDF(x float64) float64 {
+	return d.gamma.LogPDF(x)
+}
+
+func (d *ChiSquared) CDF(x float64) float64 {
+	return d.gamma.CDF(x)
+}
+
+func (d *ChiSquared) Survival(x float64) float64 {
+	return d.gamma.Survival(x)
+}
+
+func (d *ChiSquared) Quantile(p float64) float64 {
+	checkProbability(p, "ChiSquared.Quantile()")
+	return d.gamma.Quantile(p)
+}
+
+func (d *ChiSquared) Mean() float64 {
+	return d.df
+}
+
+func (d *ChiSquared) Variance() float64 {
+	return 2.0 * d.df
+}
+
+func (d *ChiSquared) Rand(src rand.Source) float64 {
+	return d.gamma.Rand(src)
+}
+
+//
+//
+// Beta
+//
+//
+
+// The beta distribution on [0, 1] with the shapes a and b.
+type Beta struct {
+	a, b float64
+}
+
+func NewBeta(a, b float64) *Beta {
+	if !(a > 0.0) || !(b > 0.0) {
+		panic("shapes must be positive in NewBeta()")
+	}
+	return &Beta{a, b}
+}
+
+func (d *Beta) PDF(x float64) float64 {
+	return math.Exp(d.LogPDF(x))
+}
+
+func (d *Beta) LogPDF(x float64) float64 {
+	switch {
+	case x < 0.0 || x > 1.0:
+		return math.Inf(-1)
+	case x == 0.0:
+		return betaEndpoint(d.a, d.b)
+	case x == 1.0:
+		return betaEndpoint(d.b, d.a)
+	}
+	return (d.a-1.0)*math.Log(x) + (d.b-1.0)*math.Log1p(-x) - logBeta(d.a, d.b)
+}
+
+// The log density of the beta distribution at 0, given its shape there, a, and its
+// other shape, b.
+func betaEndpoint(a, b float64) float64 {
+	switch {
+	case a < 1.0:
+		return math.Inf(1)
+	case a == 1.0:
+		return -logBeta(a, b)
+	}
+	return math.Inf(-1)
+}
+
+func (d *Beta) CDF(x float64) float64 {
+	p, _ := incompleteBeta(d.a, d.b, x, 1.0-x)
+	return p
+}
+
+func (d *Beta) Survival(x float64) float64 {
+	_, q := incompleteBeta(d.a, d.b, x, 1.0-x)
+	return q
+}
+
+// The quantile at p, by Newton's method from the mean.
+func (d *Beta) Quantile(p float64) float64 {
+	checkProbability(p, "Beta.Quantile()")
+	switch {
+	case p == 0.0:
+		return 0.0
+	case p == 1.0:
+		return 1.0
+	}
+	cdf := func(x float64) (float64, float64) { return incompleteBeta(d.a, d.b, x, 1.0-x) }
+	return invertCDF(p, cdf, d.PDF, 0.0, 1.0, d.Mean())
+}
+
+func (d *Beta) Mean() float64 {
+	return d.a / (d.a + d.b)
+}
+
+func (d *Beta) Variance() float64 {
+	s := d.a + d.b
+	return d.a * d.b / (s * s * (s + 1.0))
+}
+
+// A variate, as X / (X + Y) of gamma variates with the shapes a and b.
+func (d *Beta) Rand(src rand.Source) float64 {
+	rnd := generator(src)
+	logX := logGammaRand(d.a, rnd)
+	logY := logGammaRand(d.b, rnd)
+	return 1.0 / (1.0 + math.Exp(logY-logX))
+}
+
+//
+//
+// F
+//
+//
+
+// The F distribution with df1 and df2 degrees of freedom, the distribution of the ratio
+// of chi-squared variables divided by their degrees of freedom.
+type F struct {
+	df1, df2 float64
+}
+
+func NewF(df1, df2 float64) *F {
+	if !(df1 > 0.0) || !(df2 > 0.0) {
+		panic("df1 and df2 must be positive in NewF()")
+	}
+	return &F{df1, df2}
+}
+
+func (d *F) PDF(x float64) float64 {
+	return math.Exp(d.LogPDF(x))
+}
+
+func (d *F) LogPDF(x float64) float64 {
+	m, n := d.df1, d.df2
+	switch {
+	case x < 0.0:
+		return math.Inf(-1)
+	case x == 0.0:
+		switch {
+		case m < 2.0:
+			return math.Inf(1)
+		case m == 2.0:
+			return 0.0
+		}
+		return math.Inf(-1)
+	}
+	// the beta density of m x / (m x + n), times its derivative
+	var logU, logV float64
+	if r := m * x / n; r <= 1.0 {
+		logU, logV = math.Log(r)-math.Log1p(r), -math.Log1p(r)
+	} else {
+		logU, logV = -math.Log1p(1.0/r), -math.Log(r)-math.Log1p(1.0/r)
+	}
+	return 0.5*m*logU + 0.5*n*logV - math.Log(x) - logBeta(0.5*m, 0.5*n)
+}
+
+func (d *F) CDF(x float64) float64 {
+	p, _ := d.tails(x)
+	return p
+}
+
+func (d *F) Survival(x float64) float64 {
+	_, q := d.tails(x)
+	return q
+}
+
+func (d *F) tails(x float64) (p, q float64) {
+	if x <= 0.0 {
+		return 0.0, 1.0
+	}
+	u, v := oddsProbabilities(d.df1 * x / d.df2)
+	return incompleteBeta(0.5*d.df1, 0.5*d.df2, u, v)
+}
+
+// The quantile at p, by Newton's method from 1.
+func (d *F) Quantile(p float64) float64 {
+	checkProbability(p, "F.Quantile()")
+	switch {
+	case p == 0.0:
+		return 0.0
+	case p == 1.0:
+		return math.Inf(1)
+	}
+	return invertCDF(p, d.tails, d.PDF, 0.0, math.Inf(1), 1.0)
+}
+
+// The mean, df2/(df2 - 2) for df2 > 2, and NaN otherwise.
+func (d *F) Mean() float64 {
+	if d.df2 > 2.0 {
+		return d.df2 / (d.df2 - 2.0)
+	}
+	return math.NaN()
+}
+
+// The variance, for df2 > 4, +Inf for 2 < df2 <= 4, and NaN otherwise.
+func (d *F) Variance() float64 {
+	m, n := d.df1, d.df2
+	switch {
+	case n > 4.0:
+		return 2.0 * n * n * (m + n - 2.0) / (m * (n - 2.0) * (n - 2.0) * (n - 4.0))
+	case n > 2.0:
+		return math.Inf(1)
+	}
+	return math.NaN()
+}
+
+// A variate, as the ratio of chi-squared variates over their degrees of freedom.
+func (d *F) Rand(src rand.Source) float64 {
+	rnd := generator(src)
+	logX := logGammaRand(0.5*d.df1, rnd)
+	logY := logGammaRand(0.5*d.df2, rnd)
+	return math.Exp(logX-logY) * d.df2 / d.df1
+}
+
+//
+//
+// Exponential
+//
+//
+
+// The exponential distribution with the given rate, 1/mean.
+type Exponential struct {
+	rate float64
+}
+
+func NewExponential(rate float64) *Exponential {
+	if !(rate > 0.0) {
+		panic("rate must be positive in NewExponential()")
+	}
+	return &Exponential{rate}
+}
+
+func (d *Exponential) PDF(x float64) float64 {
+	return math.Exp(d.LogPDF(x))
+}
+
+func (d *Exponential) LogPDF(x float64) float64 {
+	if x < 0.0 {
+		return math.Inf(-1)
+	}
+	return math.Log(d.rate) - d.rate*x
+}
+
+func (d *Exponential) CDF(x float64) float64 {
+	if x <= 0.0 {
+		return 0.0
+	}
+	return -math.Expm1(-d.rate * x)
+}
+
+func (d *Exponential) Survival(x float64) float64 {
+	if x <= 0.0 {
+		return 1.0
+	}
+	return math.Exp(-d.rate * x)
+}
+
+func (d *Exponential) Quantile(p float64) float64 {
+	checkProbability(p, "Exponential.Quantile()")
+	return -math.Log1p(-p) / d.rate
+}
+
+func (d *Exponential) Mean() float64 {
+	return 1.0 / d.rate
+}
+
+func (d *Exponential) Variance() float64 {
+	return 1.0 / (d.rate * d.rate)
+}
+
+func (d *Exponential) Rand(src rand.Source) float64 {
+	return generator(src).ExpFloat64() / d.rate
+}
+
+//
+//
+// Log-normal
+//
+//
+
+// The log-normal distribution, whose log is normal with the given mean and standard
+// deviation.
+type LogNormal struct {
+	meanlog, sdlog float64
+}
+
+func NewLogNormal(meanlog, sdlog float64) *LogNormal {
+	if !(sdlog > 0.0) {
+		panic("sdlog must be positive in NewLogNormal()")
+	}
+	return &LogNormal{meanlog, sdlog}
+}
+
+func (d *LogNormal) PDF(x float64) float64 {
+	return math.Exp(d.LogPDF(x))
+}
+
+func (d *LogNormal) LogPDF(x float64) float64 {
+	if x <= 0.0 {
+		return math.Inf(-1)
+	}
+	z := (math.Log(x) - d.meanlog) / d.sdlog
+	return -0.5*z*z - math.Log(x*d.sdlog) - lnSqrt2Pi
+}
+
+func (d *LogNormal) CDF(x float64) float64 {
+	if x <= 0.0 {
+		return 0.0
+	}
+	p, _ := normalCDF((math.Log(x) - d.meanlog) / d.sdlog)
+	return p
+}
+
+func (d *LogNormal) Survival(x float64) float64 {
+	if x <= 0.0 {
+		return 1.0
+	}
+	_, q := normalCDF((math.Log(x) - d.meanlog) / d.sdlog)
+	return q
+}
+
+func (d *LogNormal) Quantile(p float64) float64 {
+	checkProbability(p, "LogNormal.Quantile()")
+	return math.Exp(d.meanlog + d.sdlog*normalQuantile(p))
+}
+
+func (d *LogNormal) Mean() float64 {
+	return math.Exp(d.meanlog + 0.5*d.sdlog*d.sdlog)
+}
+
+func (d *LogNormal) Variance() float64 {
+	s2 := d.sdlog * d.sdlog
+	return math.Expm1(s2) * math.Exp(2.0*d.meanlog+s2)
+}
+
+func (d *LogNormal) Rand(src rand.Source) float64 {
+	return math.Exp(d.meanlog + d.sdlog*generator(src).NormFloat64())
+}
+
+//
+//
+// Weibull
+//
+//
+
+// The Weibull distribution with the given shape and scale.
+type Weibull struct {
+	shape, scale float64
+}
+
+func NewWeibull(shape, scale float64) *Weibull {
+	if !(shape > 0.0) || !(scale > 0.0) {
+		panic("shape and scale must be positive in NewWeibull()")
+	}
+	return &Weibull{shape, scale}
+}
+
+func (d *Weibull) PDF(x float64) float64 {
+	return math.Exp(d.LogPDF(x))
+}
+
+func (d *Weibull) LogPDF(x float64) float64 {
+	k := d.shape
+	switch {
+	case x < 0.0:
+		return math.Inf(-1)
+	case x == 0.0:
+		switch {
+		case k < 1.0:
+			return math.Inf(1)
+		case k == 1.0:
+			return -math.Log(d.scale)
+		}
+		return math.Inf(-1)
+	}
+	z := x / d.scale
+	return math.Log(k/d.scale) + (k-1.0)*math.Log(z) - math.Pow(z, k)
+}
+
+func (d *Weibull) CDF(x float64) float64 {
+	if x <= 0.0 {
+		return 0.0
+	}
+	return -math.Expm1(-math.Pow(x/d.scale, d.shape))
+}
+
+func (d *Weibull) Survival(x float64) float64 {
+	if x <= 0.0 {
+		return 1.0
+	}
+	return math.Exp(-math.Pow(x/d.scale, d.shape))
+}
+
+func (d *Weibull) Quantile(p float64) float64 {
+	checkProbability(p, "Weibull.Quantile()")
+	return d.scale * math.Pow(-math.Log1p(-p), 1.0/d.shape)
+}
+
+func (d *Weibull) Mean() float64 {
+	return d.scale * math.Gamma(1.0+1.0/d.shape)
+}
+
+func (d *Weibull) Variance() float64 {
+	g1 := math.Gamma(1.0 + 1.0/d.shape)
+	return d.scale * d.scale * (math.Gamma(1.0+2.0/d.shape) - g1*g1)
+}
+
+func (d *Weibull) Rand(src rand.Source) float64 {
+	return d.scale * math.Pow(generator(src).ExpFloat64(), 1.0/d.shape)
+}
@@ -0,0 +1,553 @@
+package stats
+
+//
+// distributions_test.go
+//
+// Author:   Gary Boone
+//
+// Test:
+//   go test stats.go stats_test.go specfunc.go distributions.go distributions_test.go
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// R test code:
+// print(c(qnorm(0.975), qnorm(0.95), qnorm(1e-10), pnorm(1), dnorm(0)), digits = 17)
+// print(c(qt(0.975, 10), qchisq(0.95, 1)), digits = 17)
+//
+// print(c(pt(c(-40, -8, -1.3, -1e5, -12, -3, -0.25, -60), c(2.5, 7.3, 0.7, 1.5, 30.7, 400.5, 12.5, 55.5)),
+//   pt(c(2.2, 37, 5.5), c(15.5, 4.5, 120.25), lower.tail = FALSE)), digits = 17)
+// print(c(pf(c(0.37, 1e-6, 2.1, 0.05), c(3.5, 2.7, 0.6, 40.5), c(7.2, 9.1, 0.9, 12.5)),
+//   pf(c(25, 400, 3.3), c(4.5, 1.5, 20.5), c(11.3, 30.5, 60.5), lower.tail = FALSE)), digits = 17)
+// print(c(pgamma(c(0.001, 1e-10, 4500, 0.5, 12), c(2.7, 0.37, 5000.5, 0.05, 7.5), c(1.3, 1, 1, 2, 0.8)),
+//   pgamma(c(80, 250.5, 700, 5600), c(3.3, 200.3, 0.6, 5000.5), c(0.9, 1, 1, 1), lower.tail = FALSE)),
+//   digits = 17)
+// print(c(pbeta(c(0.001, 0.2, 1e-8, 0.6, 0.35), c(2.5, 150.5, 0.35, 0.45, 12.5), c(3.7, 60.25, 4.5, 0.55, 17.5)),
+//   pbeta(c(0.995, 0.97, 0.9), c(4.2, 30.5, 150.5), c(1.3, 0.8, 60.25), lower.tail = FALSE)), digits = 17)
+// print(qgamma(c(1e-50, 0.025, 0.5, 1 - 1e-12, 1e-5, 0.9), c(2.7, 0.37, 200.3, 3.3, 5000.5, 0.05),
+//   c(1.3, 1, 2, 0.9, 1, 1)), digits = 17)
+// print(qbeta(c(1e-30, 0.3, 0.975, 1 - 1e-9, 0.6, 1e-100), c(2.5, 0.35, 150.5, 4.2, 0.45, 0.8),
+//   c(3.7, 4.5, 60.25, 1.3, 0.55, 30.5)), digits = 17)
+// print(qt(c(0.01, 0.3, 0.99, 0.9), c(0.01, 0.01, 0.1, 0.005)), digits = 17)
+//
+// The R code gives the quantities of the fixtures, but their values were computed to 60
+// digits for the exact float64 arguments by an independent arbitrary-precision
+// implementation of the incomplete beta and gamma functions, from their series and continued
+// fractions, and then rounded to 17 digits.
+//
+// Most of the distributions are also checked against closed forms:
+//   t with 1, 2 and 3 df -- the Cauchy distribution and its relatives
+//   chi-squared with even df and gamma with integer shape -- sums of Poisson probabilities
+//   beta with integer shapes -- sums of binomial probabilities
+//   F with df1 = 2 -- 1 - (1 + 2x/df2)^(-df2/2)
+//
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+const DIST_TOL = 1e-12
+
+// the probabilities for checking quantiles, from the far lower to the far upper tail
+var distProbs = []float64{1e-300, 1e-100, 1e-20, 1e-10, 1e-5, 0.001, 0.025, 0.1, 0.3, 0.5,
+	0.7, 0.9, 0.975, 0.999, 1.0 - 1e-5, 1.0 - 1e-10}
+
+// Check that the quantiles invert the CDF, with the smaller tail compared relatively. The
+// tolerance is scaled by the condition number of the tail, x f(x) / p, since rounding x to
+// float64 changes the tail by that many times its relative error. Quantiles that underflow
+// to 0 are skipped.
+func checkQuantiles(d ContinuousDistribution, tol float64, test string, t *testing.T) {
+	for _, p := range distProbs {
+		x := d.Quantile(p)
+		if x == 0.0 && d.CDF(math.SmallestNonzeroFloat64) > p {
+			continue
+		}
+		tail, found := p, d.CDF(x)
+		if p > 0.5 {
+			tail, found = 1.0-p, d.Survival(x)
+		}
+		cond := math.Max(1.0, math.Abs(x)*d.PDF(x)/tail)
+		checkFloat64(found, tail, tol*cond, test+" Quantile", t)
+	}
+}
+
+// Check that the density is the derivative of the smaller tail, that the log density is
+// its log, and that the tails sum to 1.
+func checkDensity(d ContinuousDistribution, xs []float64, test string, t *testing.T) {
+	for _, x := range xs {
+		h := 1e-6 * math.Max(1e-3, math.Abs(x))
+		deriv := (d.CDF(x+h) - d.CDF(x-h)) / (2.0 * h)
+		if d.CDF(x) > 0.5 {
+			deriv = (d.Survival(x-h) - d.Survival(x+h)) / (2.0 * h)
+		}
+		checkFloat64(d.PDF(x), deriv, 1e-6, test+" PDF", t)
+		if d.PDF(x) > 1e-300 {
+			checkFloat64(d.LogPDF(x), math.Log(d.PDF(x)), 1e-12, test+" LogPDF", t)
+		}
+		checkFloat64Abs(d.CDF(x)+d.Survival(x), 1.0, 1e-15, test+" CDF + Survival", t)
+	}
+}
+
+func TestNormal(t *testing.T) {
+	d := NewNormal(0.0, 1.0)
+	checkFloat64(d.Quantile(0.975), 1.959963984540054, DIST_TOL, "Normal Quantile", t)
+	checkFloat64(d.Quantile(0.95), 1.6448536269514722, DIST_TOL, "Normal Quantile", t)
+	checkFloat64(d.Quantile(1e-10), -6.361340902404056, DIST_TOL, "Normal Quantile", t)
+	checkFloat64(d.CDF(1.0), 0.8413447460685429, DIST_TOL, "Normal CDF", t)
+	checkFloat64(d.PDF(0.0), 0.3989422804014327, DIST_TOL, "Normal PDF", t)
+	checkFloat64(d.Quantile(0.5), 0.0, 0.0, "Normal Quantile", t)
+	if !math.IsInf(d.Quantile(0.0), -1) || !math.IsInf(d.Quantile(1.0), 1) {
+		t.Errorf("Found %v and %v, but expected -Inf and +Inf for test Normal Quantile", d.Quantile(0.0),
+			d.Quantile(1.0))
+	}
+	// by symmetry
+	checkFloat64(d.Survival(5.0), d.CDF(-5.0), DIST_TOL, "Normal Survival", t)
+	checkQuantiles(d, 1e-14, "Normal", t)
+
+	d = NewNormal(10.0, 2.0)
+	checkFloat64(d.Quantile(0.975), 10.0+2.0*1.959963984540054, DIST_TOL, "Normal Quantile", t)
+	checkFloat64(d.Mean(), 10.0, TOL, "Normal Mean", t)
+	checkFloat64(d.Variance(), 4.0, TOL, "Normal Variance", t)
+	checkDensity(d, []float64{-3.0, 9.0, 10.0, 14.5}, "Normal", t)
+}
+
+func TestStudentsT(t *testing.T) {
+	checkFloat64(NewStudentsT(10.0).Quantile(0.975), 2.228138851986274, DIST_TOL, "StudentsT Quantile", t)
+	for _, x := range []float64{0.01, 0.5, 1.0, 3.0, 10.0, 1e3} {
+		// the upper tails of t1, t2 and t3
+		s := math.Sqrt(2.0 + x*x)
+		r := x / math.Sqrt(3.0)
+		tails := []float64{
+			math.Atan(1.0/x) / math.Pi,
+			1.0 / (s * (s + x)),
+			0.5 - (r/(1.0+r*r)+math.Atan(r))/math.Pi,
+		}
+		for df := 1; df <= 3; df++ {
+			d := NewStudentsT(float64(df))
+			if df < 3 || x <= 10.0 {
+				checkFloat64(d.Survival(x), tails[df-1], DIST_TOL, "StudentsT Survival", t)
+				checkFloat64(d.CDF(-x), tails[df-1], DIST_TOL, "StudentsT CDF", t)
+			}
+			checkFloat64(d.CDF(x), 1.0-tails[df-1], DIST_TOL, "StudentsT CDF", t)
+		}
+	}
+	for _, df := range []float64{1, 2, 3, 4.5, 10, 30, 1000, 1e6} {
+		d := NewStudentsT(df)
+		checkQuantiles(d, 1e-12, "StudentsT", t)
+		checkDensity(d, []float64{-4.0, -0.3, 0.0, 1.2, 8.0}, "StudentsT", t)
+		checkFloat64(d.CDF(0.0), 0.5, DIST_TOL, "StudentsT CDF 0", t)
+	}
+	// with many df, it's nearly normal
+	checkFloat64(NewStudentsT(1e12).Quantile(0.975), 1.959963984540054, 1e-10, "StudentsT Quantile normal", t)
+
+	checkFloat64(NewStudentsT(5.0).Mean(), 0.0, 0.0, "StudentsT Mean", t)
+	checkFloat64(NewStudentsT(5.0).Variance(), 5.0/3.0, TOL, "StudentsT Variance", t)
+	checkNaN(NewStudentsT(1.0).Mean(), "StudentsT Mean 1", t)
+	if !math.IsInf(NewStudentsT(1.5).Variance(), 1) {
+		t.Errorf("Found %v, but expected +Inf for test StudentsT Variance 1.5", NewStudentsT(1.5).Variance())
+	}
+}
+
+// With df < 1, the tails are so heavy that x^2 overflows before the quantiles are reached,
+// and the quantiles can be beyond the largest float64. The quantile fixtures are the qt()s
+// above.
+func TestStudentsTSmallDF(t *testing.T) {
+	for _, df := range []float64{0.001, 0.01, 0.1, 0.5, 0.9} {
+		d := NewStudentsT(df)
+		for _, p := range distProbs {
+			x := d.Quantile(p)
+			tail, found := p, d.CDF(x)
+			if p > 0.5 {
+				tail, found = 1.0-p, d.Survival(x)
+			}
+			if math.IsInf(x, 0) {
+				// then even the largest float64 is short of the quantile
+				found = d.Survival(math.MaxFloat64)
+				if !(found > tail) {
+					t.Errorf("Found %v, but expected a finite quantile for test StudentsT Quantile %v", x, p)
+				}
+				continue
+			}
+			checkFloat64(found, tail, DIST_TOL, "StudentsT Quantile small df", t)
+		}
+		checkDensity(d, []float64{-1e200, -3e7, 0.5, 1e300}, "StudentsT small df", t)
+	}
+	checkFloat64(NewStudentsT(0.01).Quantile(0.01), -3.9604401371524817e+168, 1e-12, "StudentsT Quantile 0.01", t)
+	checkFloat64(NewStudentsT(0.01).Quantile(0.3), -7.6845418704473371e+20, 1e-12, "StudentsT Quantile 0.01", t)
+	checkFloat64(NewStudentsT(0.1).Quantile(0.99), 16044257056665690, 1e-12, "StudentsT Quantile 0.1", t)
+	checkFloat64(NewStudentsT(0.005).Quantile(0.9), 2.2046802212591586e+138, 1e-12, "StudentsT Quantile 0.005", t)
+	checkInf(NewStudentsT(0.001).Quantile(0.99), "StudentsT Quantile 0.001", t)
+	checkInf(-NewStudentsT(0.001).Quantile(0.01), "StudentsT Quantile 0.001", t)
+}
+
+// The upper tail of the gamma distribution with integer shape k and rate 1, the Poisson
+// probability of fewer than k events: e^-x sum(x^j / j!, j < k).
+func erlangSurvival(k int, x float64) float64 {
+	term, sum := 1.0, 1.0
+	for j := 1; j < k; j++ {
+		term *= x / float64(j)
+		sum += term
+	}
+	return math.Exp(-x) * sum
+}
+
+// Its complement, the Poisson probability of at least k events: e^-x sum(x^j / j!, j >= k).
+func erlangCDF(k int, x float64) float64 {
+	term := math.Exp(-x)
+	for j := 1; j <= k; j++ {
+		term *= x / float64(j)
+	}
+	sum := 0.0
+	for j := k + 1; term > sum*1e-17; j++ {
+		sum += term
+		term *= x / float64(j)
+	}
+	return sum
+}
+
+func TestChiSquared(t *testing.T) {
+	checkFloat64(NewChiSquared(1.0).Quantile(0.95), 3.841458820694124, DIST_TOL, "ChiSquared Quantile", t)
+	checkFloat64(NewChiSquared(2.0).Quantile(0.95), -2.0*math.Log(0.05), DIST_TOL, "ChiSquared Quantile", t)
+	for _, df := range []int{2, 4, 10, 50} {
+		d := NewChiSquared(float64(df))
+		for _, x := range []float64{0.1, 1.0, 5.0, 20.0, 100.0, 500.0} {
+			checkFloat64(d.Survival(x), erlangSurvival(df/2, x/2.0), DIST_TOL, "ChiSquared Survival", t)
+			checkFloat64(d.CDF(x), erlangCDF(df/2, x/2.0), DIST_TOL, "ChiSquared CDF", t)
+		}
+		checkFloat64(d.Mean(), float64(df), TOL, "ChiSquared Mean", t)
+		checkFloat64(d.Variance(), 2.0*float64(df), TOL, "ChiSquared Variance", t)
+	}
+	// with 1 df, the square of a standard normal
+	d := NewChiSquared(1.0)
+	for _, x := range []float64{1e-6, 0.5, 3.0, 30.0} {
+		checkFloat64(d.Survival(x), math.Erfc(math.Sqrt(x/2.0)), DIST_TOL, "ChiSquared 1 Survival", t)
+		checkFloat64(d.CDF(x), math.Erf(math.Sqrt(x/2.0)), DIST_TOL, "ChiSquared 1 CDF", t)
+	}
+	for _, df := range []float64{0.5, 1, 3, 17.5, 1000} {
+		checkQuantiles(NewChiSquared(df), 1e-13, "ChiSquared", t)
+		checkDensity(NewChiSquared(df), []float64{0.05, 1.0, df, 3.0 * df}, "ChiSquared", t)
+	}
+}
+
+func TestGamma(t *testing.T) {
+	for _, k := range []int{1, 3, 12} {
+		d := NewGamma(float64(k), 2.5)
+		for _, x := range []float64{0.01, 0.4, 2.0, 10.0} {
+			checkFloat64(d.Survival(x), erlangSurvival(k, 2.5*x), DIST_TOL, "Gamma Survival", t)
+		}
+		checkFloat64(d.Mean(), float64(k)/2.5, TOL, "Gamma Mean", t)
+		checkFloat64(d.Variance(), float64(k)/6.25, TOL, "Gamma Variance", t)
+	}
+	// shape 1 is exponential
+	checkFloat64(NewGamma(1.0, 2.5).Quantile(0.3), -math.Log1p(-0.3)/2.5, DIST_TOL, "Gamma Quantile", t)
+	for _, shape := range []float64{0.01, 0.1, 0.5, 1, 3.7, 50, 1000, 1e5} {
+		d := NewGamma(shape, 0.5)
+		checkQuantiles(d, 1e-13, "Gamma", t)
+		checkDensity(d, []float64{0.1 * shape, shape, 2.0 * shape, 5.0 * shape}, "Gamma", t)
+	}
+	checkFloat64(NewGamma(0.5, 1.0).Quantile(0.0), 0.0, 0.0, "Gamma Quantile 0", t)
+	if !math.IsInf(NewGamma(0.5, 1.0).Quantile(1.0), 1) {
+		t.Errorf("Found %v, but expected +Inf for test Gamma Quantile 1", NewGamma(0.5, 1.0).Quantile(1.0))
+	}
+}
+
+// The binomial probabilities of at least and fewer than a successes of a + b - 1 trials,
+// which are I_x(a, b) and its complement for integer a and b.
+func binomialTails(a, b int, x float64) (p, q float64) {
+	n := a + b - 1
+	for j := 0; j <= n; j++ {
+		c := math.Exp(logGamma(float64(n+1)) - logGamma(float64(j+1)) - logGamma(float64(n-j+1)))
+		v := c * math.Pow(x, float64(j)) * math.Pow(1.0-x, float64(n-j))
+		if j >= a {
+			p += v
+		} else {
+			q += v
+		}
+	}
+	return
+}
+
+func TestBeta(t *testing.T) {
+	for _, ab := range [][2]int{{1, 1}, {2, 3}, {5, 2}, {10, 10}, {1, 7}, {30, 4}} {
+		d := NewBeta(float64(ab[0]), float64(ab[1]))
+		for _, x := range []float64{0.01, 0.3, 0.5, 0.9, 0.999} {
+			p, q := binomialTails(ab[0], ab[1], x)
+			checkFloat64(d.CDF(x), p, DIST_TOL, "Beta CDF", t)
+			checkFloat64(d.Survival(x), q, DIST_TOL, "Beta Survival", t)
+		}
+	}
+	// closed forms of the quantiles with a shape of 1
+	for _, p := range []float64{1e-10, 0.2, 0.5, 0.95} {
+		checkFloat64(NewBeta(1.0, 4.0).Quantile(p), -math.Expm1(math.Log1p(-p)/4.0), DIST_TOL, "Beta Quantile", t)
+		checkFloat64(NewBeta(3.0, 1.0).Quantile(p), math.Pow(p, 1.0/3.0), DIST_TOL, "Beta Quantile", t)
+	}
+	for _, ab := range [][2]float64{{0.5, 0.5}, {0.1, 3}, {2, 3}, {40, 0.7}, {200, 300}} {
+		d := NewBeta(ab[0], ab[1])
+		checkQuantiles(d, 1e-13, "Beta", t)
+		checkDensity(d, []float64{0.05, 0.3, 0.5, 0.8}, "Beta", t)
+	}
+	d := NewBeta(2.0, 3.0)
+	checkFloat64(d.Mean(), 0.4, TOL, "Beta Mean", t)
+	checkFloat64(d.Variance(), 0.04, TOL, "Beta Variance", t)
+	checkFloat64(d.PDF(0.0), 0.0, 0.0, "Beta PDF 0", t)
+	checkFloat64(NewBeta(1.0, 3.0).PDF(0.0), 3.0, TOL, "Beta PDF 0", t)
+	checkFloat64(d.CDF(-1.0), 0.0, 0.0, "Beta CDF", t)
+	checkFloat64(d.CDF(2.0), 1.0, 0.0, "Beta CDF", t)
+}
+
+func TestF(t *testing.T) {
+	for _, n := range []float64{1, 4, 10, 37} {
+		d := NewF(2.0, n)
+		for _, x := range []float64{0.01, 0.5, 1.0, 4.0, 100.0} {
+			checkFloat64(d.Survival(x), math.Pow(1.0+2.0*x/n, -n/2.0), DIST_TOL, "F Survival", t)
+		}
+		for _, p := range []float64{1e-10, 0.05, 0.5, 0.95, 0.999} {
+			checkFloat64(d.Quantile(p), n/2.0*math.Expm1(-2.0/n*math.Log1p(-p)), DIST_TOL, "F Quantile", t)
+		}
+		checkFloat64(d.PDF(0.0), 1.0, TOL, "F PDF 0", t)
+	}
+	for _, mn := range [][2]float64{{1, 1}, {3, 10}, {10, 3}, {5.5, 200}, {100, 100}} {
+		d := NewF(mn[0], mn[1])
+		checkQuantiles(d, 1e-13, "F", t)
+		checkDensity(d, []float64{0.1, 0.9, 1.5, 6.0}, "F", t)
+		// the beta distribution of df1 x / (df1 x + df2)
+		b := NewBeta(mn[0]/2.0, mn[1]/2.0)
+		checkFloat64(d.CDF(2.0), b.CDF(2.0*mn[0]/(2.0*mn[0]+mn[1])), DIST_TOL, "F CDF", t)
+	}
+	d := NewF(3.0, 10.0)
+	checkFloat64(d.Mean(), 1.25, TOL, "F Mean", t)
+	checkFloat64(d.Variance(), 2.0*100.0*11.0/(3.0*64.0*6.0), TOL, "F Variance", t)
+	checkNaN(NewF(3.0, 2.0).Mean(), "F Mean", t)
+}
+
+// Fixtures at non-integer parameters and in the far tails, from the R code above. Each
+// row is the point, the parameters and the probability or quantile. The lower-tail rows
+// are checked with CDF() and the upper-tail rows with Survival().
+type distFixture struct {
+	x, a, b, expected float64
+}
+
+var ptLower = []distFixture{
+	{-40.0, 2.5, 0, 7.0978171452466913e-05},
+	{-8.0, 7.3, 0, 3.6309866040957217e-05},
+	{-1.3, 0.7, 0, 0.23916265103958992},
+	{-100000.0, 1.5, 0, 1.1924482404598361e-08},
+	{-12.0, 30.7, 0, 2.0015129141800516e-13},
+	{-3.0, 400.5, 0, 0.0014342473755194361},
+	{-0.25, 12.5, 0, 0.40332443390001654},
+	{-60.0, 55.5, 0, 1.8296586949658716e-52},
+}
+
+var ptUpper = []distFixture{
+	{2.2, 15.5, 0, 0.021676737583903184},
+	{37.0, 4.5, 0, 4.575846063963205e-07},
+	{5.5, 120.25, 0, 1.0863350980171034e-07},
+}
+
+var pfLower = []distFixture{
+	{0.37, 3.5, 7.2, 0.19729391696906198},
+	{1e-06, 2.7, 9.1, 1.0396650373733199e-08},
+	{2.1, 0.6, 0.9, 0.65166978710265711},
+	{0.05, 40.5, 12.5, 1.8279357347412315e-13},
+}
+
+var pfUpper = []distFixture{
+	{25.0, 4.5, 11.3, 1.165036529021084e-05},
+	{400.0, 1.5, 30.5, 3.6196258655715773e-21},
+	{3.3, 20.5, 60.5, 0.00016161621315527883},
+}
+
+var pgammaLower = []distFixture{
+	{0.001, 2.7, 1.3, 3.8639468377281977e-09},
+	{1e-10, 0.37, 1.0, 0.0002243598346642745},
+	{4500.0, 5000.5, 1.0, 1.2034789877737469e-13},
+	{0.5, 0.05, 2.0, 0.98847634705146004},
+	{12.0, 7.5, 0.8, 0.79518647640265105},
+}
+
+var pgammaUpper = []distFixture{
+	{80.0, 3.3, 0.9, 3.8714224666765292e-28},
+	{250.5, 200.3, 1.0, 0.0004652089582761301},
+	{700.0, 0.6, 1.0, 4.8153176394177181e-306},
+	{5600.0, 5000.5, 1.0, 1.5999674169853525e-16},
+}
+
+var pbetaLower = []distFixture{
+	{0.001, 2.5, 3.7, 3.8575457146340964e-07},
+	{0.2, 150.5, 60.25, 1.3170174063212464e-58},
+	{1e-08, 0.35, 4.5, 0.0029349056453918548},
+	{0.6, 0.45, 0.55, 0.62089231902605158},
+	{0.35, 12.5, 17.5, 0.23303408691401162},
+}
+
+var pbetaUpper = []distFixture{
+	{0.995, 4.2, 1.3, 0.0058471940007266071},
+	{0.97, 30.5, 0.8, 0.69410744036663852},
+	{0.9, 150.5, 60.25, 2.8499777305782272e-14},
+}
+
+// the x of the quantile fixtures is the probability
+var qgammaFixtures = []distFixture{
+	{1e-50, 2.7, 1.3, 3.9558816387520307e-19},
+	{0.025, 0.37, 1.0, 3.4074496183775429e-05},
+	{0.5, 200.3, 2.0, 99.983382731999711},
+	{1.0 - 1e-12, 3.3, 0.9, 38.754992474770212},
+	{1e-05, 5000.5, 1.0, 4704.6223456052776},
+	{0.9, 0.05, 1.0, 0.076317113909188508},
+}
+
+var qbetaFixtures = []distFixture{
+	{1e-30, 2.5, 3.7, 3.6740108848710769e-13},
+	{0.3, 0.35, 4.5, 0.0055949512293210118},
+	{0.975, 150.5, 60.25, 0.77296556539511441},
+	{1.0 - 1e-9, 4.2, 1.3, 0.99999996905414212},
+	{0.6, 0.45, 0.55, 0.56668880661386234},
+	{1e-100, 0.8, 30.5, 3.009748461637743e-127},
+}
+
+func checkFixtures(fixtures []distFixture, f func(x, a, b float64) float64, tol float64,
+	test string, t *testing.T) {
+	for _, c := range fixtures {
+		checkFloat64(f(c.x, c.a, c.b), c.expected, tol, test, t)
+	}
+}
+
+func TestDistributionFixtures(t *testing.T) {
+	checkFixtures(ptLower, func(x, df, _ float64) float64 { return NewStudentsT(df).CDF(x) },
+		DIST_TOL, "pt", t)
+	checkFixtures(ptUpper, func(x, df, _ float64) float64 { return NewStudentsT(df).Survival(x) },
+		DIST_TOL, "pt upper", t)
+	// the t tails are symmetric
+	checkFixtures(ptLower, func(x, df, _ float64) float64 { return NewStudentsT(df).Survival(-x) },
+		DIST_TOL, "pt symmetric", t)
+	checkFixtures(pfLower, func(x, m, n float64) float64 { return NewF(m, n).CDF(x) },
+		DIST_TOL, "pf", t)
+	checkFixtures(pfUpper, func(x, m, n float64) float64 { return NewF(m, n).Survival(x) },
+		DIST_TOL, "pf upper", t)
+	checkFixtures(pgammaLower, func(x, shape, rate float64) float64 { return NewGamma(shape, rate).CDF(x) },
+		DIST_TOL, "pgamma", t)
+	checkFixtures(pgammaUpper, func(x, shape, rate float64) float64 { return NewGamma(shape, rate).Survival(x) },
+		DIST_TOL, "pgamma upper", t)
+	checkFixtures(pbetaLower, func(x, a, b float64) float64 { return NewBeta(a, b).CDF(x) },
+		DIST_TOL, "pbeta", t)
+	checkFixtures(pbetaUpper, func(x, a, b float64) float64 { return NewBeta(a, b).Survival(x) },
+		DIST_TOL, "pbeta upper", t)
+	checkFixtures(qgammaFixtures, func(p, shape, rate float64) float64 { return NewGamma(shape, rate).Quantile(p) },
+		DIST_TOL, "qgamma", t)
+	checkFixtures(qbetaFixtures, func(p, a, b float64) float64 { return NewBeta(a, b).Quantile(p) },
+		DIST_TOL, "qbeta", t)
+}
+
+func TestExponential(t *testing.T) {
+	d := NewExponential(0.5)
+	checkFloat64(d.CDF(2.0), 1.0-math.Exp(-1.0), DIST_TOL, "Exponential CDF", t)
+	checkFloat64(d.Survival(2.0), math.Exp(-1.0), DIST_TOL, "Exponential Survival", t)
+	checkFloat64(d.Quantile(0.5), 2.0*math.Ln2, DIST_TOL, "Exponential Quantile", t)
+	checkFloat64(d.PDF(0.0), 0.5, TOL, "Exponential PDF", t)
+	checkFloat64(d.PDF(-1.0), 0.0, 0.0, "Exponential PDF", t)
+	checkFloat64(d.Mean(), 2.0, TOL, "Exponential Mean", t)
+	checkFloat64(d.Variance(), 4.0, TOL, "Exponential Variance", t)
+	checkQuantiles(d, 1e-14, "Exponential", t)
+	checkDensity(d, []float64{0.1, 1.0, 10.0}, "Exponential", t)
+}
+
+func TestLogNormal(t *testing.T) {
+	d := NewLogNormal(1.0, 0.5)
+	n := NewNormal(1.0, 0.5)
+	for _, x := range []float64{0.1, 1.0, 2.7, 20.0} {
+		checkFloat64(d.CDF(x), n.CDF(math.Log(x)), DIST_TOL, "LogNormal CDF", t)
+		checkFloat64(d.Survival(x), n.Survival(math.Log(x)), DIST_TOL, "LogNormal Survival", t)
+		checkFloat64(d.PDF(x), n.PDF(math.Log(x))/x, DIST_TOL, "LogNormal PDF", t)
+	}
+	checkFloat64(d.Quantile(0.5), math.E, DIST_TOL, "LogNormal Quantile", t)
+	checkFloat64(d.Mean(), math.Exp(1.125), TOL, "LogNormal Mean", t)
+	checkFloat64(d.Variance(), (math.Exp(0.25)-1.0)*math.Exp(2.25), TOL, "LogNormal Variance", t)
+	checkQuantiles(d, 1e-14, "LogNormal", t)
+	checkDensity(d, []float64{0.5, 2.0, 8.0}, "LogNormal", t)
+}
+
+func TestWeibull(t *testing.T) {
+	d := NewWeibull(2.0, 3.0)
+	checkFloat64(d.CDF(3.0), 1.0-math.Exp(-1.0), DIST_TOL, "Weibull CDF", t)
+	checkFloat64(d.Survival(6.0), math.Exp(-4.0), DIST_TOL, "Weibull Survival", t)
+	checkFloat64(d.Quantile(1.0-math.Exp(-1.0)), 3.0, DIST_TOL, "Weibull Quantile", t)
+	checkFloat64(d.Mean(), 1.5*math.Sqrt(math.Pi), TOL, "Weibull Mean", t)
+	checkFloat64(d.Variance(), 9.0*(1.0-math.Pi/4.0), TOL, "Weibull Variance", t)
+	checkQuantiles(d, 1e-14, "Weibull", t)
+	checkDensity(d, []float64{0.5, 3.0, 7.0}, "Weibull", t)
+	// shape 1 is exponential
+	checkFloat64(NewWeibull(1.0, 2.0).PDF(0.0), 0.5, TOL, "Weibull PDF 0", t)
+}
+
+func TestDistributionPanics(t *testing.T) {
+	checkPanic := func(f func(), test string) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Found no panic for test %v", test)
+			}
+		}()
+		f()
+	}
+	checkPanic(func() { NewNormal(0.0, 0.0) }, "NewNormal")
+	checkPanic(func() { NewStudentsT(-1.0) }, "NewStudentsT")
+	checkPanic(func() { NewGamma(1.0, math.NaN()) }, "NewGamma")
+	checkPanic(func() { NewBeta(0.0, 1.0) }, "NewBeta")
+	checkPanic(func() { NewNormal(0.0, 1.0).Quantile(1.5) }, "Quantile")
+}
+
+// Check that variates from a seeded source are reproduced, that their mean is within 5
+// standard errors of the distribution's, and that about half fall below the median.
+func checkRand(d ContinuousDistribution, test string, t *testing.T) {
+	const n = 20000
+	rnd := rand.New(rand.NewSource(1))
+	var s Stats
+	below := 0
+	median := d.Quantile(0.5)
+	for i := 0; i < n; i++ {
+		x := d.Rand(rnd)
+		s.Update(x)
+		if x < median {
+			below++
+		}
+	}
+	if v := d.Variance(); !math.IsInf(v, 1) && !math.IsNaN(v) {
+		checkFloat64Abs(s.Mean(), d.Mean(), 5.0*math.Sqrt(v/n), test+" Rand mean", t)
+	}
+	// the binomial standard error of the fraction below is 0.5/sqrt(n)
+	checkFloat64Abs(float64(below)/n, 0.5, 5.0*0.5/math.Sqrt(n), test+" Rand median", t)
+	a, b := rand.New(rand.NewSource(3)), rand.New(rand.NewSource(3))
+	for i := 0; i < 10; i++ {
+		checkFloat64(d.Rand(a), d.Rand(b), 0.0, test+" Rand reproduced", t)
+	}
+}
+
+func TestRand(t *testing.T) {
+	checkRand(NewNormal(3.0, 2.0), "Normal", t)
+	checkRand(NewStudentsT(1.0), "StudentsT", t)
+	checkRand(NewStudentsT(7.5), "StudentsT", t)
+	checkRand(NewChiSquared(3.0), "ChiSquared", t)
+	checkRand(NewGamma(0.3, 2.0), "Gamma", t)
+	checkRand(NewGamma(40.0, 0.5), "Gamma", t)
+	checkRand(NewBeta(0.5, 2.0), "Beta", t)
+	checkRand(NewBeta(30.0, 10.0), "Beta", t)
+	checkRand(NewF(4.0, 12.0), "F", t)
+	checkRand(NewExponential(3.0), "Exponential", t)
+	checkRand(NewLogNormal(0.5, 0.8), "LogNormal", t)
+	checkRand(NewWeibull(1.5, 2.0), "Weibull", t)
+	// tiny shapes don't underflow to 0/0
+	// a *rand.Rand is drawn from directly, and a plain source gives the same variates
+	d := NewGamma(2.5, 1.0)
+	rnd := rand.New(rand.NewSource(5))
+	if allocs := testing.AllocsPerRun(100, func() { d.Rand(rnd) }); allocs != 0 {
+		t.Errorf("Found %v, but expected 0 allocations for test Rand *rand.Rand", allocs)
+	}
+	src, wrapped := rand.NewSource(7), rand.New(rand.NewSource(7))
+	for i := 0; i < 10; i++ {
+		checkFloat64(d.Rand(src), d.Rand(wrapped), 0.0, "Rand source", t)
+	}
+	x := NewBeta(0.001, 0.001).Rand(rand.NewSource(1))
+	if !(x >= 0.0 && x <= 1.0) {
+		t.Errorf("Found %v, but expected a value in [0, 1] for test Beta Rand tiny", x)
+	}
+}
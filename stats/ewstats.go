@@ -0,0 +1,183 @@
+package stats
+
+//
+// ewstats.go
+//
+// Author:   Gary Boone
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// Changes:
+//           20261017   initial version
+//
+// Source:
+// https://github.com/GaryBoone/GoStats
+//
+// EWStats provides exponentially weighted moving statistics, which favor recent values.
+// Each value's weight decays geometrically as newer values arrive:
+//
+// 1. By count -- created with NewEWStats(alpha), each new value multiplies the weights of
+//    the earlier values by (1 - alpha). NewEWStatsCountHalfLife(n) sets alpha so the
+//    weights halve every n values. Times given to UpdateAt() are ignored.
+// 2. By time -- created with NewEWStatsHalfLife(halfLife), each UpdateAt(t, x) halves the
+//    weights of the earlier values for every halfLife elapsed. This suits irregularly
+//    spaced samples. A value without a time, given to Update(), is taken to arrive with
+//    the latest timed value, so nothing decays.
+//
+// The mean and variance are the weighted mean and variance of all of the values seen so
+// far. After a few multiples of 1/alpha updates, they follow the usual EWMA and EWMVar
+// recursions,
+//   mean += alpha * (x - mean)
+//   variance = (1 - alpha) * (variance + alpha * (x - mean)^2)
+// but the first values aren't biased toward an arbitrary starting mean.
+//
+// The weighted mean and variance are updated with West's weighted form of Welford's
+// method. See:
+// D.H.D. West, Updating mean and variance estimates: an improved method, CACM 22(9), 1979.
+//
+
+import (
+	"math"
+	"time"
+)
+
+// Data structure to contain the decaying weights and moments
+type EWStats struct {
+	alpha    float64       // decay per Update()
+	halfLife time.Duration // decay per elapsed time in UpdateAt()
+	n        float64       // number of values
+	last     time.Time     // time of the latest value given to UpdateAt(), or zero
+	w, w2    float64       // sum of the weights and of the squared weights
+	mean, m2 float64       // weighted mean and sum of weighted squared deviations
+}
+
+// Create an EWStats whose Update() gives each new value weight alpha relative to the
+// total, 0 < alpha <= 1. Smaller values of alpha give smoother statistics.
+func NewEWStats(alpha float64) *EWStats {
+	if !(alpha > 0.0 && alpha <= 1.0) {
+		panic("alpha must be in (0, 1] in NewEWStats()")
+	}
+	return &EWStats{alpha: alpha}
+}
+
+// Create an EWStats whose Update() halves the weight of a value every halfLife values,
+// halfLife > 0. It's NewEWStats(1 - 2^(-1/halfLife)).
+func NewEWStatsCountHalfLife(halfLife float64) *EWStats {
+	if !(halfLife > 0.0) {
+		panic("halfLife must be positive in NewEWStatsCountHalfLife()")
+	}
+	return &EWStats{alpha: -math.Expm1(-math.Ln2 / halfLife)}
+}
+
+// Create an EWStats whose UpdateAt() halves the weight of a value every halfLife.
+func NewEWStatsHalfLife(halfLife time.Duration) *EWStats {
+	if halfLife <= 0 {
+		panic("halfLife must be positive in NewEWStatsHalfLife()")
+	}
+	return &EWStats{halfLife: halfLife}
+}
+
+//
+//
+// Accessor Functions
+//
+//
+
+func (e *EWStats) Count() int {
+	return int(e.n)
+}
+
+func (e *EWStats) Size() int {
+	return int(e.n)
+}
+
+func (e *EWStats) Mean() float64 {
+	return e.mean
+}
+
+//
+//
+// Incremental Functions
+//
+//
+
+// Update the stats with the given value. If the weights decay with time, the value is
+// taken to arrive with the latest timed value, so the earlier weights don't decay.
+func (e *EWStats) Update(x float64) {
+	if e.halfLife != 0 {
+		e.add(x, 1.0, 1.0)
+		return
+	}
+	e.add(x, 1.0-e.alpha, 1.0)
+}
+
+// Update the stats with the given array of values.
+func (e *EWStats) UpdateArray(data []float64) {
+	for _, v := range data {
+		e.Update(v)
+	}
+}
+
+// Update the stats with the value x observed at time t. The weights of the earlier values
+// decay according to the time elapsed since the latest one. A value older than the
+// latest one is included with the weight it would have had, had it arrived in order.
+// Values given to Update() before the first timed value are taken to arrive with it. If
+// the weights decay by count, the time is ignored, as in Update().
+func (e *EWStats) UpdateAt(t time.Time, x float64) {
+	if e.halfLife == 0 {
+		e.Update(x)
+		return
+	}
+	if e.last.IsZero() {
+		e.last = t
+	}
+	dt := t.Sub(e.last)
+	if dt >= 0 {
+		e.last = t
+		e.add(x, e.decay(dt), 1.0)
+	} else {
+		e.add(x, 1.0, e.decay(-dt))
+	}
+}
+
+// The factor by which a weight decays over the duration dt.
+func (e *EWStats) decay(dt time.Duration) float64 {
+	return math.Exp2(-float64(dt) / float64(e.halfLife))
+}
+
+// Scale the existing weights by decay, then add x with weight wx.
+func (e *EWStats) add(x, decay, wx float64) {
+	e.n++
+	e.w *= decay
+	e.w2 *= decay * decay
+	e.m2 *= decay
+	e.w += wx
+	e.w2 += wx * wx
+	delta := x - e.mean
+	e.mean += delta * wx / e.w
+	e.m2 += wx * delta * (x - e.mean)
+}
+
+func (e *EWStats) PopulationVariance() float64 {
+	if e.n == 0 || e.n == 1 {
+		return math.NaN()
+	}
+	return e.m2 / e.w
+}
+
+// The sample variance treats the weights as reliability weights, so it's unbiased for
+// independent values from a distribution with a fixed variance.
+func (e *EWStats) SampleVariance() float64 {
+	if e.n == 0 || e.n == 1 {
+		return math.NaN()
+	}
+	return e.m2 / (e.w - e.w2/e.w)
+}
+
+func (e *EWStats) PopulationStandardDeviation() float64 {
+	return math.Sqrt(e.PopulationVariance())
+}
+
+func (e *EWStats) SampleStandardDeviation() float64 {
+	return math.Sqrt(e.SampleVariance())
+}
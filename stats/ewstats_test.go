@@ -0,0 +1,113 @@
+package stats
+
+//
+// ewstats_test.go
+//
+// Author:   Gary Boone
+//
+// Test:
+//   go test stats.go stats_test.go ewstats.go ewstats_test.go
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// The exponentially weighted stats are compared against the weighted mean and variance
+// computed directly from the values and their decayed weights. In R:
+//   a=c(1,-2,13,47,115); w=(1-alpha)^((length(a)-1):0)
+//   m=weighted.mean(a,w); v=sum(w*(a-m)^2)/sum(w); v*sum(w)^2/(sum(w)^2-sum(w^2))
+//
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+const EW_TOL = 1e-13
+
+// With no updates, these are the results on initialization
+func TestEWStats0(t *testing.T) {
+	e := NewEWStats(0.5)
+	checkInt(e.Count(), 0, "Count", t)
+	checkFloat64(e.Mean(), 0.0, EW_TOL, "Mean", t)
+	checkNaN(e.PopulationVariance(), "PopulationVariance", t)
+	checkNaN(e.SampleVariance(), "SampleVariance", t)
+	checkNaN(e.PopulationStandardDeviation(), "PopulationStandardDeviation", t)
+	checkNaN(e.SampleStandardDeviation(), "SampleStandardDeviation", t)
+}
+
+func TestEWStats1(t *testing.T) {
+	e := NewEWStats(0.5)
+	e.Update(2.3)
+	checkInt(e.Count(), 1, "Count", t)
+	checkFloat64(e.Mean(), 2.3, EW_TOL, "Mean", t)
+	checkNaN(e.PopulationVariance(), "PopulationVariance", t)
+	checkNaN(e.SampleVariance(), "SampleVariance", t)
+}
+
+// With alpha = 0.5, the weights of the 5 values are 1/16, 1/8, 1/4, 1/2, 1.
+func TestEWStats5(t *testing.T) {
+	e := NewEWStats(0.5)
+	e.UpdateArray([]float64{1.0, -2.0, 13.0, 47.0, 115.0})
+	checkInt(e.Count(), 5, "Count", t)
+	checkFloat64(e.Mean(), 2265.0/31.0, EW_TOL, "Mean", t)
+	checkFloat64(e.PopulationVariance(), 1998442.0/961.0, EW_TOL, "PopulationVariance", t)
+	checkFloat64(e.SampleVariance(), 999221.0/310.0, EW_TOL, "SampleVariance", t)
+	checkFloat64(e.PopulationStandardDeviation(), math.Sqrt(1998442.0/961.0), EW_TOL,
+		"PopulationStandardDeviation", t)
+	checkFloat64(e.SampleStandardDeviation(), math.Sqrt(999221.0/310.0), EW_TOL,
+		"SampleStandardDeviation", t)
+}
+
+// With alpha = 1, only the latest value counts.
+func TestEWStatsAlpha1(t *testing.T) {
+	e := NewEWStats(1.0)
+	e.UpdateArray([]float64{1.0, -2.0, 13.0})
+	checkFloat64(e.Mean(), 13.0, EW_TOL, "Mean", t)
+	checkFloat64Abs(e.PopulationVariance(), 0.0, EW_TOL, "PopulationVariance", t)
+}
+
+// After a long run, the stats follow the usual EWMA and EWMVar recursions.
+func TestEWStatsRecursion(t *testing.T) {
+	alpha := 0.1
+	e := NewEWStats(alpha)
+	mean, variance := 0.0, 0.0
+	for i := 0; i < 2000; i++ {
+		x := math.Sin(float64(i)) * 10.0
+		e.Update(x)
+		diff := x - mean
+		mean += alpha * diff
+		variance = (1.0 - alpha) * (variance + alpha*diff*diff)
+	}
+	checkFloat64(e.Mean(), mean, 1e-12, "Mean", t)
+	checkFloat64(e.PopulationVariance(), variance, 1e-12, "PopulationVariance", t)
+}
+
+// Irregularly spaced values. With a half-life of 1 minute, the values at 0, 1, 3 and 4
+// minutes have weights 1/16, 1/8, 1/2 and 1 at 4 minutes. The mean is
+// (1/16*4 + 1/8*8 + 1/2*1 + 10) / (27/16) = 188/27.
+func TestEWStatsUpdateAt(t *testing.T) {
+	e := NewEWStatsHalfLife(time.Minute)
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	e.UpdateAt(t0, 4.0)
+	e.UpdateAt(t0.Add(1*time.Minute), 8.0)
+	e.UpdateAt(t0.Add(3*time.Minute), 1.0)
+	e.UpdateAt(t0.Add(4*time.Minute), 10.0)
+	checkInt(e.Count(), 4, "Count", t)
+	checkFloat64(e.Mean(), 188.0/27.0, EW_TOL, "Mean", t)
+	checkFloat64(e.PopulationVariance(), 11960.0/729.0, EW_TOL, "PopulationVariance", t)
+	// sum(w)^2 / (sum(w)^2 - sum(w^2)) = 729 / (729 - 325)
+	checkFloat64(e.SampleVariance(), 2990.0/101.0, EW_TOL, "SampleVariance", t)
+}
+
+// A value that arrives out of order gets the same weight it would have had in order.
+func TestEWStatsUpdateAtOutOfOrder(t *testing.T) {
+	e := NewEWStatsHalfLife(time.Minute)
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	e.UpdateAt(t0, 4.0)
+	e.UpdateAt(t0.Add(3*time.Minute), 1.0)
+	e.UpdateAt(t0.Add(4*time.Minute), 10.0)
+	e.UpdateAt(t0.Add(1*time.Minute), 8.0)
+	checkFloat64(e.Mean(), 188.0/27.0, EW_TOL, "Mean", t)
+	checkFloat64(e.PopulationVariance(), 11960.0/729.0, EW_TOL, "PopulationVariance", t)
+	checkFloat64(e.SampleVariance(), 2990.0/101.0, EW_TOL, "SampleVariance", t)
+}
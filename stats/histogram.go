@@ -0,0 +1,271 @@
+package stats
+
+//
+// histogram.go
+//
+// Author:   Gary Boone
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// Changes:
+//           20261017   initial version
+//
+// Source:
+// https://github.com/GaryBoone/GoStats
+//
+// Histogram counts values in buckets to show the shape of their distribution. The
+// buckets are given by increasing bounds b[0] < b[1] < ... < b[m], bucket i holding the
+// values in [b[i], b[i+1]). Values below b[0] are counted as underflow, and values at or
+// above b[m] as overflow. The bounds can be laid out in several ways:
+//
+// 1. Fixed width -- NewLinearHistogram(min, width, n), n buckets of the same width.
+// 2. Explicit -- NewHistogram(bounds), any increasing bounds.
+// 3. Exponential -- NewExponentialHistogram(start, factor, n), each bucket factor times
+//    as wide as the one before, for values such as latencies.
+// 4. Log-linear -- NewLogLinearHistogram(min, max, subBuckets), each power of 2 divided
+//    into subBuckets buckets of the same width, as in HdrHistogram. The relative width of
+//    the buckets is at most 1/subBuckets, so it's a fixed number of significant digits.
+//
+// Quantiles are estimated by linear interpolation within the bucket holding the quantile,
+// as if its values were spread evenly over it. The buckets are clipped to the exact min
+// and max, so the underflow and overflow are spread over [min, b[0]) and [b[m], max].
+//
+// A Stats of the values is kept alongside the buckets, so the exact mean and variance are
+// also available.
+//
+// Infinities are counted as underflow or overflow. A quantile that falls among them is
+// infinite. NaNs can't be placed in a bucket, so they panic.
+//
+
+import (
+	"math"
+	"sort"
+)
+
+// structure to contain the bounds, the counts and the stats of the values
+type Histogram struct {
+	bounds      []float64
+	counts      []int
+	under, over int
+	stats       Stats
+}
+
+// Create a Histogram with the given bounds, which must be increasing. There are
+// len(bounds) - 1 buckets.
+func NewHistogram(bounds []float64) *Histogram {
+	if len(bounds) < 2 {
+		panic("at least 2 bounds are needed in NewHistogram()")
+	}
+	for i := 1; i < len(bounds); i++ {
+		if !(bounds[i] > bounds[i-1]) {
+			panic("bounds must be increasing in NewHistogram()")
+		}
+	}
+	return &Histogram{
+		bounds: append([]float64(nil), bounds...),
+		counts: make([]int, len(bounds)-1),
+	}
+}
+
+// Create a Histogram with n buckets of the given width, starting at min.
+func NewLinearHistogram(min, width float64, n int) *Histogram {
+	if !(width > 0.0) || n < 1 {
+		panic("width must be positive and n at least 1 in NewLinearHistogram()")
+	}
+	bounds := make([]float64, n+1)
+	for i := range bounds {
+		bounds[i] = min + float64(i)*width
+	}
+	return NewHistogram(bounds)
+}
+
+// Create a Histogram with n buckets whose bounds are start, start*factor,
+// start*factor^2, ..., start*factor^n.
+func NewExponentialHistogram(start, factor float64, n int) *Histogram {
+	if !(start > 0.0) || !(factor > 1.0) || n < 1 {
+		panic("start must be positive, factor greater than 1 and n at least 1 in NewExponentialHistogram()")
+	}
+	bounds := make([]float64, n+1)
+	for i := range bounds {
+		bounds[i] = start * math.Pow(factor, float64(i))
+	}
+	return NewHistogram(bounds)
+}
+
+// Create a Histogram covering [min, max], 0 < min < max, whose powers of 2 are each
+// divided into subBuckets buckets of the same width. The bounds start at the power of 2
+// at or below min and end at the first bound at or above max.
+func NewLogLinearHistogram(min, max float64, subBuckets int) *Histogram {
+	if !(min > 0.0) || !(max > min) || subBuckets < 1 {
+		panic("need 0 < min < max and subBuckets at least 1 in NewLogLinearHistogram()")
+	}
+	power := math.Exp2(math.Floor(math.Log2(min)))
+	bounds := []float64{power}
+	for bounds[len(bounds)-1] < max {
+		for j := 1; j <= subBuckets; j++ {
+			bounds = append(bounds, power*(1.0+float64(j)/float64(subBuckets)))
+			if bounds[len(bounds)-1] >= max {
+				break
+			}
+		}
+		power *= 2.0
+	}
+	return NewHistogram(bounds)
+}
+
+//
+//
+// Accessor Functions
+//
+//
+
+func (h *Histogram) Count() int {
+	return h.stats.Count()
+}
+
+func (h *Histogram) Size() int {
+	return h.stats.Size()
+}
+
+// The bounds of the buckets.
+func (h *Histogram) Bounds() []float64 {
+	return append([]float64(nil), h.bounds...)
+}
+
+// The count of each bucket, not including the underflow and overflow.
+func (h *Histogram) Counts() []int {
+	return append([]int(nil), h.counts...)
+}
+
+// The count of the values below the first bound.
+func (h *Histogram) Underflow() int {
+	return h.under
+}
+
+// The count of the values at or above the last bound.
+func (h *Histogram) Overflow() int {
+	return h.over
+}
+
+// The exact stats of the values.
+func (h *Histogram) Stats() Stats {
+	return h.stats
+}
+
+func (h *Histogram) Min() float64 {
+	return h.stats.Min()
+}
+
+func (h *Histogram) Max() float64 {
+	return h.stats.Max()
+}
+
+func (h *Histogram) Sum() float64 {
+	return h.stats.Sum()
+}
+
+func (h *Histogram) Mean() float64 {
+	return h.stats.Mean()
+}
+
+func (h *Histogram) PopulationVariance() float64 {
+	return h.stats.PopulationVariance()
+}
+
+func (h *Histogram) SampleVariance() float64 {
+	return h.stats.SampleVariance()
+}
+
+//
+//
+// Incremental Functions
+//
+//
+
+// Update the histogram with a new value, which mustn't be NaN.
+func (h *Histogram) Update(x float64) {
+	if math.IsNaN(x) {
+		panic("NaN value in Update()")
+	}
+	h.stats.Update(x)
+	switch {
+	case math.IsInf(x, -1) || x < h.bounds[0]:
+		h.under++
+	case math.IsInf(x, 1) || x >= h.bounds[len(h.bounds)-1]:
+		h.over++
+	default:
+		// the last bound above x, less one
+		i := sort.Search(len(h.bounds), func(i int) bool { return h.bounds[i] > x }) - 1
+		h.counts[i]++
+	}
+}
+
+// Update the histogram with the given array of values.
+func (h *Histogram) UpdateArray(data []float64) {
+	for _, v := range data {
+		h.Update(v)
+	}
+}
+
+// Merge the values counted by another Histogram with the same bounds into this one.
+func (h *Histogram) Merge(other *Histogram) {
+	if len(other.bounds) != len(h.bounds) {
+		panic("bounds differ in Merge()")
+	}
+	for i, b := range other.bounds {
+		if b != h.bounds[i] {
+			panic("bounds differ in Merge()")
+		}
+	}
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+	h.under += other.under
+	h.over += other.over
+	h.stats.Merge(other.stats)
+}
+
+// The estimate of the quantile at q, 0 <= q <= 1, interpolated within the bucket holding
+// it. The quantiles at 0 and 1 are the exact min and max. With no values, it's NaN.
+func (h *Histogram) Quantile(q float64) float64 {
+	if !(q >= 0.0 && q <= 1.0) {
+		panic("quantile must be in [0, 1] in Quantile()")
+	}
+	if h.stats.n == 0 {
+		return math.NaN()
+	}
+	min, max := h.stats.min, h.stats.max
+	target := q * h.stats.n
+
+	// walk the underflow, the buckets and the overflow, each clipped to [min, max]
+	m := len(h.bounds) - 1
+	cum := 0.0
+	for i := -1; i <= m; i++ {
+		var lo, hi float64
+		var c int
+		switch {
+		case i == -1:
+			lo, hi, c = min, h.bounds[0], h.under
+		case i == m:
+			lo, hi, c = h.bounds[m], max, h.over
+		default:
+			lo, hi, c = h.bounds[i], h.bounds[i+1], h.counts[i]
+		}
+		if c == 0 {
+			continue
+		}
+		lo, hi = math.Max(lo, min), math.Min(hi, max)
+		if cum+float64(c) >= target {
+			// an infinite min or max can't be interpolated
+			switch {
+			case math.IsInf(lo, -1):
+				return lo
+			case math.IsInf(hi, 1):
+				return hi
+			}
+			return lo + (target-cum)/float64(c)*(hi-lo)
+		}
+		cum += float64(c)
+	}
+	return max
+}
@@ -0,0 +1,189 @@
+package stats
+
+//
+// histogram_test.go
+//
+// Author:   Gary Boone
+//
+// Test:
+//   go test stats.go stats_test.go histogram.go histogram_test.go
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// The bucket counts can be compared with R's
+//   table(cut(x, breaks = b, right = FALSE))
+//
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+var histData = []float64{1.0, -2.0, 13.0, 47.0, 115.0, -0.03, -123.4, 23.0, -23.04, 12.3}
+
+// With no updates, these are the results on initialization
+func TestHistogram0(t *testing.T) {
+	h := NewLinearHistogram(0.0, 1.0, 10)
+	checkInt(h.Count(), 0, "Count", t)
+	checkInt(len(h.Counts()), 10, "Counts", t)
+	checkInt(h.Underflow(), 0, "Underflow", t)
+	checkInt(h.Overflow(), 0, "Overflow", t)
+	checkNaN(h.Quantile(0.5), "Quantile", t)
+}
+
+func TestHistogramLinear(t *testing.T) {
+	h := NewLinearHistogram(-50.0, 25.0, 4)
+	checkFloat64Array(h.Bounds(), []float64{-50, -25, 0, 25, 50}, "Bounds", t)
+	h.UpdateArray(histData)
+	checkInt(h.Count(), 10, "Count", t)
+	checkInts(h.Counts(), []int{0, 3, 4, 1}, "Counts", t)
+	checkInt(h.Underflow(), 1, "Underflow", t)
+	checkInt(h.Overflow(), 1, "Overflow", t)
+
+	// the exact stats are those of Stats
+	var d Stats
+	d.UpdateArray(histData)
+	s := h.Stats()
+	checkSameStats(&s, &d, TOL, "Stats", t)
+	checkFloat64(h.Mean(), 6.283, TOL, "Mean", t)
+	checkFloat64(h.SampleVariance(), 3516.88129, TOL, "SampleVariance", t)
+	checkFloat64(h.PopulationVariance(), 3165.19316100, TOL, "PopulationVariance", t)
+	checkFloat64(h.Sum(), 62.83, TOL, "Sum", t)
+
+	// The quantile at 0.5 is at 5 of 10, with 4 values below 0, so it's interpolated a
+	// quarter of the way into the 4 values of [0, 25).
+	checkFloat64(h.Quantile(0.5), 6.25, TOL, "Quantile 0.5", t)
+	// the underflow is spread over [-123.4, -50)
+	checkFloat64(h.Quantile(0.05), -86.7, TOL, "Quantile 0.05", t)
+	// the overflow is spread over [50, 115]
+	checkFloat64(h.Quantile(0.95), 82.5, TOL, "Quantile 0.95", t)
+	checkFloat64(h.Quantile(0.0), -123.4, TOL, "Quantile 0", t)
+	checkFloat64(h.Quantile(1.0), 115.0, TOL, "Quantile 1", t)
+}
+
+// A value on a bound is counted in the bucket above it.
+func TestHistogramExplicit(t *testing.T) {
+	h := NewHistogram([]float64{0.0, 1.0, 10.0, 100.0})
+	h.UpdateArray([]float64{0.0, 1.0, 9.99, 10.0, 100.0, -1e-9})
+	checkInts(h.Counts(), []int{1, 2, 1}, "Counts", t)
+	checkInt(h.Underflow(), 1, "Underflow", t)
+	checkInt(h.Overflow(), 1, "Overflow", t)
+	checkFloat64(h.Min(), -1e-9, TOL, "Min", t)
+	checkFloat64(h.Max(), 100.0, TOL, "Max", t)
+}
+
+func TestHistogramExponential(t *testing.T) {
+	h := NewExponentialHistogram(1.0, 2.0, 5)
+	checkFloat64Array(h.Bounds(), []float64{1, 2, 4, 8, 16, 32}, "Bounds", t)
+	h.UpdateArray([]float64{0.5, 1.0, 3.0, 3.5, 7.9, 8.0, 31.9, 32.0, 1000.0})
+	checkInts(h.Counts(), []int{1, 2, 1, 1, 1}, "Counts", t)
+	checkInt(h.Underflow(), 1, "Underflow", t)
+	checkInt(h.Overflow(), 2, "Overflow", t)
+}
+
+// Each power of 2 is divided into 4 buckets, as 2 bits of precision in HdrHistogram.
+func TestHistogramLogLinear(t *testing.T) {
+	h := NewLogLinearHistogram(1.0, 10.0, 4)
+	checkFloat64Array(h.Bounds(), []float64{1, 1.25, 1.5, 1.75, 2, 2.5, 3, 3.5, 4, 5, 6, 7, 8, 10}, "Bounds", t)
+	h = NewLogLinearHistogram(0.3, 1.0, 2)
+	checkFloat64Array(h.Bounds(), []float64{0.25, 0.375, 0.5, 0.75, 1.0}, "Bounds", t)
+	h.UpdateArray([]float64{0.3, 0.4, 0.45, 0.9})
+	checkInts(h.Counts(), []int{1, 2, 0, 1}, "Counts", t)
+}
+
+// With fine buckets, the interpolated quantiles are close to the exact ones.
+func TestHistogramQuantiles(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	h := NewLogLinearHistogram(1e-3, 1e3, 32)
+	for i := 0; i < 100000; i++ {
+		h.Update(math.Exp(rnd.NormFloat64()))
+	}
+	// the quantiles of the lognormal distribution, exp(qnorm(p))
+	checkFloat64(h.Quantile(0.5), 1.0, 0.02, "Quantile 0.5", t)
+	checkFloat64(h.Quantile(0.9), 3.602224479279158, 0.02, "Quantile 0.9", t)
+	checkFloat64(h.Quantile(0.99), 10.240473656638043, 0.05, "Quantile 0.99", t)
+}
+
+// Merging the histograms of the parts gives the histogram of the whole.
+func TestHistogramMerge(t *testing.T) {
+	all := NewLinearHistogram(-50.0, 25.0, 4)
+	all.UpdateArray(histData)
+	h1 := NewLinearHistogram(-50.0, 25.0, 4)
+	h2 := NewLinearHistogram(-50.0, 25.0, 4)
+	h1.UpdateArray(histData[:4])
+	h2.UpdateArray(histData[4:])
+	h1.Merge(h2)
+	checkInts(h1.Counts(), all.Counts(), "Merge Counts", t)
+	checkInt(h1.Underflow(), all.Underflow(), "Merge Underflow", t)
+	checkInt(h1.Overflow(), all.Overflow(), "Merge Overflow", t)
+	checkFloat64(h1.Mean(), all.Mean(), TOL, "Merge Mean", t)
+	checkFloat64(h1.SampleVariance(), all.SampleVariance(), TOL, "Merge SampleVariance", t)
+	checkFloat64(h1.Quantile(0.5), all.Quantile(0.5), TOL, "Merge Quantile", t)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Found no panic for test Merge with different bounds")
+		}
+	}()
+	h1.Merge(NewLinearHistogram(-50.0, 25.0, 5))
+}
+
+// Infinities are counted as underflow and overflow, and quantiles among them are infinite.
+func TestHistogramInf(t *testing.T) {
+	h := NewLinearHistogram(-50.0, 25.0, 4)
+	h.UpdateArray([]float64{math.Inf(-1), 0.0, 10.0, 20.0, math.Inf(1)})
+	checkInts(h.Counts(), []int{0, 0, 3, 0}, "Counts", t)
+	checkInt(h.Underflow(), 1, "Underflow", t)
+	checkInt(h.Overflow(), 1, "Overflow", t)
+	checkFloat64(h.Quantile(0.5), 12.5, TOL, "Quantile 0.5", t)
+	for q, sign := range map[float64]int{0.0: -1, 0.1: -1, 0.95: 1, 1.0: 1} {
+		if x := h.Quantile(q); !math.IsInf(x, sign) {
+			t.Errorf("Found %v, but expected %v for test Quantile %v", x, math.Inf(sign), q)
+		}
+	}
+}
+
+// A NaN can't be placed in a bucket.
+func TestHistogramNaN(t *testing.T) {
+	h := NewLinearHistogram(-50.0, 25.0, 4)
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Found no panic for test Update(NaN)")
+		}
+		checkInt(h.Count(), 0, "Count", t)
+	}()
+	h.Update(math.NaN())
+}
+
+//
+//
+// Benchmark tests
+//
+//
+
+func BenchmarkHistogramUpdate(b *testing.B) {
+	h := NewLogLinearHistogram(1.0, 1e6, 16)
+	for i := 0; i < b.N; i++ {
+		h.Update(float64(i%100000) + 1.0)
+	}
+}
+
+//
+//
+// Assertion functions used for tests
+//
+//
+
+func checkInts(x, y []int, test string, t *testing.T) {
+	if len(x) != len(y) {
+		t.Errorf("Found %v, but expected %v for test %v", x, y, test)
+		return
+	}
+	for i := range x {
+		if x[i] != y[i] {
+			t.Errorf("Found %v, but expected %v for test %v", x, y, test)
+			return
+		}
+	}
+}
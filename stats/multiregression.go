@@ -0,0 +1,265 @@
+package stats
+
+//
+// multiregression.go
+//
+// Author:   Gary Boone
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// Changes:
+//           20261017   initial version
+//
+// Source:
+// https://github.com/GaryBoone/GoStats
+//
+// MultiRegression is linear regression on a fixed number of predictors, with an
+// intercept, as in R's lm(y ~ x1 + x2 + ...). Like Regression, it's updated one point at
+// a time and the results are available at any time.
+//
+// Rather than accumulating the normal equations X'X b = X'y, which square the condition
+// number of the problem, it keeps the QR factorization of X. Each new row of X is rotated
+// into the triangular factor R with Givens rotations, which also give that row's
+// contribution to the residual sum of squares. See:
+// W.M. Gentleman, Least squares computations by Givens transformations without square
+// roots, J. Inst. Maths Applics 12, 1973.
+// A.J. Miller, Algorithm AS 274: Least squares routines to supplement those of Gentleman,
+// Applied Statistics 41(2), 1992.
+//
+// As in R, a predictor that's collinear with the earlier ones makes the coefficients
+// undetermined. A predictor with a very large offset, such as a Unix timestamp, is nearly
+// collinear with the intercept and should be centered first.
+//
+
+import (
+	"math"
+)
+
+// structure to contain the accumulating regression components
+type MultiRegression struct {
+	k   int         // number of predictors
+	n   float64     // number of points
+	r   [][]float64 // upper triangular (k+1)x(k+1) factor R of X = QR
+	qty []float64   // the first k+1 elements of Q'y
+	sse float64     // residual sum of squares
+	y   Stats       // for the total sum of squares of y
+}
+
+// Create a MultiRegression on k predictors.
+func NewMultiRegression(k int) *MultiRegression {
+	if k < 1 {
+		panic("number of predictors must be at least 1 in NewMultiRegression()")
+	}
+	m := &MultiRegression{k: k, qty: make([]float64, k+1)}
+	m.r = make([][]float64, k+1)
+	for i := range m.r {
+		m.r[i] = make([]float64, k+1)
+	}
+	return m
+}
+
+//
+//
+// Accessor Functions
+//
+//
+
+func (m *MultiRegression) Count() int {
+	return int(m.n)
+}
+
+func (m *MultiRegression) Size() int {
+	return int(m.n)
+}
+
+// The number of predictors, not including the intercept.
+func (m *MultiRegression) Predictors() int {
+	return m.k
+}
+
+//
+//
+// Incremental Functions
+//
+//
+
+// Update the regression with a new point, where xs holds the values of the predictors.
+func (m *MultiRegression) Update(xs []float64, y float64) {
+	if len(xs) != m.k {
+		panic("wrong number of predictors in Update()")
+	}
+	m.n++
+	m.y.Update(y)
+
+	// the new row of X is [1, xs...]
+	a := make([]float64, m.k+1)
+	a[0] = 1.0
+	copy(a[1:], xs)
+	b := y
+	for i := range a {
+		if a[i] == 0.0 {
+			continue
+		}
+		ri := m.r[i]
+		if ri[i] == 0.0 {
+			// row i of R is empty, so the new row moves into it
+			copy(ri[i:], a[i:])
+			m.qty[i] = b
+			return
+		}
+		h := math.Hypot(ri[i], a[i])
+		c, s := ri[i]/h, a[i]/h
+		ri[i] = h
+		for j := i + 1; j < len(a); j++ {
+			ri[j], a[j] = c*ri[j]+s*a[j], c*a[j]-s*ri[j]
+		}
+		m.qty[i], b = c*m.qty[i]+s*b, c*b-s*m.qty[i]
+	}
+	m.sse += b * b
+}
+
+// Update the regression with the rows of X and the y values.
+func (m *MultiRegression) UpdateArray(X [][]float64, yData []float64) {
+	if len(X) != len(yData) {
+		panic("array lengths differ in UpdateArray()")
+	}
+	for i := range X {
+		m.Update(X[i], yData[i])
+	}
+}
+
+// The coefficients, intercept first, then one for each predictor. They are NaN if the
+// predictors are collinear or there are too few points to determine them.
+func (m *MultiRegression) Coefficients() []float64 {
+	p := m.k + 1
+	beta := make([]float64, p)
+	if !m.fullRank() {
+		for i := range beta {
+			beta[i] = math.NaN()
+		}
+		return beta
+	}
+	for i := p - 1; i >= 0; i-- {
+		sum := m.qty[i]
+		for j := i + 1; j < p; j++ {
+			sum -= m.r[i][j] * beta[j]
+		}
+		beta[i] = sum / m.r[i][i]
+	}
+	return beta
+}
+
+// The standard errors of the coefficients, in the same order as Coefficients().
+func (m *MultiRegression) StandardErrors() []float64 {
+	p := m.k + 1
+	se := make([]float64, p)
+	if !m.fullRank() || m.n <= float64(p) {
+		for i := range se {
+			se[i] = math.NaN()
+		}
+		return se
+	}
+	// The covariance of the coefficients is s^2 (X'X)^-1 = s^2 R^-1 R^-T, so the variance
+	// of coefficient i is s^2 times the squared norm of row i of R^-1.
+	rinv := m.rInverse()
+	s := m.ResidualStandardError()
+	for i := 0; i < p; i++ {
+		sum := 0.0
+		for j := i; j < p; j++ {
+			sum += rinv[i][j] * rinv[i][j]
+		}
+		se[i] = s * math.Sqrt(sum)
+	}
+	return se
+}
+
+func (m *MultiRegression) RSquared() float64 {
+	if !m.fullRank() {
+		return math.NaN()
+	}
+	return 1.0 - m.sse/m.y.m2
+}
+
+// R^2 adjusted for the number of predictors, 1 - (1 - R^2)(n - 1)/(n - k - 1).
+func (m *MultiRegression) AdjustedRSquared() float64 {
+	df := m.n - float64(m.k) - 1.0
+	if df <= 0 {
+		return math.NaN()
+	}
+	return 1.0 - (1.0-m.RSquared())*(m.n-1.0)/df
+}
+
+// The residual standard error, sqrt(SSE / (n - k - 1)).
+func (m *MultiRegression) ResidualStandardError() float64 {
+	df := m.n - float64(m.k) - 1.0
+	if df <= 0 || !m.fullRank() {
+		return math.NaN()
+	}
+	return math.Sqrt(m.sse / df)
+}
+
+// The residual degrees of freedom, n - k - 1.
+func (m *MultiRegression) DegreesOfFreedom() int {
+	return int(m.n) - m.k - 1
+}
+
+// Whether X has full column rank, so that the coefficients are determined. As in R's lm(),
+// a column is taken to be collinear with the earlier ones when the diagonal element of R
+// is less than 1e-7 of the column's norm.
+func (m *MultiRegression) fullRank() bool {
+	for i := range m.r {
+		norm := 0.0
+		for j := 0; j <= i; j++ {
+			norm = math.Hypot(norm, m.r[j][i])
+		}
+		if norm == 0.0 || math.Abs(m.r[i][i]) <= 1e-7*norm {
+			return false
+		}
+	}
+	return true
+}
+
+// The inverse of the upper triangular R, by back substitution.
+func (m *MultiRegression) rInverse() [][]float64 {
+	p := m.k + 1
+	rinv := make([][]float64, p)
+	for i := range rinv {
+		rinv[i] = make([]float64, p)
+	}
+	for j := p - 1; j >= 0; j-- {
+		rinv[j][j] = 1.0 / m.r[j][j]
+		for i := j - 1; i >= 0; i-- {
+			sum := 0.0
+			for l := i + 1; l <= j; l++ {
+				sum += m.r[i][l] * rinv[l][j]
+			}
+			rinv[i][j] = -sum / m.r[i][i]
+		}
+	}
+	return rinv
+}
+
+//
+//
+// Batch Functions
+//
+//
+
+// Multiple linear regression of y on the columns of X, where each row of X holds the
+// predictors for one point. The coefficients and their standard errors are ordered with
+// the intercept first.
+func MultipleLinearRegression(X [][]float64, yData []float64) (coefficients []float64,
+	rsquared, adjRSquared float64, count int, stdErrs []float64, residualStdErr float64) {
+	if len(X) == 0 {
+		panic("no points in MultipleLinearRegression()")
+	}
+	m := NewMultiRegression(len(X[0]))
+	m.UpdateArray(X, yData)
+	coefficients = m.Coefficients()
+	rsquared = m.RSquared()
+	adjRSquared = m.AdjustedRSquared()
+	count = m.Count()
+	stdErrs = m.StandardErrors()
+	residualStdErr = m.ResidualStandardError()
+	return
+}
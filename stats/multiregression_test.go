@@ -0,0 +1,141 @@
+package stats
+
+//
+// multiregression_test.go
+//
+// Author:   Gary Boone
+//
+// Test:
+//   go test stats.go stats_test.go specfunc.go distributions.go regression.go regression_test.go multiregression.go multiregression_test.go
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// R test code:
+// x1 <- c(1, 2, 3, 4, 5, 6, 7, 8)
+// x2 <- c(2.5, 1.0, 4.2, 3.3, 5.9, 4.4, 7.1, 6.0)
+// y <- c(3.1, 4.9, 7.2, 8.1, 11.3, 11.9, 15.2, 15.8)
+// summary(lm(y ~ x1 + x2))
+//
+// The expected values below are the exact least squares results for these inputs,
+// computed in rational arithmetic.
+//
+
+import (
+	"testing"
+)
+
+const MREG_TOL = 1e-12
+
+var mregX = [][]float64{{1, 2.5}, {2, 1.0}, {3, 4.2}, {4, 3.3}, {5, 5.9}, {6, 4.4}, {7, 7.1}, {8, 6.0}}
+var mregY = []float64{3.1, 4.9, 7.2, 8.1, 11.3, 11.9, 15.2, 15.8}
+
+func TestMultiRegressionUpdate0(t *testing.T) {
+	m := NewMultiRegression(2)
+	checkInt(m.Count(), 0, "Count", t)
+	checkInt(m.Predictors(), 2, "Predictors", t)
+	for _, v := range m.Coefficients() {
+		checkNaN(v, "Coefficients", t)
+	}
+	for _, v := range m.StandardErrors() {
+		checkNaN(v, "StandardErrors", t)
+	}
+	checkNaN(m.RSquared(), "RSquared", t)
+	checkNaN(m.AdjustedRSquared(), "AdjustedRSquared", t)
+	checkNaN(m.ResidualStandardError(), "ResidualStandardError", t)
+}
+
+// With 3 points and 2 predictors, the fit is exact but there are no degrees of freedom
+// for the standard errors.
+func TestMultiRegressionUpdate3(t *testing.T) {
+	m := NewMultiRegression(2)
+	m.UpdateArray(mregX[:3], mregY[:3])
+	checkInt(m.Count(), 3, "Count", t)
+	beta := m.Coefficients()
+	checkFloat64(beta[0], 0.87446808510638319512, MREG_TOL, "Intercept", t)
+	checkFloat64(beta[1], 1.9595744680851065017, MREG_TOL, "Coefficient 1", t)
+	checkFloat64(beta[2], 0.10638297872340415681, MREG_TOL, "Coefficient 2", t)
+	for _, v := range m.StandardErrors() {
+		checkNaN(v, "StandardErrors", t)
+	}
+	checkNaN(m.ResidualStandardError(), "ResidualStandardError", t)
+}
+
+func TestMultiRegressionUpdate8(t *testing.T) {
+	m := NewMultiRegression(2)
+	for i := range mregX {
+		m.Update(mregX[i], mregY[i])
+	}
+	checkInt(m.Count(), 8, "Count", t)
+	checkInt(m.DegreesOfFreedom(), 5, "DegreesOfFreedom", t)
+	beta := m.Coefficients()
+	checkFloat64(beta[0], 0.74114138407110213749, MREG_TOL, "Intercept", t)
+	checkFloat64(beta[1], 1.5854172059711796552, MREG_TOL, "Coefficient 1", t)
+	checkFloat64(beta[2], 0.42139097419967199116, MREG_TOL, "Coefficient 2", t)
+	se := m.StandardErrors()
+	checkFloat64(se[0], 0.34509193136154670125, MREG_TOL, "Intercept StandardError", t)
+	checkFloat64(se[1], 0.11204583878201260353, MREG_TOL, "Coefficient 1 StandardError", t)
+	checkFloat64(se[2], 0.13616189321631715241, MREG_TOL, "Coefficient 2 StandardError", t)
+	checkFloat64(m.RSquared(), 0.99489341670382199539, MREG_TOL, "RSquared", t)
+	checkFloat64(m.AdjustedRSquared(), 0.99285078338535079354, MREG_TOL, "AdjustedRSquared", t)
+	checkFloat64(m.ResidualStandardError(), 0.39175499708045806434, MREG_TOL, "ResidualStandardError", t)
+}
+
+// Offset x1 by 2000, as with years. The slopes and their standard errors are unchanged.
+func TestMultiRegressionOffset(t *testing.T) {
+	m := NewMultiRegression(2)
+	for i := range mregX {
+		m.Update([]float64{2000 + mregX[i][0], mregX[i][1]}, mregY[i])
+	}
+	beta := m.Coefficients()
+	checkFloat64(beta[0], -3170.0932705582882082, 1e-10, "Intercept", t)
+	checkFloat64(beta[1], 1.5854172059711796552, 1e-10, "Coefficient 1", t)
+	checkFloat64(beta[2], 0.42139097419967199116, 1e-10, "Coefficient 2", t)
+	se := m.StandardErrors()
+	checkFloat64(se[0], 224.10317120517536395, 1e-10, "Intercept StandardError", t)
+	checkFloat64(se[1], 0.11204583878201260353, 1e-10, "Coefficient 1 StandardError", t)
+	checkFloat64(se[2], 0.13616189321631715241, 1e-10, "Coefficient 2 StandardError", t)
+	checkFloat64(m.RSquared(), 0.99489341670382199539, 1e-10, "RSquared", t)
+}
+
+// With one predictor, the results are those of Regression.
+func TestMultiRegressionUnivariate(t *testing.T) {
+	m := NewMultiRegression(1)
+	xData := []float64{2000, 2001, 2002, 2003, 2004}
+	yData := []float64{9.34, 8.50, 7.62, 6.93, 6.60}
+	for i := range xData {
+		m.Update([]float64{xData[i]}, yData[i])
+	}
+	beta := m.Coefficients()
+	se := m.StandardErrors()
+	checkFloat64(beta[1], -0.705000000000075, REG_TOL, "Slope", t)
+	checkFloat64(beta[0], 1419.208000000151287, REG_TOL, "Intercept", t)
+	checkFloat64(m.RSquared(), 0.976304686026756, REG_TOL, "RSquared", t)
+	checkFloat64(se[1], 0.0634113554499872, 1e-10, "SlopeStandardError", t)
+	checkFloat64(se[0], 126.9495652848741400, 1e-10, "InterceptStandardError", t)
+}
+
+// Collinear predictors don't determine the coefficients.
+func TestMultiRegressionCollinear(t *testing.T) {
+	m := NewMultiRegression(2)
+	for i := range mregX {
+		m.Update([]float64{mregX[i][0], 2.0*mregX[i][0] + 1.0}, mregY[i])
+	}
+	for _, v := range m.Coefficients() {
+		checkNaN(v, "Coefficients", t)
+	}
+	checkNaN(m.RSquared(), "RSquared", t)
+}
+
+func TestMultipleLinearRegression(t *testing.T) {
+	var beta, rsquared, adjRSquared, count, se, rse = MultipleLinearRegression(mregX, mregY)
+	checkInt(count, 8, "Count", t)
+	checkFloat64(beta[0], 0.74114138407110213749, MREG_TOL, "Intercept", t)
+	checkFloat64(beta[1], 1.5854172059711796552, MREG_TOL, "Coefficient 1", t)
+	checkFloat64(beta[2], 0.42139097419967199116, MREG_TOL, "Coefficient 2", t)
+	checkFloat64(se[0], 0.34509193136154670125, MREG_TOL, "Intercept StandardError", t)
+	checkFloat64(se[1], 0.11204583878201260353, MREG_TOL, "Coefficient 1 StandardError", t)
+	checkFloat64(se[2], 0.13616189321631715241, MREG_TOL, "Coefficient 2 StandardError", t)
+	checkFloat64(rsquared, 0.99489341670382199539, MREG_TOL, "RSquared", t)
+	checkFloat64(adjRSquared, 0.99285078338535079354, MREG_TOL, "AdjustedRSquared", t)
+	checkFloat64(rse, 0.39175499708045806434, MREG_TOL, "ResidualStandardError", t)
+}
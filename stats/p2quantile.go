@@ -0,0 +1,239 @@
+package stats
+
+//
+// p2quantile.go
+//
+// Author:   Gary Boone
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// Changes:
+//           20261017   initial version
+//
+// Source:
+// https://github.com/GaryBoone/GoStats
+//
+// P2Quantile estimates quantiles, such as the median and 99th percentile, of a stream of
+// values in constant memory, using the P-square algorithm. See:
+// R. Jain and I. Chlamtac, The P-square algorithm for dynamic calculation of quantiles
+// and histograms without storing observations, CACM 28(10), 1985.
+//
+// The algorithm keeps a few markers whose heights estimate the quantiles at a set of
+// probabilities. As each value arrives, the markers' positions, that is, the number of
+// values at or below them, are incremented, and any marker that drifts a position or
+// more from where it should be is moved toward it, with its height adjusted by a
+// piecewise-parabolic (P-square) interpolation between its neighbors.
+//
+// For m quantiles p1 < ... < pm, it uses the 2m + 3 markers of the paper's extension to
+// several quantiles, at the probabilities
+//   0, p1/2, p1, (p1 + p2)/2, p2, ..., pm, (pm + 1)/2, 1
+// For a single quantile, these are the 5 markers of the original algorithm. The first
+// and last markers are the exact min and max.
+//
+// Until 2m + 3 values have been seen, the values are kept and the quantiles are exact.
+// After that, the estimates are usually accurate to a fraction of a percent in rank for
+// smooth distributions, but they aren't exact.
+//
+
+import (
+	"math"
+	"sort"
+)
+
+// structure to contain the markers
+type P2Quantile struct {
+	ps   []float64 // the quantiles being estimated, in the order given
+	n    float64   // number of values
+	dp   []float64 // the probability of each marker, which is also its desired increment
+	q    []float64 // the height of each marker
+	pos  []float64 // the actual position of each marker, from 1
+	npos []float64 // the desired position of each marker
+}
+
+// Create a P2Quantile estimating the quantiles at the given probabilities, each in (0, 1).
+func NewP2Quantile(ps ...float64) *P2Quantile {
+	if len(ps) == 0 {
+		panic("no quantiles given to NewP2Quantile()")
+	}
+	sorted := make([]float64, 0, len(ps))
+	for _, p := range ps {
+		if !(p > 0.0 && p < 1.0) {
+			panic("quantiles must be in (0, 1) in NewP2Quantile()")
+		}
+		if !containsFloat64(sorted, p) {
+			sorted = append(sorted, p)
+		}
+	}
+	sort.Float64s(sorted)
+
+	dp := []float64{0.0}
+	prev := 0.0
+	for _, p := range sorted {
+		dp = append(dp, (prev+p)/2.0, p)
+		prev = p
+	}
+	dp = append(dp, (prev+1.0)/2.0, 1.0)
+
+	m := len(dp)
+	e := &P2Quantile{
+		ps:   append([]float64(nil), ps...),
+		dp:   dp,
+		q:    make([]float64, 0, m),
+		pos:  make([]float64, m),
+		npos: make([]float64, m),
+	}
+	return e
+}
+
+func containsFloat64(data []float64, x float64) bool {
+	for _, v := range data {
+		if v == x {
+			return true
+		}
+	}
+	return false
+}
+
+//
+//
+// Accessor Functions
+//
+//
+
+func (e *P2Quantile) Count() int {
+	return int(e.n)
+}
+
+func (e *P2Quantile) Size() int {
+	return int(e.n)
+}
+
+// The min of the values seen. It's exact.
+func (e *P2Quantile) Min() float64 {
+	if e.n == 0 {
+		return math.NaN()
+	}
+	return e.q[0]
+}
+
+// The max of the values seen. It's exact.
+func (e *P2Quantile) Max() float64 {
+	if e.n == 0 {
+		return math.NaN()
+	}
+	return e.q[len(e.q)-1]
+}
+
+//
+//
+// Incremental Functions
+//
+//
+
+// Update the estimates with a new value.
+func (e *P2Quantile) Update(x float64) {
+	e.n++
+	m := len(e.dp)
+
+	// until there's a value for each marker, keep them sorted
+	if len(e.q) < m {
+		i := sort.SearchFloat64s(e.q, x)
+		e.q = append(e.q, 0.0)
+		copy(e.q[i+1:], e.q[i:])
+		e.q[i] = x
+		if len(e.q) == m {
+			for i := range e.pos {
+				e.pos[i] = float64(i + 1)
+				e.npos[i] = 1.0 + float64(m-1)*e.dp[i]
+			}
+		}
+		return
+	}
+
+	// find the cell k with q[k] <= x < q[k+1], extending the extremes if needed
+	var k int
+	switch {
+	case x < e.q[0]:
+		e.q[0] = x
+		k = 0
+	case x >= e.q[m-1]:
+		e.q[m-1] = x
+		k = m - 2
+	default:
+		k = sort.Search(m, func(i int) bool { return e.q[i] > x }) - 1
+	}
+	for i := k + 1; i < m; i++ {
+		e.pos[i]++
+	}
+	for i := range e.npos {
+		e.npos[i] += e.dp[i]
+	}
+
+	// move the interior markers that are off by a position or more
+	for i := 1; i < m-1; i++ {
+		d := e.npos[i] - e.pos[i]
+		if (d >= 1.0 && e.pos[i+1]-e.pos[i] > 1.0) || (d <= -1.0 && e.pos[i-1]-e.pos[i] < -1.0) {
+			s := 1.0
+			if d < 0.0 {
+				s = -1.0
+			}
+			qp := e.parabolic(i, s)
+			if e.q[i-1] < qp && qp < e.q[i+1] {
+				e.q[i] = qp
+			} else {
+				e.q[i] = e.linear(i, s)
+			}
+			e.pos[i] += s
+		}
+	}
+}
+
+// Update the estimates with the given array of values.
+func (e *P2Quantile) UpdateArray(data []float64) {
+	for _, v := range data {
+		e.Update(v)
+	}
+}
+
+// The piecewise-parabolic prediction of the height of marker i moved by d = +/-1.
+func (e *P2Quantile) parabolic(i int, d float64) float64 {
+	q, n := e.q, e.pos
+	return q[i] + d/(n[i+1]-n[i-1])*
+		((n[i]-n[i-1]+d)*(q[i+1]-q[i])/(n[i+1]-n[i])+
+			(n[i+1]-n[i]-d)*(q[i]-q[i-1])/(n[i]-n[i-1]))
+}
+
+// The linear prediction of the height of marker i moved by d = +/-1.
+func (e *P2Quantile) linear(i int, d float64) float64 {
+	j := i + int(d)
+	return e.q[i] + d*(e.q[j]-e.q[i])/(e.pos[j]-e.pos[i])
+}
+
+// The estimate of the quantile at probability p, which must be one of those given to
+// NewP2Quantile(). With no values, it's NaN. With fewer values than markers, it's the
+// exact quantile, interpolated between the sorted values as in R's default quantile type.
+func (e *P2Quantile) Quantile(p float64) float64 {
+	if !containsFloat64(e.ps, p) {
+		panic("quantile not tracked in Quantile()")
+	}
+	i := sort.SearchFloat64s(e.dp, p)
+	if e.n == 0 {
+		return math.NaN()
+	}
+	if len(e.q) < len(e.dp) || e.n == float64(len(e.dp)) {
+		h := (float64(len(e.q)) - 1.0) * p
+		lo := math.Floor(h)
+		hi := math.Min(lo+1.0, float64(len(e.q)-1))
+		return e.q[int(lo)] + (h-lo)*(e.q[int(hi)]-e.q[int(lo)])
+	}
+	return e.q[i]
+}
+
+// The estimates of all of the quantiles, in the order given to NewP2Quantile().
+func (e *P2Quantile) Quantiles() []float64 {
+	qs := make([]float64, len(e.ps))
+	for i, p := range e.ps {
+		qs[i] = e.Quantile(p)
+	}
+	return qs
+}
@@ -0,0 +1,135 @@
+package stats
+
+//
+// p2quantile_test.go
+//
+// Author:   Gary Boone
+//
+// Test:
+//   go test stats.go stats_test.go p2quantile.go p2quantile_test.go
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// The estimates are compared against the exact quantiles of the same values. Since the
+// estimates aren't exact, the accuracy is checked by rank: the fraction of the values
+// below the estimate should be close to p.
+//
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// With no updates, these are the results on initialization
+func TestP2Quantile0(t *testing.T) {
+	e := NewP2Quantile(0.5)
+	checkInt(e.Count(), 0, "Count", t)
+	checkNaN(e.Quantile(0.5), "Quantile", t)
+	checkNaN(e.Min(), "Min", t)
+	checkNaN(e.Max(), "Max", t)
+}
+
+// With fewer values than markers, the quantiles are exact.
+func TestP2QuantileFew(t *testing.T) {
+	e := NewP2Quantile(0.5, 0.9)
+	e.UpdateArray([]float64{3.0, 1.0, 4.0, 1.5, 5.0, 9.0})
+	checkInt(e.Count(), 6, "Count", t)
+	// R: quantile(c(3, 1, 4, 1.5, 5, 9), c(0.5, 0.9))
+	checkFloat64(e.Quantile(0.5), 3.5, TOL, "Quantile 0.5", t)
+	checkFloat64(e.Quantile(0.9), 7.0, TOL, "Quantile 0.9", t)
+	checkFloat64(e.Min(), 1.0, TOL, "Min", t)
+	checkFloat64(e.Max(), 9.0, TOL, "Max", t)
+	qs := e.Quantiles()
+	checkFloat64(qs[0], 3.5, TOL, "Quantiles 0.5", t)
+	checkFloat64(qs[1], 7.0, TOL, "Quantiles 0.9", t)
+}
+
+// The example of the paper, with p = 0.5. The final marker heights match its table, to the
+// 2 decimals shown there: 0.02, 0.49, 4.44, 17.20 and 38.62.
+func TestP2QuantilePaper(t *testing.T) {
+	e := NewP2Quantile(0.5)
+	e.UpdateArray([]float64{0.02, 0.15, 0.74, 3.39, 0.83, 22.37, 10.15, 15.43, 38.62, 15.92,
+		34.60, 10.28, 1.47, 0.40, 0.05, 11.39, 0.27, 0.42, 0.09, 11.37})
+	checkFloat64(e.Quantile(0.5), 4.440634353260338, TOL, "Quantile 0.5", t)
+	for i, q := range []float64{0.02, 0.49, 4.44, 17.20, 38.62} {
+		checkFloat64Abs(e.q[i], q, 0.005, "Marker", t)
+	}
+	checkFloat64(e.Min(), 0.02, TOL, "Min", t)
+	checkFloat64(e.Max(), 38.62, TOL, "Max", t)
+}
+
+func TestP2QuantileUniform(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	checkP2Accuracy(func() float64 { return rnd.Float64() }, 0.001, "Uniform", t)
+}
+
+func TestP2QuantileNormal(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	checkP2Accuracy(func() float64 { return rnd.NormFloat64()*3.0 + 100.0 }, 0.001, "Normal", t)
+}
+
+// A skewed distribution, like latencies.
+func TestP2QuantileExponential(t *testing.T) {
+	rnd := rand.New(rand.NewSource(3))
+	checkP2Accuracy(func() float64 { return rnd.ExpFloat64() }, 0.001, "Exponential", t)
+}
+
+func TestP2QuantileLogNormal(t *testing.T) {
+	rnd := rand.New(rand.NewSource(4))
+	checkP2Accuracy(func() float64 { return math.Exp(rnd.NormFloat64() * 2.0) }, 0.001, "LogNormal", t)
+}
+
+// Quantiles given out of order are returned in that order.
+func TestP2QuantileMonotonic(t *testing.T) {
+	e := NewP2Quantile(0.99, 0.5, 0.9)
+	for i := 0; i < 10000; i++ {
+		e.Update(float64(i))
+	}
+	qs := e.Quantiles()
+	if !(qs[1] < qs[2] && qs[2] < qs[0]) {
+		t.Errorf("Found %v, but expected increasing quantiles for test Monotonic", qs)
+	}
+	checkFloat64(qs[1], 4999.5, 1e-3, "Quantile 0.5", t)
+}
+
+//
+//
+// Benchmark tests
+//
+//
+
+func BenchmarkP2QuantileUpdate(b *testing.B) {
+	e := NewP2Quantile(0.5, 0.9, 0.99)
+	for i := 0; i < b.N; i++ {
+		e.Update(float64(i % 1000))
+	}
+}
+
+//
+//
+// Assertion functions used for tests
+//
+//
+
+// check the estimates of p50, p90, p99 and p999 of 100000 values against the fraction of
+// the values below them. In the tails, the rank error must also be within a tenth of 1 - p.
+func checkP2Accuracy(next func() float64, tol float64, test string, t *testing.T) {
+	ps := []float64{0.5, 0.9, 0.99, 0.999}
+	e := NewP2Quantile(ps...)
+	data := make([]float64, 100000)
+	for i := range data {
+		data[i] = next()
+		e.Update(data[i])
+	}
+	sort.Float64s(data)
+	for _, p := range ps {
+		rank := float64(sort.SearchFloat64s(data, e.Quantile(p))) / float64(len(data))
+		if math.Abs(rank-p) > math.Min(tol, 0.1*(1.0-p)) {
+			t.Errorf("Found rank %v, but expected %v for test %v Quantile %v", rank, p, test, p)
+		}
+	}
+	checkFloat64(e.Min(), data[0], TOL, test+" Min", t)
+	checkFloat64(e.Max(), data[len(data)-1], TOL, test+" Max", t)
+}
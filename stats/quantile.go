@@ -0,0 +1,255 @@
+package stats
+
+//
+// quantile.go
+//
+// Author:   Gary Boone
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// Changes:
+//           20261017   initial version
+//
+// Source:
+// https://github.com/GaryBoone/GoStats
+//
+// Exact batch quantiles, with the nine definitions of R's quantile(x, p, type = 1..9).
+// See:
+// R.J. Hyndman and Y. Fan, Sample quantiles in statistical packages, The American
+// Statistician 50(4), 1996.
+//
+// Types 1-3 are discontinuous: the quantile is one of the values.
+//   1 -- the inverse of the empirical CDF
+//   2 -- as type 1, but averaging at discontinuities
+//   3 -- the nearest even order statistic, as in SAS
+// Types 4-9 interpolate linearly between the order statistics, the kth sorted value,
+// from 1, being placed at probability p(k) = (k - a) / (n + 1 - a - b):
+//   4 -- a = 0,   b = 1,   the linear interpolation of the empirical CDF
+//   5 -- a = 1/2, b = 1/2, as in hydrology
+//   6 -- a = 0,   b = 0,   as in Minitab and SPSS
+//   7 -- a = 1,   b = 1,   the default of R and Excel
+//   8 -- a = 1/3, b = 1/3, approximately median-unbiased, recommended by Hyndman and Fan
+//   9 -- a = 3/8, b = 3/8, approximately unbiased for normal data
+//
+// The calculation follows R's quantile.default() step by step, including its small fuzz
+// to guard against rounding in n*p, so the results match R's.
+//
+// Rather than sorting, the order statistics are found by selection (quickselect) on a
+// copy of the data, in expected linear time. The caller's slice isn't modified. The data
+// shouldn't contain NaNs.
+//
+
+import (
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// The quantile of the data at probability p, 0 <= p <= 1, using R's quantile type typ,
+// 1 to 9. With no data, it's NaN.
+func StatsQuantile(data []float64, p float64, typ int) float64 {
+	return StatsQuantiles(data, []float64{p}, typ)[0]
+}
+
+// The quantiles of the data at each of the probabilities ps, using R's quantile type typ.
+func StatsQuantiles(data []float64, ps []float64, typ int) []float64 {
+	if typ < 1 || typ > 9 {
+		panic("quantile type must be 1 to 9 in StatsQuantiles()")
+	}
+	for _, p := range ps {
+		if !(p >= 0.0 && p <= 1.0) {
+			panic("probabilities must be in [0, 1] in StatsQuantiles()")
+		}
+	}
+	qs := make([]float64, len(ps))
+	if len(data) == 0 {
+		for i := range qs {
+			qs[i] = math.NaN()
+		}
+		return qs
+	}
+	x := append([]float64(nil), data...)
+	for i, p := range ps {
+		qs[i] = quantile(x, p, typ)
+	}
+	return qs
+}
+
+// The median of the data, as R's median().
+func StatsMedian(data []float64) float64 {
+	return StatsQuantile(data, 0.5, 7)
+}
+
+// The interquartile range of the data, as R's IQR(), which uses quantile type 7.
+func StatsIQR(data []float64) float64 {
+	qs := StatsQuantiles(data, []float64{0.25, 0.75}, 7)
+	return qs[1] - qs[0]
+}
+
+// Tukey's five-number summary of the data, as R's fivenum(). The hinges are the medians
+// of the lower and upper halves of the data, each including the median when n is odd.
+func StatsFiveNumberSummary(data []float64) (min, lowerHinge, median, upperHinge, max float64) {
+	n := len(data)
+	if n == 0 {
+		nan := math.NaN()
+		return nan, nan, nan, nan, nan
+	}
+	x := append([]float64(nil), data...)
+	// the positions of the summary among the sorted values, from 1
+	n4 := math.Floor(float64(n+3)/2.0) / 2.0
+	d := []float64{1.0, n4, float64(n+1) / 2.0, float64(n+1) - n4, float64(n)}
+	var s [5]float64
+	for i, pos := range d {
+		lo, hi := orderStatistics(x, int(math.Floor(pos))-1)
+		if math.Ceil(pos) == math.Floor(pos) {
+			hi = lo
+		}
+		s[i] = 0.5 * (lo + hi)
+	}
+	return s[0], s[1], s[2], s[3], s[4]
+}
+
+// The quantile of x at p using type typ. x may be reordered.
+func quantile(x []float64, p float64, typ int) float64 {
+	n := len(x)
+	if typ == 7 {
+		// R computes type 7 directly from index = 1 + (n - 1)p.
+		index := float64(n-1) * p
+		lo := math.Floor(index)
+		qlo, qhi := orderStatistics(x, int(lo))
+		if index > lo && qhi != qlo {
+			h := index - lo
+			return (1.0-h)*qlo + h*qhi
+		}
+		return qlo
+	}
+
+	fuzz := 4.0 * 2.220446049250313e-16 // 4 * .Machine$double.eps
+	var j, h float64
+	if typ <= 3 {
+		nppm := float64(n) * p
+		if typ == 3 {
+			nppm -= 0.5
+		}
+		j = math.Floor(nppm + fuzz)
+		switch typ {
+		case 1:
+			h = boolToFloat64(nppm > j)
+		case 2:
+			h = (boolToFloat64(nppm > j) + 1.0) / 2.0
+		case 3:
+			h = boolToFloat64(nppm != j || math.Mod(j, 2.0) == 1.0)
+		}
+	} else {
+		var a, b float64
+		switch typ {
+		case 4:
+			a, b = 0.0, 1.0
+		case 5:
+			a, b = 0.5, 0.5
+		case 6:
+			a, b = 0.0, 0.0
+		case 8:
+			a, b = 1.0/3.0, 1.0/3.0
+		case 9:
+			a, b = 3.0/8.0, 3.0/8.0
+		}
+		nppm := a + p*(float64(n)+1.0-a-b)
+		j = math.Floor(nppm + fuzz)
+		h = nppm - j
+		if math.Abs(h) < fuzz {
+			h = 0.0
+		}
+	}
+
+	// the jth and (j+1)th order statistics, from 1, the 0th being the 1st and the
+	// (n+1)th and (n+2)th being the nth, as R pads the sorted values
+	k := int(j) - 1
+	var qlo, qhi float64
+	switch {
+	case k < 0:
+		qlo, _ = orderStatistics(x, 0)
+		qhi = qlo
+	case k >= n-1:
+		qlo, _ = orderStatistics(x, n-1)
+		qhi = qlo
+	default:
+		qlo, qhi = orderStatistics(x, k)
+	}
+	switch {
+	case h == 1.0:
+		return qhi
+	case h > 0.0 && h < 1.0 && qlo != qhi:
+		return (1.0-h)*qlo + h*qhi
+	}
+	return qlo
+}
+
+func boolToFloat64(b bool) float64 {
+	if b {
+		return 1.0
+	}
+	return 0.0
+}
+
+// The kth and (k+1)th smallest values of x, from 0, found by selection. If k is the last,
+// both are the max. x is reordered.
+func orderStatistics(x []float64, k int) (float64, float64) {
+	selectKth(x, k)
+	next := x[k]
+	if k+1 < len(x) {
+		// after selection, the values after k are the larger ones
+		next = x[k+1]
+		for _, v := range x[k+2:] {
+			if v < next {
+				next = v
+			}
+		}
+	}
+	return x[k], next
+}
+
+// Reorder x so that x[k] is the kth smallest value, from 0, with no larger values before
+// it and no smaller ones after it. This is quickselect with a median-of-3 pivot and a
+// 3-way partition, so repeated values are handled well. If the partitions shrink too
+// slowly, as with adversarial data, it sorts the rest instead, so the worst case is
+// O(n log n).
+func selectKth(x []float64, k int) {
+	lo, hi := 0, len(x)-1
+	budget := 2 * (bits.Len(uint(len(x))) + 1)
+	for hi > lo {
+		if budget == 0 {
+			sort.Float64s(x[lo : hi+1])
+			return
+		}
+		budget--
+
+		// the median of the first, middle and last values
+		a, b, c := x[lo], x[lo+(hi-lo)/2], x[hi]
+		pivot := math.Max(math.Min(a, b), math.Min(math.Max(a, b), c))
+
+		// partition into x[lo:lt] < pivot, x[lt:gt+1] == pivot, x[gt+1:hi+1] > pivot
+		lt, i, gt := lo, lo, hi
+		for i <= gt {
+			switch {
+			case x[i] < pivot:
+				x[lt], x[i] = x[i], x[lt]
+				lt++
+				i++
+			case x[i] > pivot:
+				x[i], x[gt] = x[gt], x[i]
+				gt--
+			default:
+				i++
+			}
+		}
+		switch {
+		case k < lt:
+			hi = lt - 1
+		case k > gt:
+			lo = gt + 1
+		default:
+			return
+		}
+	}
+}
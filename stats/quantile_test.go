@@ -0,0 +1,152 @@
+package stats
+
+//
+// quantile_test.go
+//
+// Author:   Gary Boone
+//
+// Test:
+//   go test stats.go stats_test.go quantile.go quantile_test.go
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// R test code:
+// sapply(1:9, function(t) quantile(1:10, 0.25, type = t))
+// x <- c(1.0, -2.0, 13.0, 47.0, 115.0, -0.03, -123.4, 23.0, -23.04, 12.3)
+// for (t in 1:9) print(quantile(x, c(0, 0.1, 0.25, 0.5, 0.9, 1), type = t), digits = 17)
+// median(x); IQR(x); fivenum(x)
+//
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+var quantData = []float64{1.0, -2.0, 13.0, 47.0, 115.0, -0.03, -123.4, 23.0, -23.04, 12.3}
+var quantProbs = []float64{0.0, 0.1, 0.25, 0.5, 0.9, 1.0}
+
+// the expected quantiles of quantData at quantProbs for each type
+var quantExpected = [][]float64{
+	{-123.4, -123.4, -2.0, 1.0, 47.0, 115.0},
+	{-123.4, -73.22, -2.0, 6.65, 81.0, 115.0},
+	{-123.4, -123.4, -23.04, 1.0, 47.0, 115.0},
+	{-123.4, -123.4, -12.52, 1.0, 47.0, 115.0},
+	{-123.4, -73.22, -2.0, 6.65, 81.0, 115.0},
+	{-123.4, -113.364, -7.26, 6.65, 108.2, 115.0},
+	{-123.4, -33.076, -1.5075, 6.65, 53.8, 115.0},
+	{-123.4, -86.601333333333333, -3.7533333333333333, 6.65, 90.066666666666667, 115.0},
+	{-123.4, -83.256, -3.315, 6.65, 87.8, 115.0},
+}
+
+func TestStatsQuantileTypes(t *testing.T) {
+	data := []float64{10, 9, 8, 7, 6, 5, 4, 3, 2, 1}
+	expected := []float64{3.0, 3.0, 2.0, 2.5, 3.0, 2.75, 3.25, 2.9166666666666667, 2.9375}
+	for typ := 1; typ <= 9; typ++ {
+		checkFloat64(StatsQuantile(data, 0.25, typ), expected[typ-1], TOL, "StatsQuantile", t)
+	}
+	// the caller's data isn't modified
+	checkFloat64(data[0], 10.0, TOL, "StatsQuantile data", t)
+	checkFloat64(data[9], 1.0, TOL, "StatsQuantile data", t)
+}
+
+func TestStatsQuantiles(t *testing.T) {
+	for typ := 1; typ <= 9; typ++ {
+		qs := StatsQuantiles(quantData, quantProbs, typ)
+		for i, q := range qs {
+			checkFloat64(q, quantExpected[typ-1][i], 1e-12, "StatsQuantiles", t)
+		}
+	}
+	checkFloat64(quantData[0], 1.0, TOL, "StatsQuantiles data", t)
+	checkFloat64(quantData[6], -123.4, TOL, "StatsQuantiles data", t)
+}
+
+// R's fuzz keeps n*p from being rounded down to the wrong order statistic. For 1:49 and
+// p = 1/49, n*p = 0.9999999999999999 in floating point, but it's 1, where type 2 averages.
+func TestStatsQuantileFuzz(t *testing.T) {
+	data := make([]float64, 49)
+	for i := range data {
+		data[i] = float64(i + 1)
+	}
+	p := 1.0 / 49.0
+	checkFloat64(StatsQuantile(data, p, 1), 1.0, TOL, "StatsQuantile type 1", t)
+	checkFloat64(StatsQuantile(data, p, 2), 1.5, TOL, "StatsQuantile type 2", t)
+}
+
+func TestStatsQuantileSmall(t *testing.T) {
+	for typ := 1; typ <= 9; typ++ {
+		checkNaN(StatsQuantile([]float64{}, 0.5, typ), "StatsQuantile empty", t)
+		checkFloat64(StatsQuantile([]float64{2.3}, 0.0, typ), 2.3, TOL, "StatsQuantile 1", t)
+		checkFloat64(StatsQuantile([]float64{2.3}, 0.7, typ), 2.3, TOL, "StatsQuantile 1", t)
+		checkFloat64(StatsQuantile([]float64{2.3}, 1.0, typ), 2.3, TOL, "StatsQuantile 1", t)
+	}
+}
+
+// Selection gives the same results as sorting, with many repeated values.
+func TestStatsQuantileSelection(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, n := range []int{2, 3, 10, 101, 1000} {
+		data := make([]float64, n)
+		for i := range data {
+			data[i] = float64(rnd.Intn(n/2 + 1))
+		}
+		sorted := append([]float64(nil), data...)
+		sort.Float64s(sorted)
+		for _, p := range []float64{0.0, 0.01, 0.3, 0.5, 0.77, 0.99, 1.0} {
+			// type 7, from the sorted values
+			index := float64(n-1) * p
+			lo := int(index)
+			want := sorted[lo]
+			if lo+1 < n {
+				want += (index - float64(lo)) * (sorted[lo+1] - sorted[lo])
+			}
+			checkFloat64Abs(StatsQuantile(data, p, 7), want, 1e-12*float64(n), "StatsQuantile selection", t)
+		}
+	}
+}
+
+func TestStatsMedian(t *testing.T) {
+	checkFloat64(StatsMedian(quantData), 6.65, TOL, "StatsMedian", t)
+	checkFloat64(StatsMedian([]float64{3, 1, 2}), 2.0, TOL, "StatsMedian odd", t)
+	checkNaN(StatsMedian([]float64{}), "StatsMedian empty", t)
+}
+
+func TestStatsIQR(t *testing.T) {
+	checkFloat64(StatsIQR(quantData), 22.0075, TOL, "StatsIQR", t)
+	checkFloat64(StatsIQR([]float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}), 4.5, TOL, "StatsIQR 1:10", t)
+}
+
+func TestStatsFiveNumberSummary(t *testing.T) {
+	min, lh, med, uh, max := StatsFiveNumberSummary(quantData)
+	checkFloat64(min, -123.4, TOL, "Min", t)
+	checkFloat64(lh, -2.0, TOL, "LowerHinge", t)
+	checkFloat64(med, 6.65, TOL, "Median", t)
+	checkFloat64(uh, 23.0, TOL, "UpperHinge", t)
+	checkFloat64(max, 115.0, TOL, "Max", t)
+
+	// fivenum(1:8) and fivenum(1:9)
+	min, lh, med, uh, max = StatsFiveNumberSummary([]float64{8, 7, 6, 5, 4, 3, 2, 1})
+	checkFloat64Array([]float64{min, lh, med, uh, max}, []float64{1, 2.5, 4.5, 6.5, 8}, "fivenum 1:8", t)
+	min, lh, med, uh, max = StatsFiveNumberSummary([]float64{1, 2, 3, 4, 5, 6, 7, 8, 9})
+	checkFloat64Array([]float64{min, lh, med, uh, max}, []float64{1, 3, 5, 7, 9}, "fivenum 1:9", t)
+	min, lh, med, uh, max = StatsFiveNumberSummary([]float64{2.3})
+	checkFloat64Array([]float64{min, lh, med, uh, max}, []float64{2.3, 2.3, 2.3, 2.3, 2.3}, "fivenum 2.3", t)
+}
+
+//
+//
+// Benchmark tests
+//
+//
+
+func BenchmarkStatsMedian(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	data := make([]float64, 10000)
+	for i := range data {
+		data[i] = rnd.Float64()
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		StatsMedian(data)
+	}
+}
@@ -0,0 +1,88 @@
+package stats
+
+//
+// ransac.go
+//
+// Author:   Gary Boone
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// Changes:
+//           20261017   initial version
+//
+// Source:
+// https://github.com/GaryBoone/GoStats
+//
+// RANSAC, random sample consensus, fits a line to data with a large share of gross
+// outliers. Each iteration draws two points at random and counts the inliers of the line
+// through them, the points within the threshold of it in y. The line with the most
+// inliers wins, and the result is the least squares line through its inliers. See:
+// M.A. Fischler and R.C. Bolles, Random sample consensus: a paradigm for model fitting
+// with applications to image analysis and automated cartography, Comm. ACM 24(6), 1981.
+//
+// With a fraction f of inliers, an iteration draws two inliers with probability f^2, so
+// log(1 - P) / log(1 - f^2) iterations find a clean pair with probability P. For
+// f = 0.5 and P = 0.99, that's 17 iterations.
+//
+// The random source is given, so runs can be reproduced.
+//
+
+import (
+	"math"
+	"math/rand"
+)
+
+// The RANSAC line through the points. A point is an inlier of a line if its residual is
+// at most threshold in absolute value. It tries the given number of random pairs of
+// points, and refits the inliers of the best by least squares, as LinearRegression(). It
+// returns the refit line, the number of inliers and which points they are. If there are
+// no pairs of points with different x, the line is NaN with no inliers.
+func RANSACRegression(xData, yData []float64, threshold float64, iterations int,
+	src rand.Source) (slope, intercept float64, count int, inliers []bool) {
+	if len(xData) != len(yData) {
+		panic("array lengths differ in RANSACRegression()")
+	}
+	if !(threshold >= 0.0) || iterations < 1 {
+		panic("threshold must be nonnegative and iterations at least 1 in RANSACRegression()")
+	}
+	n := len(xData)
+	rnd := generator(src)
+	inliers = make([]bool, n)
+	candidate := make([]bool, n)
+	best := 0
+	for iteration := 0; iteration < iterations && n >= 2; iteration++ {
+		i := rnd.Intn(n)
+		j := rnd.Intn(n - 1)
+		if j >= i {
+			j++
+		}
+		dx := xData[j] - xData[i]
+		if dx == 0.0 {
+			continue
+		}
+		b := (yData[j] - yData[i]) / dx
+		a := yData[i] - b*xData[i]
+		c := 0
+		for k := range xData {
+			candidate[k] = math.Abs(yData[k]-(a+b*xData[k])) <= threshold
+			if candidate[k] {
+				c++
+			}
+		}
+		if c > best {
+			best = c
+			inliers, candidate = candidate, inliers
+		}
+	}
+	if best == 0 {
+		return math.NaN(), math.NaN(), 0, inliers
+	}
+
+	var r Regression
+	for k, in := range inliers {
+		if in {
+			r.Update(xData[k], yData[k])
+		}
+	}
+	return r.Slope(), r.Intercept(), best, inliers
+}
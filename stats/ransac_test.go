@@ -0,0 +1,92 @@
+package stats
+
+//
+// ransac_test.go
+//
+// Author:   Gary Boone
+//
+// Test:
+//   go test stats.go stats_test.go specfunc.go distributions.go regression.go regression_test.go ransac.go ransac_test.go
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// The refit lines are compared against LinearRegression() on the known inliers.
+//
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// A line with noise, 40% of whose points are replaced by gross outliers.
+func ransacData(n int, seed int64) (x, y []float64, outlier []bool) {
+	rnd := rand.New(rand.NewSource(seed))
+	x = make([]float64, n)
+	y = make([]float64, n)
+	outlier = make([]bool, n)
+	for i := range x {
+		x[i] = 10.0 * rnd.Float64()
+		y[i] = 1.5 + 0.5*x[i] + 0.1*(rnd.Float64()-0.5)
+		if rnd.Float64() < 0.4 {
+			outlier[i] = true
+			y[i] += 5.0 + 20.0*rnd.Float64()
+		}
+	}
+	return
+}
+
+func TestRANSACRegression(t *testing.T) {
+	x, y, outlier := ransacData(200, 1)
+	slope, intercept, count, inliers := RANSACRegression(x, y, 0.5, 50, rand.NewSource(7))
+
+	// the inliers are exactly the points that weren't made outliers
+	var xIn, yIn []float64
+	for i := range x {
+		if inliers[i] == outlier[i] {
+			t.Errorf("Found inlier %v, but expected %v for point %v", inliers[i], !outlier[i], i)
+		}
+		if !outlier[i] {
+			xIn = append(xIn, x[i])
+			yIn = append(yIn, y[i])
+		}
+	}
+	checkInt(count, len(xIn), "Count", t)
+	lsSlope, lsIntercept, _, _, _, _ := LinearRegression(xIn, yIn)
+	checkFloat64(slope, lsSlope, REG_TOL, "Slope", t)
+	checkFloat64(intercept, lsIntercept, REG_TOL, "Intercept", t)
+	checkFloat64(slope, 0.5, 0.01, "Slope true", t)
+	checkFloat64(intercept, 1.5, 0.05, "Intercept true", t)
+
+	// the same source gives the same result
+	slope2, intercept2, count2, _ := RANSACRegression(x, y, 0.5, 50, rand.NewSource(7))
+	checkFloat64(slope2, slope, 0.0, "Slope reproduced", t)
+	checkFloat64(intercept2, intercept, 0.0, "Intercept reproduced", t)
+	checkInt(count2, count, "Count reproduced", t)
+}
+
+// With a threshold that includes every point, it's least squares.
+func TestRANSACRegressionAll(t *testing.T) {
+	x, y, _ := ransacData(50, 2)
+	slope, intercept, count, inliers := RANSACRegression(x, y, 1e6, 1, rand.NewSource(1))
+	lsSlope, lsIntercept, _, _, _, _ := LinearRegression(x, y)
+	checkFloat64(slope, lsSlope, REG_TOL, "Slope", t)
+	checkFloat64(intercept, lsIntercept, REG_TOL, "Intercept", t)
+	checkInt(count, 50, "Count", t)
+	for i, in := range inliers {
+		if !in {
+			t.Errorf("Found outlier for point %v", i)
+		}
+	}
+}
+
+func TestRANSACRegressionDegenerate(t *testing.T) {
+	slope, intercept, count, inliers := RANSACRegression([]float64{1, 1, 1}, []float64{1, 2, 3}, 1.0, 10,
+		rand.NewSource(1))
+	checkNaN(slope, "Slope", t)
+	checkNaN(intercept, "Intercept", t)
+	checkInt(count, 0, "Count", t)
+	checkInt(len(inliers), 3, "Inliers", t)
+	slope, _, count, _ = RANSACRegression([]float64{1}, []float64{1}, 1.0, 10, rand.NewSource(1))
+	checkNaN(slope, "Slope 1", t)
+	checkInt(count, 0, "Count 1", t)
+}
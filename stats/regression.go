@@ -9,6 +9,12 @@ package stats
 //
 // Changes:
 //           20110618:    initial version
+//           20261017:    added Merge()
+//           20261017:    accumulate means and centered co-moments instead of raw sums
+//           20261017:    added Remove()
+//           20261017:    added weighted least squares
+//           20261017:    added t tests and confidence intervals of the coefficients
+//           20261017:    added Predict(), ConfidenceBand() and PredictionInterval()
 //
 // Source:
 // https://github.com/GaryBoone/GoStats
@@ -18,14 +24,26 @@ package stats
 // http://mathworld.wolfram.com/LeastSquaresFitting.html
 // http://mathworld.wolfram.com/CorrelationCoefficient.html
 //
+// Like Stats, the running means and centered co-moments are updated with Welford's
+// method rather than accumulating raw sums of x, x*x, x*y, etc. The raw sums cancel
+// badly when the x values are large, such as years or Unix timestamps.
+//
+// Points may be weighted, as for weighted least squares with heteroscedastic errors. The
+// weights are then included in the means and co-moments, and the results match R's
+// lm(y ~ x, weights = w). The residual degrees of freedom remain the number of points
+// less 2.
+//
 
 import (
 	"math"
 )
 
-// structure to contain the accumulating regression components
+// structure to contain the accumulating regression components: the number of points,
+// the sum of their weights, the weighted means of x and y, and the weighted centered sums
+// of squares and cross products
+//   m2x = sum(w*(x - meanX)^2), m2y = sum(w*(y - meanY)^2), cxy = sum(w*(x - meanX)*(y - meanY))
 type Regression struct {
-	n, sx, sy, sxx, sxy, syy float64
+	n, w, meanX, meanY, m2x, m2y, cxy float64
 }
 
 // 
@@ -50,12 +68,27 @@ func (r *Regression) Size() int {
 
 // Update the stats with a new point.
 func (r *Regression) Update(x, y float64) {
+	r.UpdateWeighted(x, y, 1.0)
+}
+
+// Update the stats with a new point having weight w. Points with zero weight are ignored,
+// as in R. Negative weights panic.
+func (r *Regression) UpdateWeighted(x, y, w float64) {
+	if w < 0.0 {
+		panic("negative weight in UpdateWeighted()")
+	}
+	if w == 0.0 {
+		return
+	}
 	r.n++
-	r.sx += x
-	r.sy += y
-	r.sxx += x * x
-	r.sxy += x * y
-	r.syy += y * y
+	r.w += w
+	dx := x - r.meanX
+	dy := y - r.meanY
+	r.meanX += dx * w / r.w
+	r.meanY += dy * w / r.w
+	r.m2x += w * dx * (x - r.meanX)
+	r.m2y += w * dy * (y - r.meanY)
+	r.cxy += w * dx * (y - r.meanY)
 }
 
 // Update the stats with arrays of x and y values.
@@ -68,44 +101,191 @@ func (r *Regression) UpdateArray(xData, yData []float64) {
 	}
 }
 
+// Update the stats with arrays of x and y values and their weights.
+func (r *Regression) UpdateWeightedArray(xData, yData, weights []float64) {
+	if len(xData) != len(yData) || len(xData) != len(weights) {
+		panic("array lengths differ in UpdateWeightedArray()")
+	}
+	for i := 0; i < len(xData); i++ {
+		r.UpdateWeighted(xData[i], yData[i], weights[i])
+	}
+}
+
+// Merge the points accumulated in another Regression into this one. The result is the
+// same as if all of the other's points had been passed to Update(), so regressions
+// accumulated in parallel or on separate machines can be combined.
+func (r *Regression) Merge(other Regression) {
+	if other.n == 0 {
+		return
+	}
+	if r.n == 0 {
+		*r = other
+		return
+	}
+	wa, wb := r.w, other.w
+	w := wa + wb
+	dx := other.meanX - r.meanX
+	dy := other.meanY - r.meanY
+	f := wa * wb / w
+	r.n += other.n
+	r.w = w
+	r.meanX += dx * wb / w
+	r.meanY += dy * wb / w
+	r.m2x += other.m2x + dx*dx*f
+	r.m2y += other.m2y + dy*dy*f
+	r.cxy += other.cxy + dx*dy*f
+}
+
+// Remove a point that was previously added with Update(), that is, with weight 1. The
+// regression returns to what it would be had the point never been added. Removing the last
+// point resets the Regression. Removing from an empty Regression panics.
+func (r *Regression) Remove(x, y float64) {
+	if r.n == 0 {
+		panic("Remove() called on empty Regression")
+	}
+	if r.n == 1 {
+		*r = Regression{}
+		return
+	}
+	// merge formula with a single point of weight -1
+	w := r.w - 1.0
+	dx := x - r.meanX
+	dy := y - r.meanY
+	f := -r.w / w
+	r.n--
+	r.w = w
+	r.meanX -= dx / w
+	r.meanY -= dy / w
+	r.m2x += dx * dx * f
+	r.m2y += dy * dy * f
+	r.cxy += dx * dy * f
+}
+
 func (r *Regression) Slope() float64 {
-	ss_xy := r.n*r.sxy - r.sx*r.sy
-	ss_xx := r.n*r.sxx - r.sx*r.sx
-	return ss_xy / ss_xx
+	return r.cxy / r.m2x
 }
 
 func (r *Regression) Intercept() float64 {
-	return (r.sy - r.Slope()*r.sx) / r.n
+	return r.meanY - r.Slope()*r.meanX
 }
 
 func (r *Regression) RSquared() float64 {
-	ss_xy := r.n*r.sxy - r.sx*r.sy
-	ss_xx := r.n*r.sxx - r.sx*r.sx
-	ss_yy := r.n*r.syy - r.sy*r.sy
-	return ss_xy * ss_xy / ss_xx / ss_yy
+	return r.cxy * r.cxy / r.m2x / r.m2y
+}
+
+// The residual standard error, sqrt(SSE / (n - 2)). For a perfect fit, rounding can
+// leave the SSE slightly negative, so it's clamped at 0.
+func (r *Regression) residualStandardError() float64 {
+	sse := r.m2y - r.cxy*r.cxy/r.m2x
+	if sse < 0.0 {
+		sse = 0.0
+	}
+	return math.Sqrt(sse / (r.n - 2.0))
 }
 
 func (r *Regression) SlopeStandardError() float64 {
 	if r.n <= 2 {
 		return math.NaN()
 	}
-	ss_xy := r.n*r.sxy - r.sx*r.sy
-	ss_xx := r.n*r.sxx - r.sx*r.sx
-	ss_yy := r.n*r.syy - r.sy*r.sy
-	s := math.Sqrt((ss_yy - ss_xy*ss_xy/ss_xx) / (r.n - 2.0))
-	return s / math.Sqrt(ss_xx)
+	return r.residualStandardError() / math.Sqrt(r.m2x)
 }
 
 func (r *Regression) InterceptStandardError() float64 {
 	if r.n <= 2 {
 		return math.NaN()
 	}
-	ss_xy := r.n*r.sxy - r.sx*r.sy
-	ss_xx := r.n*r.sxx - r.sx*r.sx
-	ss_yy := r.n*r.syy - r.sy*r.sy
-	s := math.Sqrt((ss_yy - ss_xy*ss_xy/ss_xx) / (r.n - 2.0))
-	mean_x := r.sx / r.n
-	return s * math.Sqrt(1.0/r.n+mean_x*mean_x/ss_xx)
+	return r.residualStandardError() * math.Sqrt(1.0/r.w+r.meanX*r.meanX/r.m2x)
+}
+
+// The t test of a coefficient against 0 with the given standard error, as in
+// summary(lm): the t statistic, the residual degrees of freedom and the two-sided p-value.
+func (r *Regression) coefficientTTest(estimate, stdErr float64) (t, df, p float64) {
+	df = r.n - 2.0
+	if r.n <= 2 {
+		return math.NaN(), df, math.NaN()
+	}
+	t = estimate / stdErr
+	p = 2.0 * NewStudentsT(df).Survival(math.Abs(t))
+	return
+}
+
+// The critical value of the t distribution with n - 2 df for intervals at the given
+// level, qt((1 + level)/2, n - 2), or NaN if there are too few points.
+func (r *Regression) criticalValue(level float64, caller string) float64 {
+	if !(level > 0.0 && level < 1.0) {
+		panic("level must be in (0, 1) in " + caller)
+	}
+	if r.n <= 2 {
+		return math.NaN()
+	}
+	return NewStudentsT(r.n-2.0).Quantile(0.5 + 0.5*level)
+}
+
+// The confidence interval of a coefficient with the given standard error at the given
+// level, as confint(lm): estimate -/+ qt((1 + level)/2, n - 2) * stdErr.
+func (r *Regression) coefficientConfidenceInterval(estimate, stdErr, level float64,
+	caller string) (lower, upper float64) {
+	half := r.criticalValue(level, caller) * stdErr
+	return estimate - half, estimate + half
+}
+
+// The t test of the slope against 0: the t statistic, the degrees of freedom, n - 2, and
+// the two-sided p-value.
+func (r *Regression) SlopeTTest() (t, df, p float64) {
+	return r.coefficientTTest(r.Slope(), r.SlopeStandardError())
+}
+
+// The t test of the intercept against 0: the t statistic, the degrees of freedom, n - 2,
+// and the two-sided p-value.
+func (r *Regression) InterceptTTest() (t, df, p float64) {
+	return r.coefficientTTest(r.Intercept(), r.InterceptStandardError())
+}
+
+// The confidence interval of the slope at the given level, such as 0.95.
+func (r *Regression) SlopeConfidenceInterval(level float64) (lower, upper float64) {
+	return r.coefficientConfidenceInterval(r.Slope(), r.SlopeStandardError(), level,
+		"SlopeConfidenceInterval()")
+}
+
+// The confidence interval of the intercept at the given level, such as 0.95.
+func (r *Regression) InterceptConfidenceInterval(level float64) (lower, upper float64) {
+	return r.coefficientConfidenceInterval(r.Intercept(), r.InterceptStandardError(), level,
+		"InterceptConfidenceInterval()")
+}
+
+// The fitted value at x. It's found from the means, meanY + slope*(x - meanX), which keeps
+// its precision for large x.
+func (r *Regression) Predict(x float64) float64 {
+	return r.meanY + r.Slope()*(x-r.meanX)
+}
+
+// The standard error of the fitted value at x, s * sqrt(1/w + (x - meanX)^2 / m2x), the
+// se.fit of predict(lm).
+func (r *Regression) fitStandardError(x float64) float64 {
+	if r.n <= 2 {
+		return math.NaN()
+	}
+	dx := x - r.meanX
+	return r.residualStandardError() * math.Sqrt(1.0/r.w+dx*dx/r.m2x)
+}
+
+// The confidence interval at the given level of the mean response at x, as
+// predict(lm, interval = "confidence").
+func (r *Regression) ConfidenceBand(x, level float64) (lower, upper float64) {
+	half := r.criticalValue(level, "ConfidenceBand()") * r.fitStandardError(x)
+	y := r.Predict(x)
+	return y - half, y + half
+}
+
+// The prediction interval at the given level of a new observation at x, as
+// predict(lm, interval = "prediction"). It adds the residual variance to that of the
+// fitted value. For a weighted regression, as in R, the new observation has weight 1.
+func (r *Regression) PredictionInterval(x, level float64) (lower, upper float64) {
+	se := r.fitStandardError(x)
+	s := r.residualStandardError()
+	half := r.criticalValue(level, "PredictionInterval()") * math.Sqrt(se*se+s*s)
+	y := r.Predict(x)
+	return y - half, y + half
 }
 
 // 
@@ -118,21 +298,26 @@ func LinearRegression(xData, yData []float64) (slope, intercept, rsquared float6
 	count int, slopeStdErr, interceptStdErr float64) {
 	var r Regression
 	r.UpdateArray(xData, yData)
-	ss_xy := r.n*r.sxy - r.sx*r.sy
-	ss_xx := r.n*r.sxx - r.sx*r.sx
-	ss_yy := r.n*r.syy - r.sy*r.sy
-	slope = ss_xy / ss_xx
-	intercept = (r.sy - r.Slope()*r.sx) / r.n
-	rsquared = ss_xy * ss_xy / ss_xx / ss_yy
-	if r.n <= 2 {
-		slopeStdErr = math.NaN()
-		interceptStdErr = math.NaN()
-	} else {
-		s := math.Sqrt((ss_yy - ss_xy*ss_xy/ss_xx) / (r.n - 2.0))
-		slopeStdErr = s / math.Sqrt(ss_xx)
-		mean_x := r.sx / r.n
-		interceptStdErr = s * math.Sqrt(1.0/r.n+mean_x*mean_x/ss_xx)
-	}
-	count = int(r.n)
+	slope = r.Slope()
+	intercept = r.Intercept()
+	rsquared = r.RSquared()
+	count = r.Count()
+	slopeStdErr = r.SlopeStandardError()
+	interceptStdErr = r.InterceptStandardError()
+	return
+}
+
+// Weighted least squares on the given points and weights. The results are the same as
+// those of R's lm(y ~ x, weights = w).
+func WeightedLinearRegression(xData, yData, weights []float64) (slope, intercept, rsquared float64,
+	count int, slopeStdErr, interceptStdErr float64) {
+	var r Regression
+	r.UpdateWeightedArray(xData, yData, weights)
+	slope = r.Slope()
+	intercept = r.Intercept()
+	rsquared = r.RSquared()
+	count = r.Count()
+	slopeStdErr = r.SlopeStandardError()
+	interceptStdErr = r.InterceptStandardError()
 	return
 }
@@ -6,7 +6,7 @@ package stats
 // Author:   Gary Boone
 // 
 // Test:
-//   go test stats.go stats_test.go regression.go regression_test.go
+//   go test stats.go stats_test.go specfunc.go distributions.go regression.go regression_test.go
 //
 // Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
 //
@@ -107,6 +107,362 @@ func TestRegressionUpdateArray5(t *testing.T) {
 	checkFloat64(r.InterceptStandardError(), 126.9495652848741400, 1e-6, "InterceptStandardError", t)
 }
 
+// Split the 5 points into two regressions in every possible way, then merge them. Each
+// merged regression should match the regression on all 5 points.
+func TestRegressionMerge5(t *testing.T) {
+	xData := []float64{2000, 2001, 2002, 2003, 2004}
+	yData := []float64{9.34, 8.50, 7.62, 6.93, 6.60}
+	for mask := 0; mask < 1<<uint(len(xData)); mask++ {
+		var r1, r2 Regression
+		for i := range xData {
+			if mask&(1<<uint(i)) != 0 {
+				r1.Update(xData[i], yData[i])
+			} else {
+				r2.Update(xData[i], yData[i])
+			}
+		}
+		r1.Merge(r2)
+		checkInt(r1.Count(), 5, "Count", t)
+		checkFloat64(r1.Slope(), -0.705000000000075, REG_TOL, "Slope", t)
+		checkFloat64(r1.Intercept(), 1419.208000000151287, REG_TOL, "Intercept", t)
+		checkFloat64(r1.RSquared(), 0.976304686026756, REG_TOL, "RSquared", t)
+		checkFloat64(r1.SlopeStandardError(), 0.0634113554499872, 1e-10, "SlopeStandardError", t)
+		checkFloat64(r1.InterceptStandardError(), 126.9495652848741400, 1e-6, "InterceptStandardError", t)
+	}
+}
+
+// Split the first 3 points in every possible way. The merged regression should match the
+// 3 point regression.
+func TestRegressionMerge3(t *testing.T) {
+	xData := []float64{2000, 2001, 2002}
+	yData := []float64{9.34, 8.50, 7.62}
+	for mask := 0; mask < 1<<uint(len(xData)); mask++ {
+		var r1, r2 Regression
+		for i := range xData {
+			if mask&(1<<uint(i)) != 0 {
+				r1.Update(xData[i], yData[i])
+			} else {
+				r2.Update(xData[i], yData[i])
+			}
+		}
+		r2.Merge(r1)
+		checkInt(r2.Count(), 3, "Count", t)
+		checkFloat64(r2.Slope(), -0.8600000000004419, REG_TOL, "Slope", t)
+		checkFloat64(r2.Intercept(), 1729.3466666675515171, REG_TOL, "Intercept", t)
+		checkFloat64(r2.RSquared(), 0.999819754866627, REG_TOL, "RSquared", t)
+		checkFloat64(r2.SlopeStandardError(), 0.0115470053835452, 1e-8, "SlopeStandardError", t)
+		checkFloat64(r2.InterceptStandardError(), 23.1055596960129250, 1e-6, "InterceptStandardError", t)
+	}
+}
+
+// Merging empty regressions changes nothing.
+func TestRegressionMergeEmpty(t *testing.T) {
+	var r, empty Regression
+	r.Merge(empty)
+	checkInt(r.Count(), 0, "Count", t)
+	checkNaN(r.Slope(), "Slope", t)
+	r.UpdateArray([]float64{2000, 2001}, []float64{9.34, 8.50})
+	r.Merge(empty)
+	empty.Merge(r)
+	checkInt(empty.Count(), 2, "Count", t)
+	checkFloat64(empty.Slope(), -0.840000000000126, REG_TOL, "Slope", t)
+	checkFloat64(empty.Intercept(), 1689.340000000251393, REG_TOL, "Intercept", t)
+}
+
+// Offset x by 1e9, as with Unix timestamps. Raw sums of x*x lose all precision at this
+// scale, while the centered co-moments do not. The slope, R^2 and slope standard error
+// are unchanged by the offset. The expected values are the exact least squares results
+// for these inputs, computed in rational arithmetic; compare with R:
+//   x <- 1e9 + c(2000, 2001, 2002, 2003, 2004)
+//   y <- c(9.34, 8.50, 7.62, 6.93, 6.60)
+//   summary(lm(y ~ x))
+func TestRegressionOffsetUpdate3(t *testing.T) {
+	var r Regression
+	r.Update(1e9+2000, 9.34)
+	r.Update(1e9+2001, 8.50)
+	r.Update(1e9+2002, 7.62)
+	checkInt(r.Count(), 3, "Count", t)
+	checkFloat64(r.Slope(), -0.86, 1e-10, "Slope", t)
+	checkFloat64(r.Intercept(), 860001729.346666542321, 1e-10, "Intercept", t)
+	checkFloat64(r.RSquared(), 0.999819754866618601, 1e-10, "RSquared", t)
+	checkFloat64(r.SlopeStandardError(), 0.0115470053837925255, 1e-10, "SlopeStandardError", t)
+	checkFloat64(r.InterceptStandardError(), 11547028.4893502985, 1e-10, "InterceptStandardError", t)
+}
+
+func TestRegressionOffsetUpdate5(t *testing.T) {
+	var r Regression
+	xData := []float64{1e9 + 2000, 1e9 + 2001, 1e9 + 2002, 1e9 + 2003, 1e9 + 2004}
+	yData := []float64{9.34, 8.50, 7.62, 6.93, 6.60}
+	r.UpdateArray(xData, yData)
+	checkInt(r.Count(), 5, "Count", t)
+	checkFloat64(r.Slope(), -0.705, 1e-10, "Slope", t)
+	checkFloat64(r.Intercept(), 705001419.208000071054, 1e-10, "Intercept", t)
+	checkFloat64(r.RSquared(), 0.976304686026777326, 1e-10, "RSquared", t)
+	checkFloat64(r.SlopeStandardError(), 0.0634113554499507253, 1e-10, "SlopeStandardError", t)
+	checkFloat64(r.InterceptStandardError(), 63411482.3994843362, 1e-10, "InterceptStandardError", t)
+}
+
+// Update() then Remove() points. The regression should return to the regression on the
+// original points.
+func TestRegressionRemove(t *testing.T) {
+	var r Regression
+	r.Update(2000, 9.34)
+	r.Update(2001, 8.50)
+	r.Update(1995, 20.1)
+	r.Update(2002, 7.62)
+	r.Update(2003, 6.93)
+	r.Update(2004, 6.60)
+	r.Update(2010, -3.5)
+	r.Remove(1995, 20.1)
+	r.Remove(2010, -3.5)
+	checkInt(r.Count(), 5, "Count", t)
+	checkFloat64(r.Slope(), -0.705000000000075, REG_TOL, "Slope", t)
+	checkFloat64(r.Intercept(), 1419.208000000151287, REG_TOL, "Intercept", t)
+	checkFloat64(r.RSquared(), 0.976304686026756, REG_TOL, "RSquared", t)
+	checkFloat64(r.SlopeStandardError(), 0.0634113554499872, 1e-10, "SlopeStandardError", t)
+	checkFloat64(r.InterceptStandardError(), 126.9495652848741400, 1e-6, "InterceptStandardError", t)
+
+	// remove down to 3 points
+	r.Remove(2004, 6.60)
+	r.Remove(2003, 6.93)
+	checkInt(r.Count(), 3, "Count", t)
+	checkFloat64(r.Slope(), -0.8600000000004419, REG_TOL, "Slope", t)
+	checkFloat64(r.Intercept(), 1729.3466666675515171, REG_TOL, "Intercept", t)
+	checkFloat64(r.RSquared(), 0.999819754866627, REG_TOL, "RSquared", t)
+	checkFloat64(r.SlopeStandardError(), 0.0115470053835452, 1e-8, "SlopeStandardError", t)
+	checkFloat64(r.InterceptStandardError(), 23.1055596960129250, 1e-6, "InterceptStandardError", t)
+
+	// remove all points, then start again
+	r.Remove(2000, 9.34)
+	r.Remove(2002, 7.62)
+	r.Remove(2001, 8.50)
+	checkInt(r.Count(), 0, "Count", t)
+	checkNaN(r.Slope(), "Slope", t)
+	r.Update(2000, 9.34)
+	r.Update(2001, 8.50)
+	checkFloat64(r.Slope(), -0.840000000000126, REG_TOL, "Slope", t)
+	checkFloat64(r.Intercept(), 1689.340000000251393, REG_TOL, "Intercept", t)
+
+	// the weighted points remain, even when their weights sum to less than 1
+	var w, whole Regression
+	whole.UpdateWeighted(2000, 9.34, 0.25)
+	whole.UpdateWeighted(2001, 8.50, 0.5)
+	w = whole
+	w.Update(2002, 7.62)
+	w.Remove(2002, 7.62)
+	checkInt(w.Count(), 2, "Count weighted", t)
+	checkFloat64(w.Slope(), whole.Slope(), 1e-10, "Slope weighted", t)
+	checkFloat64(w.Intercept(), whole.Intercept(), 1e-10, "Intercept weighted", t)
+}
+
+// Weighted least squares. The expected values are the same as R's
+//   w <- c(1, 2, 0.5, 3, 1.5)
+//   summary(lm(y ~ x, weights = w))
+func TestRegressionUpdateWeighted5(t *testing.T) {
+	var r Regression
+	xData := []float64{2000, 2001, 2002, 2003, 2004}
+	yData := []float64{9.34, 8.50, 7.62, 6.93, 6.60}
+	weights := []float64{1, 2, 0.5, 3, 1.5}
+	for i := range xData {
+		r.UpdateWeighted(xData[i], yData[i], weights[i])
+	}
+	checkWeightedRegression(&r, "UpdateWeighted", t)
+
+	// a zero weight point is ignored
+	r.UpdateWeighted(2005, 100.0, 0.0)
+	checkWeightedRegression(&r, "UpdateWeighted", t)
+
+	// split and merge
+	var r1, r2 Regression
+	r1.UpdateWeightedArray(xData[:2], yData[:2], weights[:2])
+	r2.UpdateWeightedArray(xData[2:], yData[2:], weights[2:])
+	r2.Merge(r1)
+	checkWeightedRegression(&r2, "Merge", t)
+
+	// add and remove a unit weight point
+	r2.Update(2010, -3.5)
+	r2.Remove(2010, -3.5)
+	checkWeightedRegression(&r2, "Remove", t)
+}
+
+func checkWeightedRegression(r *Regression, test string, t *testing.T) {
+	checkInt(r.Count(), 5, test+" Count", t)
+	checkFloat64(r.Slope(), -0.71034482758620698384, REG_TOL, test+" Slope", t)
+	checkFloat64(r.Intercept(), 1429.8929310344829332, REG_TOL, test+" Intercept", t)
+	checkFloat64(r.RSquared(), 0.97628220436037136796, REG_TOL, test+" RSquared", t)
+	checkFloat64(r.SlopeStandardError(), 0.063923135570753928432, REG_TOL, test+" SlopeStandardError", t)
+	checkFloat64(r.InterceptStandardError(), 127.99012712916038923, REG_TOL, test+" InterceptStandardError", t)
+}
+
+// Unit weights are ordinary least squares. Integer weights give the same fit as repeating
+// the points, but the standard errors differ because the degrees of freedom don't.
+func TestRegressionUpdateWeightedUnit(t *testing.T) {
+	var r, rw, rr Regression
+	xData := []float64{2000, 2001, 2002, 2003, 2004}
+	yData := []float64{9.34, 8.50, 7.62, 6.93, 6.60}
+	r.UpdateArray(xData, yData)
+	rw.UpdateWeightedArray(xData, yData, []float64{1, 1, 1, 1, 1})
+	checkFloat64(rw.Slope(), r.Slope(), REG_TOL, "Slope", t)
+	checkFloat64(rw.Intercept(), r.Intercept(), REG_TOL, "Intercept", t)
+	checkFloat64(rw.RSquared(), r.RSquared(), REG_TOL, "RSquared", t)
+	checkFloat64(rw.SlopeStandardError(), r.SlopeStandardError(), REG_TOL, "SlopeStandardError", t)
+	checkFloat64(rw.InterceptStandardError(), r.InterceptStandardError(), REG_TOL, "InterceptStandardError", t)
+
+	rw = Regression{}
+	rw.UpdateWeightedArray(xData, yData, []float64{1, 2, 1, 3, 1})
+	rr.UpdateArray([]float64{2000, 2001, 2001, 2002, 2003, 2003, 2003, 2004},
+		[]float64{9.34, 8.50, 8.50, 7.62, 6.93, 6.93, 6.93, 6.60})
+	checkFloat64(rw.Slope(), rr.Slope(), REG_TOL, "Slope", t)
+	checkFloat64(rw.Intercept(), rr.Intercept(), REG_TOL, "Intercept", t)
+	checkFloat64(rw.RSquared(), rr.RSquared(), REG_TOL, "RSquared", t)
+}
+
+// The t tests and confidence intervals, as in R's
+//   fit <- lm(y ~ x)
+//   summary(fit)
+//   confint(fit, level = 0.95)
+//   confint(fit, level = 0.99)
+// The expected values were computed in extended precision from the exact fit and the
+// closed form of the t distribution with 3 df, P(T > t) = (atan(1/r) - r/(1 + r^2))/pi,
+// r = t/sqrt(3).
+func TestRegressionTTest5(t *testing.T) {
+	var r Regression
+	r.UpdateArray([]float64{2000, 2001, 2002, 2003, 2004}, []float64{9.34, 8.50, 7.62, 6.93, 6.60})
+	tStat, df, p := r.SlopeTTest()
+	checkFloat64(tStat, -11.117882514850853, 1e-10, "SlopeTTest t", t)
+	checkFloat64(df, 3.0, 0.0, "SlopeTTest df", t)
+	checkFloat64(p, 0.0015591876492257108, 1e-9, "SlopeTTest p", t)
+	tStat, df, p = r.InterceptTTest()
+	checkFloat64(tStat, 11.179305709445483, 1e-8, "InterceptTTest t", t)
+	checkFloat64(df, 3.0, 0.0, "InterceptTTest df", t)
+	checkFloat64(p, 0.0015341070216409402, 1e-7, "InterceptTTest p", t)
+
+	lower, upper := r.SlopeConfidenceInterval(0.95)
+	checkFloat64(lower, -0.9068032338647276, 1e-10, "SlopeConfidenceInterval 95 lower", t)
+	checkFloat64(upper, -0.5031967661352723, 1e-10, "SlopeConfidenceInterval 95 upper", t)
+	lower, upper = r.SlopeConfidenceInterval(0.99)
+	checkFloat64(lower, -1.0753799763904284, 1e-10, "SlopeConfidenceInterval 99 lower", t)
+	checkFloat64(upper, -0.3346200236095715, 1e-10, "SlopeConfidenceInterval 99 upper", t)
+	lower, upper = r.InterceptConfidenceInterval(0.95)
+	checkFloat64(lower, 1015.1978250020117, 1e-8, "InterceptConfidenceInterval 95 lower", t)
+	checkFloat64(upper, 1823.2181749979886, 1e-8, "InterceptConfidenceInterval 95 upper", t)
+	lower, upper = r.InterceptConfidenceInterval(0.99)
+	checkFloat64(lower, 677.7071022614022, 1e-8, "InterceptConfidenceInterval 99 lower", t)
+	checkFloat64(upper, 2160.708897738598, 1e-8, "InterceptConfidenceInterval 99 upper", t)
+}
+
+// With weights, as summary(lm(y ~ x, weights = w)). The degrees of freedom are still n - 2.
+func TestRegressionTTestWeighted5(t *testing.T) {
+	var r Regression
+	r.UpdateWeightedArray([]float64{2000, 2001, 2002, 2003, 2004}, []float64{9.34, 8.50, 7.62, 6.93, 6.60},
+		[]float64{1, 2, 0.5, 3, 1.5})
+	tStat, df, p := r.SlopeTTest()
+	checkFloat64(tStat, -11.112484098968439, REG_TOL, "SlopeTTest t", t)
+	checkFloat64(df, 3.0, 0.0, "SlopeTTest df", t)
+	checkFloat64(p, 0.0015614178946325888, 1e-10, "SlopeTTest p", t)
+	tStat, _, p = r.InterceptTTest()
+	checkFloat64(tStat, 11.171900232519619, REG_TOL, "InterceptTTest t", t)
+	checkFloat64(p, 0.001537102348028267, 1e-10, "InterceptTTest p", t)
+	lower, upper := r.SlopeConfidenceInterval(0.95)
+	checkFloat64(lower, -0.9137767742055023, REG_TOL, "SlopeConfidenceInterval lower", t)
+	checkFloat64(upper, -0.5069128809669114, REG_TOL, "SlopeConfidenceInterval upper", t)
+	lower, upper = r.InterceptConfidenceInterval(0.99)
+	checkFloat64(lower, 682.3142059318137, REG_TOL, "InterceptConfidenceInterval lower", t)
+	checkFloat64(upper, 2177.4716561371515, REG_TOL, "InterceptConfidenceInterval upper", t)
+}
+
+// With 3 points, there's 1 df, the Cauchy distribution, and too few points for any tests.
+func TestRegressionTTestSmall(t *testing.T) {
+	var r Regression
+	r.UpdateArray([]float64{2000, 2001, 2002}, []float64{9.34, 8.50, 7.62})
+	tStat, df, p := r.SlopeTTest()
+	checkFloat64(tStat, -74.47818472546172, 1e-8, "SlopeTTest t", t)
+	checkFloat64(df, 1.0, 0.0, "SlopeTTest df", t)
+	checkFloat64(p, 0.008547221213176479, 1e-8, "SlopeTTest p", t)
+	lower, upper := r.SlopeConfidenceInterval(0.9)
+	checkFloat64(lower, -0.9329049227318809, 1e-8, "SlopeConfidenceInterval lower", t)
+	checkFloat64(upper, -0.7870950772681191, 1e-8, "SlopeConfidenceInterval upper", t)
+
+	r.Remove(2002, 7.62)
+	tStat, df, p = r.SlopeTTest()
+	checkNaN(tStat, "SlopeTTest t 2", t)
+	checkFloat64(df, 0.0, 0.0, "SlopeTTest df 2", t)
+	checkNaN(p, "SlopeTTest p 2", t)
+	lower, upper = r.InterceptConfidenceInterval(0.95)
+	checkNaN(lower, "InterceptConfidenceInterval lower 2", t)
+	checkNaN(upper, "InterceptConfidenceInterval upper 2", t)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Found no panic for test SlopeConfidenceInterval level 1")
+		}
+	}()
+	r.SlopeConfidenceInterval(1.0)
+}
+
+// Predictions with their intervals, as R's
+//   predict(fit, data.frame(x = c(2002, 2005, 1990)), interval = "confidence")
+//   predict(fit, data.frame(x = c(2002, 2005, 1990)), interval = "prediction")
+// The expected values were computed as for TestRegressionTTest5().
+func TestRegressionPredict5(t *testing.T) {
+	xData := []float64{2000, 2001, 2002, 2003, 2004}
+	yData := []float64{9.34, 8.50, 7.62, 6.93, 6.60}
+	var r Regression
+	r.UpdateArray(xData, yData)
+	checkPrediction(&r, 0.0, []float64{
+		2002, 7.798, 7.512607129737753, 8.083392870262248, 7.098933091629175, 8.497066908370826,
+		2005, 5.683, 5.013694391790356, 6.352305608209644, 4.758221405328009, 6.6077785946719905,
+		1990, 16.258, 13.819602247592437, 18.69639775240756, 13.737478416888901, 18.778521583111097,
+	}, "", t)
+
+	// offset by 1e9, as with Unix timestamps, the running sums keep the precision
+	var ro Regression
+	for i := range xData {
+		ro.Update(1e9+xData[i], yData[i])
+	}
+	checkPrediction(&ro, 1e9, []float64{
+		2005, 5.683, 5.013694391790356, 6.352305608209644, 4.758221405328009, 6.6077785946719905,
+	}, "Offset ", t)
+
+	var rw Regression
+	rw.UpdateWeightedArray(xData, yData, []float64{1, 2, 0.5, 3, 1.5})
+	checkPrediction(&rw, 0.0, []float64{
+		2002, 7.782586206896552, 7.504025541695114, 8.06114687209799, 6.959377776834913, 8.60579463695819,
+		2005, 5.651551724137931, 5.028671140889958, 6.274432307385905, 4.657541514877273, 6.645561933398589,
+		1990, 16.306724137931035, 13.7996781511181, 18.81377012474397, 13.682728014625605, 18.930720261236466,
+	}, "Weighted ", t)
+}
+
+// Check rows of x, fit, confidence band and prediction interval at the 95% level, with x
+// offset by the given amount.
+func checkPrediction(r *Regression, offset float64, rows []float64, test string, t *testing.T) {
+	for i := 0; i < len(rows); i += 6 {
+		x := rows[i] + offset
+		checkFloat64(r.Predict(x), rows[i+1], 1e-10, test+"Predict", t)
+		lower, upper := r.ConfidenceBand(x, 0.95)
+		checkFloat64(lower, rows[i+2], 1e-10, test+"ConfidenceBand lower", t)
+		checkFloat64(upper, rows[i+3], 1e-10, test+"ConfidenceBand upper", t)
+		lower, upper = r.PredictionInterval(x, 0.95)
+		checkFloat64(lower, rows[i+4], 1e-10, test+"PredictionInterval lower", t)
+		checkFloat64(upper, rows[i+5], 1e-10, test+"PredictionInterval upper", t)
+	}
+}
+
+// With 2 points, the line can be predicted but has no intervals.
+func TestRegressionPredict2(t *testing.T) {
+	var r Regression
+	r.UpdateArray([]float64{2000, 2001}, []float64{9.34, 8.50})
+	checkFloat64(r.Predict(2002), 7.66, REG_TOL, "Predict", t)
+	lower, upper := r.ConfidenceBand(2002, 0.95)
+	checkNaN(lower, "ConfidenceBand lower", t)
+	checkNaN(upper, "ConfidenceBand upper", t)
+	lower, upper = r.PredictionInterval(2002, 0.95)
+	checkNaN(lower, "PredictionInterval lower", t)
+	checkNaN(upper, "PredictionInterval upper", t)
+	var empty Regression
+	checkNaN(empty.Predict(1.0), "Predict empty", t)
+}
+
 //
 //
 // Test batch functions
@@ -173,6 +529,31 @@ func TestLinearRegression5(t *testing.T) {
 	checkFloat64(intcptStdErr, 126.9495652848741400, 1e-6, "InterceptStandardError", t)
 }
 
+func TestLinearRegressionOffset5(t *testing.T) {
+	xData := []float64{1e9 + 2000, 1e9 + 2001, 1e9 + 2002, 1e9 + 2003, 1e9 + 2004}
+	yData := []float64{9.34, 8.50, 7.62, 6.93, 6.60}
+	var slope, intercept, rsquared, count, slopeStdErr, intcptStdErr = LinearRegression(xData, yData)
+	checkFloat64(slope, -0.705, 1e-10, "Slope", t)
+	checkFloat64(intercept, 705001419.208000071054, 1e-10, "Intercept", t)
+	checkFloat64(rsquared, 0.976304686026777326, 1e-10, "RSquared", t)
+	checkInt(count, 5, "Count", t)
+	checkFloat64(slopeStdErr, 0.0634113554499507253, 1e-10, "SlopeStandardError", t)
+	checkFloat64(intcptStdErr, 63411482.3994843362, 1e-10, "InterceptStandardError", t)
+}
+
+func TestWeightedLinearRegression5(t *testing.T) {
+	xData := []float64{2000, 2001, 2002, 2003, 2004, 2005}
+	yData := []float64{9.34, 8.50, 7.62, 6.93, 6.60, 100.0}
+	weights := []float64{1, 2, 0.5, 3, 1.5, 0}
+	var slope, intercept, rsquared, count, slopeStdErr, intcptStdErr = WeightedLinearRegression(xData, yData, weights)
+	checkFloat64(slope, -0.71034482758620698384, REG_TOL, "Slope", t)
+	checkFloat64(intercept, 1429.8929310344829332, REG_TOL, "Intercept", t)
+	checkFloat64(rsquared, 0.97628220436037136796, REG_TOL, "RSquared", t)
+	checkInt(count, 5, "Count", t)
+	checkFloat64(slopeStdErr, 0.063923135570753928432, REG_TOL, "SlopeStandardError", t)
+	checkFloat64(intcptStdErr, 127.99012712916038923, REG_TOL, "InterceptStandardError", t)
+}
+
 //
 //
 // Degenerate examples tests
@@ -221,7 +602,7 @@ func TestLinearRegression2SameY(t *testing.T) {
 	var slope, intercept, rsquared, count, slopeStdErr, intcptStdErr = LinearRegression(xData, yData)
 	checkFloat64Abs(slope, 0.0, REG_TOL, "Slope", t)
 	checkFloat64(intercept, 0.3, REG_TOL, "Intercept", t)
-	checkInf(rsquared, "RSquared", t)
+	checkNaN(rsquared, "RSquared", t) // y has no variance, so R^2 = 0/0, as in R
 	checkInt(count, 2, "Count", t)
 	checkNaN(slopeStdErr, "SlopeStandardError", t)
 	checkNaN(intcptStdErr, "InterceptStandardError", t)
@@ -235,8 +616,9 @@ func TestLinearRegression3WithSame(t *testing.T) {
 	checkFloat64(intercept, 25.3, REG_TOL, "Intercept", t)
 	checkFloat64(rsquared, 1.0, REG_TOL, "RSquared", t)
 	checkInt(count, 3, "Count", t)
-	checkFloat64(slopeStdErr, 5.03103783538893e-15, 1e-8, "SlopeStandardError", t)
-	checkFloat64(intcptStdErr, 1.02325257636427e-13, 1e-6, "InterceptStandardError", t)
+	// a perfect fit; R's nonzero standard errors are rounding error
+	checkFloat64Abs(slopeStdErr, 5.03103783538893e-15, 1e-13, "SlopeStandardError", t)
+	checkFloat64Abs(intcptStdErr, 1.02325257636427e-13, 1e-12, "InterceptStandardError", t)
 }
 
 func TestLinearRegression3WithSameX(t *testing.T) {
@@ -255,7 +637,7 @@ func TestLinearRegression3WithSameY(t *testing.T) {
 	xData := []float64{2000, 2001, 2002}
 	yData := []float64{9.34, 8.50, 9.34}
 	var slope, intercept, rsquared, count, slopeStdErr, intcptStdErr = LinearRegression(xData, yData)
-	checkFloat64(slope, 0.0, REG_TOL, "Slope", t)
+	checkFloat64Abs(slope, 0.0, REG_TOL, "Slope", t)
 	checkFloat64Abs(intercept, 9.05999999971740, 1e-9, "Intercept", t)
 	checkFloat64Abs(rsquared, 8.69423995966795e-26, REG_TOL, "RSquared", t)
 	checkInt(count, 3, "Count", t)
@@ -0,0 +1,546 @@
+package stats
+
+//
+// regsummary.go
+//
+// Author:   Gary Boone
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// Changes:
+//           20261017   initial version
+//
+// Source:
+// https://github.com/GaryBoone/GoStats
+//
+// The summary of a linear regression, with the contents of R's summary(lm(y ~ x)): the
+// table of coefficients with their t tests, the residual standard error, R^2 and adjusted
+// R^2, and the F test of the regression. Its String() prints them as R does, following
+// the formatting rules of R's format(), format.pval() and printCoefmat().
+//
+// A Regression keeps only running sums, so its residuals are available only from the
+// batch form, LinearRegressionSummary(). As in R's print of summary.lm, they're printed
+// if there are at most 5 residual degrees of freedom, and otherwise summarized by their
+// quantiles of type 7, the minimum, quartiles and maximum.
+//
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// A row of the table of coefficients: the name, the estimate, its standard error, and the
+// t statistic and two-sided p-value of its test against 0.
+type CoefficientSummary struct {
+	Name                               string
+	Estimate, StdError, TValue, PValue float64
+}
+
+// The summary of a linear regression. Residuals holds the residuals in the order of the
+// points, and ResidualQuantiles their minimum, 1st quartile, median, 3rd quartile and
+// maximum. Both are nil if the residuals are unavailable.
+type RegressionSummary struct {
+	Count                 int
+	Coefficients          []CoefficientSummary // the intercept, then the slope
+	ResidualStandardError float64
+	DegreesOfFreedom      int
+	RSquared              float64
+	AdjustedRSquared      float64
+	FStatistic            float64
+	FNumeratorDF          int
+	FDenominatorDF        int
+	FPValue               float64
+	Residuals             []float64
+	ResidualQuantiles     []float64
+}
+
+// The summary of the regression. The residuals aren't kept, so Residuals and
+// ResidualQuantiles are nil. With fewer than 3 points, there are no residual degrees of
+// freedom, and the tests and residual standard error are NaN.
+func (r *Regression) Summary() RegressionSummary {
+	df := int(r.n) - 2
+	if df < 0 {
+		df = 0
+	}
+	s := RegressionSummary{
+		Count:                 r.Count(),
+		ResidualStandardError: math.NaN(),
+		DegreesOfFreedom:      df,
+		RSquared:              r.RSquared(),
+		AdjustedRSquared:      math.NaN(),
+		FStatistic:            math.NaN(),
+		FNumeratorDF:          1,
+		FDenominatorDF:        df,
+		FPValue:               math.NaN(),
+	}
+	intercept, slope := r.Intercept(), r.Slope()
+	interceptT, _, interceptP := r.InterceptTTest()
+	slopeT, _, slopeP := r.SlopeTTest()
+	s.Coefficients = []CoefficientSummary{
+		{"(Intercept)", intercept, r.InterceptStandardError(), interceptT, interceptP},
+		{"x", slope, r.SlopeStandardError(), slopeT, slopeP},
+	}
+	if df > 0 {
+		s.ResidualStandardError = r.residualStandardError()
+		s.AdjustedRSquared = 1.0 - (1.0-s.RSquared)*(r.n-1.0)/float64(df)
+		// with one predictor, F is the square of the slope's t statistic
+		s.FStatistic = slopeT * slopeT
+		s.FPValue = NewF(1.0, float64(df)).Survival(s.FStatistic)
+	}
+	return s
+}
+
+//
+//
+// Formatting as R
+//
+//
+
+// The digits of print(summary(lm)), max(3, getOption("digits") - 3).
+const summaryDigits = 4
+
+// The sign, the power of 10 and the number of significant digits of x when it's rounded to
+// the given significant digits, and whether rounding made it wider, as R's scientific().
+func rScientific(x float64, digits int) (neg bool, kpower, nsig int, roundingWidens bool) {
+	if x == 0.0 {
+		return false, 0, 1, false
+	}
+	neg = x < 0.0
+	r := math.Abs(x)
+	kp := int(math.Floor(math.Log10(r))) - digits + 1
+	alpha := r / math.Pow10(kp)
+	if alpha < math.Pow10(digits-1) {
+		alpha *= 10.0
+		kp--
+	}
+	alpha = math.RoundToEven(alpha)
+	nsig = digits
+	for j := 1; j <= digits; j++ {
+		alpha /= 10.0
+		if alpha != math.Floor(alpha) {
+			break
+		}
+		nsig--
+	}
+	if nsig == 0 {
+		nsig = 1
+		kp++
+	}
+	kpower = kp + digits - 1
+	rgt := digits - kpower
+	if rgt < 0 {
+		rgt = 0
+	}
+	roundingWidens = kpower > 0 && kpower <= 22 && r < math.Pow10(kpower)-0.5/math.Pow10(rgt)
+	return
+}
+
+// The values in the common format of R's format(x, digits): fixed notation with enough
+// decimals to show each value to the given significant digits, unless scientific notation
+// is narrower. The strings are right-justified to a common width.
+func formatReals(xs []float64, digits int) []string {
+	neg := false
+	finite := false
+	rgt, mxsl, mxns := 0, 0, 0
+	mxl, mnl := math.MinInt, math.MaxInt
+	special := 0
+	for _, x := range xs {
+		switch {
+		case math.IsNaN(x) || math.IsInf(x, 1):
+			special = max(special, 3)
+			continue
+		case math.IsInf(x, -1):
+			special = max(special, 4)
+			continue
+		}
+		finite = true
+		negI, kpower, nsig, widens := rScientific(x, digits)
+		left := kpower + 1
+		if widens {
+			left--
+		}
+		sleft := max(left, 1)
+		if negI {
+			sleft++
+			neg = true
+		}
+		rgt = max(rgt, nsig-left)
+		mxl = max(mxl, left)
+		mnl = min(mnl, left)
+		mxsl = max(mxsl, sleft)
+		mxns = max(mxns, nsig)
+	}
+	w, d, sci := special, 0, false
+	if finite {
+		if mxl < 0 {
+			mxsl = 1
+			if neg {
+				mxsl++
+			}
+		}
+		wF := mxsl + rgt
+		if rgt > 0 {
+			wF++
+		}
+		e := 1
+		if mxl > 100 || mnl <= -99 {
+			e = 2
+		}
+		// the width of [-]X.XXXe+XX
+		d = mxns - 1
+		wE := d + 4 + e
+		if neg {
+			wE++
+		}
+		if d > 0 {
+			wE++
+		}
+		if wF <= wE {
+			d = rgt
+			wE = wF
+		} else {
+			sci = true
+		}
+		w = max(w, wE)
+	}
+	out := make([]string, len(xs))
+	for i, x := range xs {
+		switch {
+		case math.IsNaN(x):
+			out[i] = fmt.Sprintf("%*s", w, "NaN")
+		case math.IsInf(x, 1):
+			out[i] = fmt.Sprintf("%*s", w, "Inf")
+		case math.IsInf(x, -1):
+			out[i] = fmt.Sprintf("%*s", w, "-Inf")
+		case x == 0.0:
+			out[i] = fmt.Sprintf("%*.*f", w, d, 0.0)
+		case sci:
+			out[i] = fmt.Sprintf("%*.*e", w, d, x)
+		default:
+			out[i] = fmt.Sprintf("%*.*f", w, d, x)
+		}
+	}
+	return out
+}
+
+// x rounded to the given decimal places, as R's round(x, digits). Negative places round
+// to tens, hundreds, etc.
+func roundDecimals(x float64, places int) float64 {
+	if places < 0 {
+		p := math.Pow10(-places)
+		return math.Round(x/p) * p
+	}
+	p := math.Pow10(places)
+	return math.Round(x*p) / p
+}
+
+// The values with those that are negligible beside the largest rounded to 0, as R's
+// zapsmall(x, digits).
+func zapSmall(xs []float64, digits int) []float64 {
+	mx := 0.0
+	for _, x := range xs {
+		mx = math.Max(mx, math.Abs(x))
+	}
+	if !(mx > 0.0) {
+		return xs
+	}
+	places := max(0, digits-int(math.Ceil(math.Log10(mx))))
+	out := make([]float64, len(xs))
+	for i, x := range xs {
+		out[i] = roundDecimals(x, places)
+	}
+	return out
+}
+
+// x as R's formatC(x, digits = 4), with the non-finite values shown as by format().
+func formatC(x float64) string {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return formatReals([]float64{x}, summaryDigits)[0]
+	}
+	return fmt.Sprintf("%.4g", x)
+}
+
+// x rounded to the given significant digits, as R's signif(x, digits).
+func signif(x float64, digits int) float64 {
+	if x == 0.0 || math.IsNaN(x) || math.IsInf(x, 0) {
+		return x
+	}
+	return roundDecimals(x, digits-1-int(math.Floor(math.Log10(math.Abs(x)))))
+}
+
+// The p-values as R's format.pval(pv, digits), with values below the float64 epsilon
+// shown as a bound. Those of at least 0.001 share a format, as do the smaller ones.
+func formatPValues(ps []float64, digits int) []string {
+	out := make([]string, len(ps))
+	var fixed, small []int
+	tiny := false
+	for i, p := range ps {
+		switch {
+		case math.IsNaN(p):
+			out[i] = "NA"
+		case p < epsilon:
+			tiny = true
+		case p == 0.0 || math.Floor(math.Log10(p)) >= -3:
+			fixed = append(fixed, i)
+		default:
+			small = append(small, i)
+		}
+	}
+	nc := 0
+	for _, group := range [][]int{fixed, small} {
+		values := make([]float64, len(group))
+		for j, i := range group {
+			values[j] = ps[i]
+		}
+		for j, str := range formatReals(values, digits) {
+			out[group[j]] = strings.TrimLeft(str, " ")
+			nc = max(nc, len(str))
+		}
+	}
+	if tiny {
+		digits = max(1, digits-2)
+		sep := " "
+		if len(fixed)+len(small) > 0 {
+			if digits > 1 && digits+6 > nc {
+				digits = max(1, nc-7)
+			}
+			if digits == 1 && nc <= 6 {
+				sep = ""
+			}
+		} else if digits == 1 {
+			sep = ""
+		}
+		bound := "<" + sep + strings.TrimLeft(formatReals([]float64{epsilon}, digits)[0], " ")
+		for i, p := range ps {
+			if p < epsilon {
+				out[i] = bound
+			}
+		}
+	}
+	return out
+}
+
+// The significance code of R's printCoefmat() for the p-value.
+func significanceStars(p float64) string {
+	switch {
+	case math.IsNaN(p):
+		return ""
+	case p <= 0.001:
+		return "***"
+	case p <= 0.01:
+		return "**"
+	case p <= 0.05:
+		return "*"
+	case p <= 0.1:
+		return "."
+	}
+	return " "
+}
+
+// The strings padded on the right to their common width, as R's format() of characters.
+func padRight(cells []string) []string {
+	w := 0
+	for _, c := range cells {
+		w = max(w, len(c))
+	}
+	out := make([]string, len(cells))
+	for i, c := range cells {
+		out[i] = fmt.Sprintf("%-*s", w, c)
+	}
+	return out
+}
+
+// Print a named vector as R does, each value under its name, right-justified to a common
+// width and followed by a space, in lines of at most 80 characters.
+func printNamedVector(b *strings.Builder, names, values []string) {
+	w := 0
+	for i := range names {
+		w = max(w, len(names[i]), len(values[i]))
+	}
+	perLine := max(1, 80/(w+1))
+	for start := 0; start < len(names); start += perLine {
+		end := min(start+perLine, len(names))
+		if start > 0 {
+			b.WriteString("\n")
+		}
+		for _, n := range names[start:end] {
+			fmt.Fprintf(b, "%*s ", w, n)
+		}
+		b.WriteString("\n")
+		for _, v := range values[start:end] {
+			fmt.Fprintf(b, "%*s ", w, v)
+		}
+		b.WriteString("\n")
+	}
+}
+
+// Print a matrix of strings as R's print(quote = FALSE, right = TRUE): the row names
+// left-justified, and each column right-justified to the width of its widest entry or
+// label, after a space.
+func printMatrix(b *strings.Builder, rowNames, colNames []string, cells [][]string) {
+	rw := 0
+	for _, n := range rowNames {
+		rw = max(rw, len(n))
+	}
+	widths := make([]int, len(colNames))
+	for j, n := range colNames {
+		widths[j] = len(n)
+		for _, row := range cells {
+			widths[j] = max(widths[j], len(row[j]))
+		}
+	}
+	fmt.Fprintf(b, "%*s", rw, "")
+	for j, n := range colNames {
+		fmt.Fprintf(b, " %*s", widths[j], n)
+	}
+	b.WriteString("\n")
+	for i, row := range cells {
+		fmt.Fprintf(b, "%-*s", rw, rowNames[i])
+		for j, c := range row {
+			fmt.Fprintf(b, " %*s", widths[j], c)
+		}
+		b.WriteString("\n")
+	}
+}
+
+// Print the residuals as R's print.summary.lm: their quantiles when there are more than
+// 5 residual degrees of freedom, and otherwise the residuals themselves.
+func (s RegressionSummary) printResiduals(b *strings.Builder) {
+	b.WriteString("Residuals:\n")
+	switch {
+	case s.DegreesOfFreedom > 5 || s.Residuals == nil:
+		values := formatReals(zapSmall(s.ResidualQuantiles, summaryDigits+1), summaryDigits)
+		printNamedVector(b, []string{"Min", "1Q", "Median", "3Q", "Max"}, values)
+	case s.DegreesOfFreedom > 0:
+		names := make([]string, len(s.Residuals))
+		for i := range names {
+			names[i] = fmt.Sprint(i + 1)
+		}
+		printNamedVector(b, names, formatReals(s.Residuals, summaryDigits))
+	default:
+		fmt.Fprintf(b, "ALL %d residuals are 0: no residual degrees of freedom!\n", s.Count)
+	}
+}
+
+// Print the table of coefficients as R's printCoefmat(). The estimates and standard
+// errors share a format, rounded to the decimals that show the smallest of them to the
+// summary's digits. The t values are rounded to 3 decimals, and the p-values are shown to
+// 3 significant digits. Their significance codes are shown if any is below 0.1.
+func (s RegressionSummary) printCoefficients(b *strings.Builder) (stars bool) {
+	n := len(s.Coefficients)
+	minAbs := math.Inf(1)
+	for _, c := range s.Coefficients {
+		for _, v := range []float64{c.Estimate, c.StdError} {
+			if v != 0.0 && !math.IsNaN(v) && !math.IsInf(v, 0) {
+				minAbs = math.Min(minAbs, math.Abs(v))
+			}
+		}
+	}
+	places := summaryDigits - 1
+	if !math.IsInf(minAbs, 1) {
+		places = max(1, summaryDigits-1-int(math.Floor(math.Log10(minAbs))))
+	}
+	// the estimates, then the standard errors
+	raw := make([]float64, 2*n)
+	tValues := make([]float64, n)
+	pValues := make([]float64, n)
+	for i, c := range s.Coefficients {
+		raw[i], raw[i+n] = c.Estimate, c.StdError
+		tValues[i] = roundDecimals(c.TValue, 3)
+		pValues[i] = c.PValue
+	}
+	values := make([]float64, 2*n)
+	for i, v := range raw {
+		values[i] = roundDecimals(v, places)
+	}
+	cs := formatReals(values, summaryDigits)
+	// values that round to 0 are shown to fewer digits instead
+	var zeroed []int
+	var zeroedValues []float64
+	for i, v := range raw {
+		if v != 0.0 && values[i] == 0.0 {
+			zeroed = append(zeroed, i)
+			zeroedValues = append(zeroedValues, v)
+		}
+	}
+	for j, str := range formatReals(zeroedValues, summaryDigits-1) {
+		cs[zeroed[j]] = str
+	}
+	ts := formatReals(tValues, summaryDigits)
+	ps := formatPValues(pValues, summaryDigits-1)
+
+	codes := make([]string, n)
+	for i, p := range pValues {
+		codes[i] = significanceStars(p)
+		stars = stars || p < 0.1
+	}
+	codes = padRight(codes)
+	rowNames := make([]string, n)
+	cells := make([][]string, n)
+	for i, c := range s.Coefficients {
+		rowNames[i] = c.Name
+		cells[i] = []string{cs[i], cs[i+n], ts[i], ps[i]}
+		for j, v := range []float64{c.Estimate, c.StdError, c.TValue, c.PValue} {
+			if math.IsNaN(v) {
+				cells[i][j] = "NA"
+			}
+		}
+		if stars {
+			cells[i] = append(cells[i], codes[i])
+		}
+	}
+	colNames := []string{"Estimate", "Std. Error", "t value", "Pr(>|t|)"}
+	if stars {
+		colNames = append(colNames, "")
+	}
+	printMatrix(b, rowNames, colNames, cells)
+	return
+}
+
+// The summary printed as R's summary(lm), without the call. The residuals are omitted if
+// they're unavailable.
+func (s RegressionSummary) String() string {
+	var b strings.Builder
+	if s.Residuals != nil || s.ResidualQuantiles != nil {
+		s.printResiduals(&b)
+		b.WriteString("\n")
+	}
+
+	b.WriteString("Coefficients:\n")
+	if s.printCoefficients(&b) {
+		b.WriteString("---\n")
+		b.WriteString("Signif. codes:  0 '***' 0.001 '**' 0.01 '*' 0.05 '.' 0.1 ' ' 1\n")
+	}
+
+	fmt.Fprintf(&b, "\nResidual standard error: %s on %d degrees of freedom\n",
+		strings.TrimLeft(formatReals([]float64{signif(s.ResidualStandardError, summaryDigits)}, 7)[0], " "),
+		s.DegreesOfFreedom)
+	fmt.Fprintf(&b, "Multiple R-squared:  %s,\tAdjusted R-squared:  %s \n", formatC(s.RSquared),
+		formatC(s.AdjustedRSquared))
+	fmt.Fprintf(&b, "F-statistic: %s on %d and %d DF,  p-value: %s\n", formatC(s.FStatistic), s.FNumeratorDF,
+		s.FDenominatorDF, formatPValues([]float64{s.FPValue}, summaryDigits)[0])
+	return b.String()
+}
+
+//
+//
+// Batch Functions
+//
+//
+
+// The summary of the least squares line through the points, as R's summary(lm(y ~ x)),
+// including the quantiles of the residuals.
+func LinearRegressionSummary(xData, yData []float64) RegressionSummary {
+	var r Regression
+	r.UpdateArray(xData, yData)
+	s := r.Summary()
+	if len(xData) > 0 {
+		s.Residuals = make([]float64, len(xData))
+		for i := range xData {
+			s.Residuals[i] = yData[i] - r.Predict(xData[i])
+		}
+		s.ResidualQuantiles = StatsQuantiles(s.Residuals, []float64{0.0, 0.25, 0.5, 0.75, 1.0}, 7)
+	}
+	return s
+}
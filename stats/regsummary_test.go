@@ -0,0 +1,266 @@
+package stats
+
+//
+// regsummary_test.go
+//
+// Author:   Gary Boone
+//
+// Test:
+//   go test stats.go stats_test.go quantile.go specfunc.go distributions.go regression.go regression_test.go regsummary.go regsummary_test.go
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// R test code:
+// x <- c(2000, 2001, 2002, 2003, 2004)
+// y <- c(9.34, 8.50, 7.62, 6.93, 6.60)
+// summary(lm(y ~ x))
+//
+// x <- 1:8
+// y <- c(3.1, 4.9, 7.2, 8.1, 11.3, 11.9, 15.2, 15.8)
+// quantile(resid(lm(y ~ x)))
+//
+// x <- 2000:2009
+// y <- c(1.2, 1.9, 2.4, 3.3, 3.9, 4.4, 5.2, 5.8, 6.7, 7.1)
+// summary(lm(y ~ x))
+//
+// x <- 1:7
+// y <- c(0.3, -0.5, 0.4, -0.2, 0.6, -0.4, 0.1)
+// summary(lm(y ~ x))
+//
+// print(c(-1.5, 10), digits = 4)
+// print(c(30.09886, -0.06823, 1.63392, 0.01012), digits = 4)
+// format.pval(c(0.0123, 1.49e-12, 1e-20, NA), digits = 3)
+//
+// The t tests are those of TestRegressionTTest5(), and the residual quantiles were
+// computed in rational arithmetic. The printouts follow the rules of R's formatReal(),
+// format.pval() and printCoefmat(), and omit the Call.
+//
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+var summaryX = []float64{2000, 2001, 2002, 2003, 2004}
+var summaryY = []float64{9.34, 8.50, 7.62, 6.93, 6.60}
+
+func TestLinearRegressionSummary5(t *testing.T) {
+	s := LinearRegressionSummary(summaryX, summaryY)
+	checkInt(s.Count, 5, "Count", t)
+	checkInt(len(s.Coefficients), 2, "Coefficients", t)
+	c := s.Coefficients[0]
+	if c.Name != "(Intercept)" {
+		t.Errorf("Found %v, but expected (Intercept) for test Coefficients name", c.Name)
+	}
+	checkFloat64(c.Estimate, 1419.208000000151287, REG_TOL, "Intercept Estimate", t)
+	checkFloat64(c.StdError, 126.9495652848741400, 1e-6, "Intercept StdError", t)
+	checkFloat64(c.TValue, 11.179305709445483, 1e-8, "Intercept TValue", t)
+	checkFloat64(c.PValue, 0.0015341070216409402, 1e-7, "Intercept PValue", t)
+	c = s.Coefficients[1]
+	if c.Name != "x" {
+		t.Errorf("Found %v, but expected x for test Coefficients name", c.Name)
+	}
+	checkFloat64(c.Estimate, -0.705000000000075, REG_TOL, "Slope Estimate", t)
+	checkFloat64(c.StdError, 0.0634113554499872, 1e-10, "Slope StdError", t)
+	checkFloat64(c.TValue, -11.117882514850853, 1e-10, "Slope TValue", t)
+	checkFloat64(c.PValue, 0.0015591876492257108, 1e-9, "Slope PValue", t)
+
+	checkFloat64(s.ResidualStandardError, 0.0634113554499872*math.Sqrt(10.0), 1e-10, "ResidualStandardError", t)
+	checkInt(s.DegreesOfFreedom, 3, "DegreesOfFreedom", t)
+	checkFloat64(s.RSquared, 0.976304686026756, REG_TOL, "RSquared", t)
+	checkFloat64(s.AdjustedRSquared, 1.0-(1.0-0.976304686026756)*4.0/3.0, 1e-10, "AdjustedRSquared", t)
+	checkFloat64(s.FStatistic, 11.117882514850853*11.117882514850853, 1e-10, "FStatistic", t)
+	checkInt(s.FNumeratorDF, 1, "FNumeratorDF", t)
+	checkInt(s.FDenominatorDF, 3, "FDenominatorDF", t)
+	checkFloat64(s.FPValue, 0.0015591876492257108, 1e-9, "FPValue", t)
+
+	expected := []float64{-0.178, -0.163, -0.003, 0.132, 0.212}
+	checkInt(len(s.ResidualQuantiles), 5, "ResidualQuantiles", t)
+	for i, q := range s.ResidualQuantiles {
+		checkFloat64Abs(q, expected[i], 1e-10, "ResidualQuantiles", t)
+	}
+}
+
+// The quantiles of 8 residuals interpolate between them.
+func TestLinearRegressionSummaryResiduals(t *testing.T) {
+	s := LinearRegressionSummary([]float64{1, 2, 3, 4, 5, 6, 7, 8},
+		[]float64{3.1, 4.9, 7.2, 8.1, 11.3, 11.9, 15.2, 15.8})
+	expected := []float64{-0.6488095238095238, -0.49464285714285716, -0.055357142857142855,
+		0.4148809523809524, 0.819047619047619}
+	for i, q := range s.ResidualQuantiles {
+		checkFloat64(q, expected[i], REG_TOL, "ResidualQuantiles", t)
+	}
+	checkFloat64(s.Coefficients[1].Estimate, 1.8773809523809524, REG_TOL, "Slope Estimate", t)
+	checkFloat64(s.RSquared, 0.985111595692638, REG_TOL, "RSquared", t)
+	checkInt(s.DegreesOfFreedom, 6, "DegreesOfFreedom", t)
+}
+
+// The incremental form matches the batch form, but without residuals.
+func TestRegressionSummary(t *testing.T) {
+	var r Regression
+	r.UpdateArray(summaryX, summaryY)
+	s := r.Summary()
+	b := LinearRegressionSummary(summaryX, summaryY)
+	if s.ResidualQuantiles != nil {
+		t.Errorf("Found %v, but expected nil for test ResidualQuantiles", s.ResidualQuantiles)
+	}
+	for i := range s.Coefficients {
+		checkFloat64(s.Coefficients[i].Estimate, b.Coefficients[i].Estimate, 0.0, "Estimate", t)
+		checkFloat64(s.Coefficients[i].PValue, b.Coefficients[i].PValue, 0.0, "PValue", t)
+	}
+	checkFloat64(s.FStatistic, b.FStatistic, 0.0, "FStatistic", t)
+	if strings.Contains(s.String(), "Residuals:") {
+		t.Errorf("Found residuals in the printout of a Regression summary")
+	}
+
+	// too few points for the tests
+	var r2 Regression
+	r2.UpdateArray(summaryX[:2], summaryY[:2])
+	s = r2.Summary()
+	checkNaN(s.Coefficients[1].TValue, "TValue 2", t)
+	checkNaN(s.ResidualStandardError, "ResidualStandardError 2", t)
+	checkNaN(s.AdjustedRSquared, "AdjustedRSquared 2", t)
+	checkNaN(s.FStatistic, "FStatistic 2", t)
+	checkNaN(s.FPValue, "FPValue 2", t)
+	found := LinearRegressionSummary(summaryX[:2], summaryY[:2]).String()
+	if !strings.Contains(found, "ALL 2 residuals are 0: no residual degrees of freedom!") ||
+		!strings.Contains(found, "p-value: NA") {
+		t.Errorf("Found\n%v\nbut expected no residual degrees of freedom for test String 2", found)
+	}
+
+	// the residual degrees of freedom don't go below 0
+	for n := 0; n < 3; n++ {
+		var r3 Regression
+		r3.UpdateArray(summaryX[:n], summaryY[:n])
+		s = r3.Summary()
+		checkInt(s.DegreesOfFreedom, 0, "DegreesOfFreedom n < 3", t)
+		checkInt(s.FDenominatorDF, 0, "FDenominatorDF n < 3", t)
+		found = s.String()
+		if !strings.Contains(found, "on 0 degrees of freedom") || !strings.Contains(found, "on 1 and 0 DF") {
+			t.Errorf("Found\n%v\nbut expected 0 degrees of freedom for %d points", found, n)
+		}
+	}
+
+	// a perfect fit has an infinite F statistic, printed as R prints it
+	found = LinearRegressionSummary([]float64{1, 2, 3}, []float64{2, 4, 6}).String()
+	if !strings.Contains(found, "Multiple R-squared:  1,\tAdjusted R-squared:  1 ") ||
+		!strings.Contains(found, "F-statistic: Inf on 1 and 1 DF") {
+		t.Errorf("Found\n%v\nbut expected an infinite F statistic for test String perfect fit", found)
+	}
+}
+
+// The printouts as R's, one line per string. As in R, the named vectors and the table of
+// coefficients keep their trailing spaces.
+func TestRegressionSummaryString(t *testing.T) {
+	// with at most 5 residual degrees of freedom, the residuals are printed, and the
+	// estimates and standard errors fit in fixed notation
+	expected := strings.Join([]string{
+		"Residuals:",
+		"     1      2      3      4      5 ",
+		" 0.132 -0.003 -0.178 -0.163  0.212 ",
+		"",
+		"Coefficients:",
+		"              Estimate Std. Error t value Pr(>|t|)   ",
+		"(Intercept) 1419.20800  126.94957   11.18  0.00153 **",
+		"x             -0.70500    0.06341  -11.12  0.00156 **",
+		"---",
+		"Signif. codes:  0 '***' 0.001 '**' 0.01 '*' 0.05 '.' 0.1 ' ' 1",
+		"",
+		"Residual standard error: 0.2005 on 3 degrees of freedom",
+		"Multiple R-squared:  0.9763,\tAdjusted R-squared:  0.9684 ",
+		"F-statistic: 123.6 on 1 and 3 DF,  p-value: 0.001559",
+		""}, "\n")
+	found := LinearRegressionSummary(summaryX, summaryY).String()
+	if found != expected {
+		t.Errorf("Found\n%v\nbut expected\n%v\nfor test String", found, expected)
+	}
+
+	// with more, their quantiles are printed, and the estimates and standard errors are in
+	// scientific notation, which is narrower
+	x := []float64{2000, 2001, 2002, 2003, 2004, 2005, 2006, 2007, 2008, 2009}
+	y := []float64{1.2, 1.9, 2.4, 3.3, 3.9, 4.4, 5.2, 5.8, 6.7, 7.1}
+	expected = strings.Join([]string{
+		"Residuals:",
+		"     Min       1Q   Median       3Q      Max ",
+		"-0.12485 -0.07924  0.00909  0.04258  0.17879 ",
+		"",
+		"Coefficients:",
+		"              Estimate Std. Error t value Pr(>|t|)    ",
+		"(Intercept) -1.331e+03  2.327e+01  -57.19 9.71e-12 ***",
+		"x            6.661e-01  1.161e-02   57.37 9.46e-12 ***",
+		"---",
+		"Signif. codes:  0 '***' 0.001 '**' 0.01 '*' 0.05 '.' 0.1 ' ' 1",
+		"",
+		"Residual standard error: 0.1055 on 8 degrees of freedom",
+		"Multiple R-squared:  0.9976,\tAdjusted R-squared:  0.9973 ",
+		"F-statistic: 3291 on 1 and 8 DF,  p-value: 9.465e-12",
+		""}, "\n")
+	found = LinearRegressionSummary(x, y).String()
+	if found != expected {
+		t.Errorf("Found\n%v\nbut expected\n%v\nfor test String", found, expected)
+	}
+
+	// without a p-value below 0.1, there are no significance codes
+	expected = strings.Join([]string{
+		"Residuals:",
+		"       1        2        3        4        5        6        7 ",
+		" 0.23571 -0.55714  0.35000 -0.24286  0.56429 -0.42857  0.07857 ",
+		"",
+		"Coefficients:",
+		"             Estimate Std. Error t value Pr(>|t|)",
+		"(Intercept)  0.071429   0.388351   0.184    0.861",
+		"x           -0.007143   0.086838  -0.082    0.938",
+		"",
+		"Residual standard error: 0.4595 on 5 degrees of freedom",
+		"Multiple R-squared:  0.001351,\tAdjusted R-squared:  -0.1984 ",
+		"F-statistic: 0.006766 on 1 and 5 DF,  p-value: 0.9376",
+		""}, "\n")
+	found = LinearRegressionSummary([]float64{1, 2, 3, 4, 5, 6, 7},
+		[]float64{0.3, -0.5, 0.4, -0.2, 0.6, -0.4, 0.1}).String()
+	if found != expected {
+		t.Errorf("Found\n%v\nbut expected\n%v\nfor test String", found, expected)
+	}
+
+	// p-values below the float64 epsilon are shown as bounds, as in R
+	x = make([]float64, 50)
+	y = make([]float64, 50)
+	for i := range x {
+		x[i] = float64(i)
+		y[i] = 2.0*x[i] + 0.01*math.Sin(float64(i))
+	}
+	found = LinearRegressionSummary(x, y).String()
+	if !strings.Contains(found, "<2e-16 ***") || !strings.Contains(found, "p-value: < 2.2e-16") {
+		t.Errorf("Found\n%v\nbut expected p-value bounds for test String", found)
+	}
+}
+
+// R's rules for formatting numbers, with the results of print(x, digits = 4).
+func TestFormatReals(t *testing.T) {
+	for _, c := range []struct {
+		xs       []float64
+		expected []string
+	}{
+		{[]float64{0.001}, []string{"0.001"}},
+		{[]float64{0.0001}, []string{"1e-04"}},
+		{[]float64{100000}, []string{"1e+05"}},
+		{[]float64{123456}, []string{"123456"}},
+		{[]float64{-1.5, 10}, []string{"-1.5", "10.0"}},
+		{[]float64{30.09886, -0.06823, 1.63392, 0.01012}, []string{"30.09886", "-0.06823", " 1.63392", " 0.01012"}},
+		{[]float64{0, 3.14159}, []string{"0.000", "3.142"}},
+		{[]float64{math.NaN(), 2}, []string{"NaN", "  2"}},
+	} {
+		found := formatReals(c.xs, 4)
+		for i := range found {
+			if found[i] != c.expected[i] {
+				t.Errorf("Found %q, but expected %q for test formatReals", found[i], c.expected[i])
+			}
+		}
+	}
+	ps := formatPValues([]float64{0.0123, 1.49e-12, 1e-20, math.NaN()}, 3)
+	for i, p := range []string{"0.0123", "1.49e-12", "< 2e-16", "NA"} {
+		if ps[i] != p {
+			t.Errorf("Found %q, but expected %q for test formatPValues", ps[i], p)
+		}
+	}
+}
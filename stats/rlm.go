@@ -0,0 +1,181 @@
+package stats
+
+//
+// rlm.go
+//
+// Author:   Gary Boone
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// Changes:
+//           20261017   initial version
+//
+// Source:
+// https://github.com/GaryBoone/GoStats
+//
+// M-estimator regression lines, as R's MASS::rlm(y ~ x, psi = psi.huber) and
+// rlm(y ~ x, psi = psi.bisquare) with their defaults. Rather than the squared residuals
+// of least squares, an M-estimator minimizes a loss that grows more slowly for large
+// residuals, so outliers have less influence:
+//
+// 1. Huber -- quadratic for scaled residuals |u| <= k and linear beyond, with the weights
+//    w(u) = min(1, k/|u|). k = 1.345 gives 95% efficiency for normal errors.
+// 2. Tukey's bisquare -- bounded, so gross outliers get no weight at all, with the weights
+//    w(u) = (1 - (u/c)^2)^2 for |u| < c and 0 beyond. c = 4.685 gives 95% efficiency.
+//
+// The fit is by iteratively reweighted least squares. Starting from the least squares
+// line, each iteration estimates the scale as the MAD of the residuals about 0,
+// median(|r|)/0.6745, computes the weights of the scaled residuals, and refits by
+// weighted least squares. It stops when the residuals change by a relative amount
+//   sqrt(sum((r_old - r)^2) / sum(r_old^2))
+// of at most the tolerance. The bisquare loss isn't convex, so its fit depends on the
+// starting line and may not be the global minimum.
+//
+// The standard errors are the asymptotic ones of Huber (1981), as in summary.rlm(). With
+// p = 2 coefficients, the scaled residuals u = r/s and the derivative of the psi function
+// psi(u) = u w(u),
+//   S = sum((r w(u))^2) / (n - p)
+//   kappa = 1 + p var(psi'(u)) / (n mean(psi'(u))^2)
+//   stddev = sqrt(S) kappa / mean(psi'(u))
+// and the standard errors are those of least squares with the residual standard error
+// replaced by stddev.
+//
+
+import (
+	"math"
+)
+
+// The loss functions of RobustLinearRegression().
+type RobustLoss int
+
+const (
+	HuberLoss RobustLoss = iota
+	BisquareLoss
+)
+
+// The options of RobustLinearRegression(). Zero values give the defaults of MASS::rlm().
+type RobustRegressionOptions struct {
+	Loss          RobustLoss
+	K             float64 // the tuning constant, 1.345 for Huber and 4.685 for bisquare
+	MaxIterations int     // the limit on the iterations, 20
+	Tolerance     float64 // the relative change in the residuals for convergence, 1e-4
+}
+
+// The weight of the scaled residual u, psi(u)/u, and the derivative psi'(u).
+func (o *RobustRegressionOptions) weight(u float64) (w, dpsi float64) {
+	k := o.K
+	switch o.Loss {
+	case HuberLoss:
+		if k == 0.0 {
+			k = 1.345
+		}
+		if math.Abs(u) <= k {
+			return 1.0, 1.0
+		}
+		return k / math.Abs(u), 0.0
+	case BisquareLoss:
+		if k == 0.0 {
+			k = 4.685
+		}
+		if math.Abs(u) < k {
+			t := (u / k) * (u / k)
+			return (1.0 - t) * (1.0 - t), (1.0 - t) * (1.0 - 5.0*t)
+		}
+		return 0.0, 0.0
+	}
+	panic("unknown loss in RobustLinearRegression()")
+}
+
+// The M-estimator regression line through the points, as MASS::rlm(). It returns the
+// coefficients, their standard errors as summary.rlm(), the final scale, the final weight
+// of each point, which are small for outliers, and whether the iterations converged. With
+// fewer than 3 points, the standard errors are NaN.
+func RobustLinearRegression(xData, yData []float64, opts RobustRegressionOptions) (slope, intercept,
+	slopeStdErr, interceptStdErr, scale float64, weights []float64, converged bool) {
+	if len(xData) != len(yData) {
+		panic("array lengths differ in RobustLinearRegression()")
+	}
+	if opts.K < 0.0 {
+		panic("negative tuning constant in RobustLinearRegression()")
+	}
+	maxIterations := opts.MaxIterations
+	if maxIterations == 0 {
+		maxIterations = 20
+	}
+	tolerance := opts.Tolerance
+	if tolerance == 0.0 {
+		tolerance = 1e-4
+	}
+	n := len(xData)
+
+	// start from the least squares line
+	var r Regression
+	r.UpdateArray(xData, yData)
+	slope, intercept = r.Slope(), r.Intercept()
+	residuals := make([]float64, n)
+	absResiduals := make([]float64, n)
+	setResiduals := func() {
+		for i := range xData {
+			residuals[i] = yData[i] - (intercept + slope*xData[i])
+		}
+	}
+	setResiduals()
+	weights = make([]float64, n)
+	for i := range weights {
+		weights[i] = 1.0
+	}
+
+	old := make([]float64, n)
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		copy(old, residuals)
+		for i, v := range residuals {
+			absResiduals[i] = math.Abs(v)
+		}
+		scale = StatsMedian(absResiduals) / 0.6745
+		if scale == 0.0 {
+			// a perfect fit of at least half of the points
+			converged = true
+			break
+		}
+		for i, v := range residuals {
+			weights[i], _ = opts.weight(v / scale)
+		}
+		r = Regression{}
+		r.UpdateWeightedArray(xData, yData, weights)
+		slope, intercept = r.Slope(), r.Intercept()
+		setResiduals()
+
+		var change, size float64
+		for i, v := range residuals {
+			change += (old[i] - v) * (old[i] - v)
+			size += old[i] * old[i]
+		}
+		if math.Sqrt(change/math.Max(1e-20, size)) <= tolerance {
+			converged = true
+			break
+		}
+	}
+
+	// the asymptotic standard errors
+	if n <= 2 || scale == 0.0 {
+		return slope, intercept, math.NaN(), math.NaN(), scale, weights, converged
+	}
+	p := 2.0
+	var S float64
+	var dpsi Stats
+	for _, v := range residuals {
+		w, d := opts.weight(v / scale)
+		S += (v * w) * (v * w)
+		dpsi.Update(d)
+	}
+	S /= float64(n) - p
+	mn := dpsi.Mean()
+	kappa := 1.0 + p*dpsi.SampleVariance()/(float64(n)*mn*mn)
+	stddev := math.Sqrt(S) * kappa / mn
+
+	var u Regression
+	u.UpdateArray(xData, yData)
+	slopeStdErr = stddev / math.Sqrt(u.m2x)
+	interceptStdErr = stddev * math.Sqrt(1.0/u.w+u.meanX*u.meanX/u.m2x)
+	return
+}
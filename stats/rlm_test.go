@@ -0,0 +1,104 @@
+package stats
+
+//
+// rlm_test.go
+//
+// Author:   Gary Boone
+//
+// Test:
+//   go test stats.go stats_test.go quantile.go specfunc.go distributions.go regression.go regression_test.go theilsen.go theilsen_test.go rlm.go rlm_test.go
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// R test code:
+// library(MASS)
+// x <- 1:12
+// y <- c(2.1, 3.9, 6.2, 7.8, 10.1, 12.2, 13.8, 16.1, 50.0, 19.9, 22.2, -30.0)
+// for (psi in list(psi.huber, psi.bisquare)) {
+//   fit <- rlm(y ~ x, psi = psi)
+//   print(summary(fit)$coefficients, digits = 17)
+//   print(c(fit$s, fit$w, fit$converged), digits = 17)
+// }
+//
+
+import (
+	"testing"
+)
+
+const RLM_TOL = 1e-9
+
+func TestRobustLinearRegressionHuber(t *testing.T) {
+	slope, intercept, slopeStdErr, interceptStdErr, scale, weights, converged :=
+		RobustLinearRegression(theilX, theilY, RobustRegressionOptions{})
+	checkFloat64(slope, 1.9930910263646495, RLM_TOL, "Slope", t)
+	checkFloat64(intercept, 0.06938020731391603, RLM_TOL, "Intercept", t)
+	checkFloat64(slopeStdErr, 0.022398974825101104, RLM_TOL, "SlopeStandardError", t)
+	checkFloat64(interceptStdErr, 0.16485199108849213, RLM_TOL, "InterceptStandardError", t)
+	checkFloat64(scale, 0.24325705258001856, RLM_TOL, "Scale", t)
+	if !converged {
+		t.Errorf("Found not converged for test Huber")
+	}
+	expected := []float64{1, 1, 1, 1, 1, 1, 1, 1, 0.010226560061235707, 1, 1, 0.006060513035728063}
+	for i, w := range weights {
+		checkFloat64(w, expected[i], RLM_TOL, "Weights", t)
+	}
+}
+
+func TestRobustLinearRegressionBisquare(t *testing.T) {
+	slope, intercept, slopeStdErr, interceptStdErr, scale, weights, converged :=
+		RobustLinearRegression(theilX, theilY, RobustRegressionOptions{Loss: BisquareLoss})
+	checkFloat64(slope, 2.002897742195055, RLM_TOL, "Slope", t)
+	checkFloat64(intercept, 0.01582731893272893, RLM_TOL, "Intercept", t)
+	checkFloat64(slopeStdErr, 0.017167715299271216, RLM_TOL, "SlopeStandardError", t)
+	checkFloat64(interceptStdErr, 0.1263509634536345, RLM_TOL, "InterceptStandardError", t)
+	checkFloat64(scale, 0.23722990796893267, RLM_TOL, "Scale", t)
+	if !converged {
+		t.Errorf("Found not converged for test Bisquare")
+	}
+	expected := []float64{0.9892766153796525, 0.9762910361606829, 0.9506069389217219,
+		0.9182392693561252, 0.9920741087550964, 0.9552640520714284, 0.9120803125014024,
+		0.9938967524129203, 0.0, 0.9665777163355822, 0.962529932640759, 0.0}
+	for i, w := range weights {
+		checkFloat64Abs(w, expected[i], RLM_TOL, "Weights", t)
+	}
+}
+
+// With a large tuning constant, Huber is least squares.
+func TestRobustLinearRegressionLeastSquares(t *testing.T) {
+	slope, intercept, _, _, _, weights, _ :=
+		RobustLinearRegression(theilX, theilY, RobustRegressionOptions{K: 1e6})
+	lsSlope, lsIntercept, _, _, _, _ := LinearRegression(theilX, theilY)
+	checkFloat64(slope, lsSlope, REG_TOL, "Slope", t)
+	checkFloat64(intercept, lsIntercept, REG_TOL, "Intercept", t)
+	for _, w := range weights {
+		checkFloat64(w, 1.0, TOL, "Weights", t)
+	}
+}
+
+// Limiting the iterations stops it short.
+func TestRobustLinearRegressionIterations(t *testing.T) {
+	_, _, _, _, _, _, converged :=
+		RobustLinearRegression(theilX, theilY, RobustRegressionOptions{MaxIterations: 1})
+	if converged {
+		t.Errorf("Found converged for test MaxIterations 1")
+	}
+	_, _, _, _, _, _, converged =
+		RobustLinearRegression(theilX, theilY, RobustRegressionOptions{MaxIterations: 1, Tolerance: 10.0})
+	if !converged {
+		t.Errorf("Found not converged for test Tolerance 10")
+	}
+}
+
+// An exact fit of most of the points has scale 0.
+func TestRobustLinearRegressionExact(t *testing.T) {
+	x := []float64{1, 2, 3, 4, 5}
+	y := []float64{3, 5, 7, 9, 11}
+	slope, intercept, slopeStdErr, _, scale, _, converged := RobustLinearRegression(x, y, RobustRegressionOptions{})
+	checkFloat64(slope, 2.0, TOL, "Slope", t)
+	checkFloat64(intercept, 1.0, TOL, "Intercept", t)
+	checkFloat64(scale, 0.0, TOL, "Scale", t)
+	checkNaN(slopeStdErr, "SlopeStandardError", t)
+	if !converged {
+		t.Errorf("Found not converged for test Exact")
+	}
+}
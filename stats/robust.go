@@ -0,0 +1,359 @@
+package stats
+
+//
+// robust.go
+//
+// Author:   Gary Boone
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// Changes:
+//           20261017   initial version
+//
+// Source:
+// https://github.com/GaryBoone/GoStats
+//
+// Robust estimators of location and scale, which are little affected by outliers:
+//
+// 1. Trimmed mean -- the mean of the data with a fraction trimmed from each end, as R's
+//    mean(x, trim).
+// 2. Winsorized mean and variance -- the mean and sample variance of the data with a
+//    fraction at each end replaced by the nearest value that remains, as in the WRS2
+//    package's winmean() and winvar().
+// 3. MAD -- the median absolute deviation from the median, times a constant, as R's
+//    mad(x, constant). The constant 1.4826 makes it consistent for the standard
+//    deviation of normal data.
+// 4. Sn and Qn -- the scale estimators of Rousseeuw and Croux, as the robustbase
+//    package's Sn() and Qn(). They're as robust as the MAD, with a 50% breakdown point,
+//    but more efficient, and they don't assume symmetry. See:
+//    P.J. Rousseeuw and C. Croux, Alternatives to the median absolute deviation, JASA
+//    88(424), 1993.
+//    C. Croux and P.J. Rousseeuw, Time-efficient algorithms for two highly robust
+//    estimators of scale, Computational Statistics 1, 1992.
+// 5. Huber's M-estimator of location, as MASS::huber(), with the MAD as the scale.
+//
+// Sn is multiplied by the consistency constant 1.1926 of the 1993 paper and the
+// small-sample correction factors of the 1992 paper, as in robustbase. Qn is multiplied by
+// the refined constant 2.21914 and the small-sample factors of robustbase 0.93 and later,
+// found by simulation: tabulated for n <= 12, and given by a polynomial in 1/n above.
+//
+// Like the quantiles, these work on a copy of the data, which isn't modified. The data
+// shouldn't contain NaNs.
+//
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// The constant that makes the MAD consistent for the standard deviation of normal data,
+// the default of R's mad().
+const MADNormalConstant = 1.4826
+
+// The mean of the data with the fraction trim, 0 <= trim <= 0.5, of the values removed
+// from each end, as R's mean(x, trim). floor(n * trim) values are removed from each end.
+// A trim of 0.5 gives the median.
+func StatsTrimmedMean(data []float64, trim float64) float64 {
+	checkTrim(trim, "StatsTrimmedMean()")
+	n := len(data)
+	if n == 0 {
+		return math.NaN()
+	}
+	if trim >= 0.5 {
+		return StatsMedian(data)
+	}
+	g := int(math.Floor(float64(n) * trim))
+	x := append([]float64(nil), data...)
+	sort.Float64s(x)
+	return StatsMean(x[g : n-g])
+}
+
+// The mean of the data after the floor(n * trim) values at each end, 0 <= trim <= 0.5,
+// are replaced by the nearest value that remains.
+func StatsWinsorizedMean(data []float64, trim float64) float64 {
+	checkTrim(trim, "StatsWinsorizedMean()")
+	if len(data) == 0 {
+		return math.NaN()
+	}
+	return StatsMean(winsorize(data, trim))
+}
+
+// The sample variance of the winsorized data, as for StatsWinsorizedMean().
+func StatsWinsorizedVariance(data []float64, trim float64) float64 {
+	checkTrim(trim, "StatsWinsorizedVariance()")
+	if len(data) == 0 {
+		return math.NaN()
+	}
+	return StatsSampleVariance(winsorize(data, trim))
+}
+
+func checkTrim(trim float64, caller string) {
+	if !(trim >= 0.0 && trim <= 0.5) {
+		panic("trim must be in [0, 0.5] in " + caller)
+	}
+}
+
+// A sorted copy of the data with the floor(n * trim) values at each end replaced by the
+// nearest value that remains.
+func winsorize(data []float64, trim float64) []float64 {
+	n := len(data)
+	x := append([]float64(nil), data...)
+	sort.Float64s(x)
+	g := int(math.Floor(float64(n) * trim))
+	if g > (n-1)/2 {
+		g = (n - 1) / 2
+	}
+	for i := 0; i < g; i++ {
+		x[i] = x[g]
+		x[n-1-i] = x[n-1-g]
+	}
+	return x
+}
+
+// The median absolute deviation from the median, times the given constant, as R's
+// mad(x, constant = constant). Use MADNormalConstant to estimate the standard deviation
+// of normal data, or 1 for the raw MAD.
+func StatsMAD(data []float64, constant float64) float64 {
+	if len(data) == 0 {
+		return math.NaN()
+	}
+	median := StatsMedian(data)
+	dev := make([]float64, len(data))
+	for i, v := range data {
+		dev[i] = math.Abs(v - median)
+	}
+	return constant * StatsMedian(dev)
+}
+
+// The Sn scale estimator of Rousseeuw and Croux, as robustbase's Sn(x),
+//
+//	Sn = 1.1926 c(n) lomed_i himed_j |x_i - x_j|
+//
+// where lomed is the low median, the (n+1)/2th order statistic rounded down, himed is the
+// high median, the n/2+1th, and c(n) is the small-sample correction factor. It takes
+// O(n log n) time.
+func StatsSn(data []float64) float64 {
+	n := len(data)
+	if n == 0 {
+		return math.NaN()
+	}
+	if n == 1 {
+		return 0.0
+	}
+	x := append([]float64(nil), data...)
+	sort.Float64s(x)
+
+	// for each i, the himed of the distances to all of the values, including itself
+	h := n/2 + 1
+	meds := make([]float64, n)
+	for i := range x {
+		meds[i] = kthDistance(x, i, h)
+	}
+	lomed, _ := orderStatistics(meds, (n+1)/2-1)
+	sn := 1.1926 * lomed
+
+	if n <= 9 {
+		sn *= []float64{0.743, 1.851, 0.954, 1.351, 0.993, 1.198, 1.005, 1.131}[n-2]
+	} else if n%2 == 1 {
+		sn *= float64(n) / (float64(n) - 0.9)
+	}
+	return sn
+}
+
+// The kth smallest, from 1, of the distances from x[i] to each value of the sorted x,
+// including the 0 to itself. The distances to the left and right are each increasing,
+// so this is the kth smallest of the union of two sorted lists, found by binary search.
+func kthDistance(x []float64, i, k int) float64 {
+	// left(t) = x[i] - x[i-t], t = 0..i, and right(t) = x[i+1+t] - x[i], t = 0..n-i-2
+	nl, nr := i+1, len(x)-i-1
+	left := func(t int) float64 { return x[i] - x[i-t] }
+	right := func(t int) float64 { return x[i+1+t] - x[i] }
+
+	// find a, the number taken from the left, so that the a smallest of the left and the
+	// k-a smallest of the right are the k smallest of all
+	lo, hi := k-nr, k
+	if lo < 0 {
+		lo = 0
+	}
+	if hi > nl {
+		hi = nl
+	}
+	for lo < hi {
+		a := (lo + hi) / 2
+		// too few from the left if the next left is smaller than the last right taken
+		if left(a) < right(k-a-1) {
+			lo = a + 1
+		} else {
+			hi = a
+		}
+	}
+	a := lo
+	switch {
+	case a == 0:
+		return right(k - 1)
+	case a == k:
+		return left(k - 1)
+	}
+	return math.Max(left(a-1), right(k-a-1))
+}
+
+// The Qn scale estimator of Rousseeuw and Croux, as robustbase's Qn(x),
+//
+//	Qn = 2.21914 d(n) {|x_i - x_j|; i < j}_(k),  k = h(h-1)/2,  h = n/2 + 1
+//
+// the kth order statistic of the pairwise distances, about their first quartile, where
+// d(n) is the small-sample correction factor. It takes O(n log n) expected time.
+func StatsQn(data []float64) float64 {
+	n := len(data)
+	if n == 0 {
+		return math.NaN()
+	}
+	if n == 1 {
+		return 0.0
+	}
+	x := append([]float64(nil), data...)
+	sort.Float64s(x)
+	h := n/2 + 1
+	return 2.21914 * qnCorrection(n) * kthPairwiseDifference(x, h*(h-1)/2)
+}
+
+// The small-sample correction factor d(n) of Qn, n >= 2, as in robustbase 0.93 and later.
+func qnCorrection(n int) float64 {
+	if n <= 12 {
+		return []float64{0.399356, 0.99365, 0.51321, 0.84401, 0.61220, 0.85877, 0.66993,
+			0.87344, 0.72014, 0.88906, 0.75743}[n-2]
+	}
+	m := float64(n)
+	if n%2 == 1 {
+		return 1.0 / (1.0 + (1.60188+(-2.1284-5.172/m)/m)/m)
+	}
+	return 1.0 / (1.0 + (3.67561+(1.9654+(6.987-77.0/m)/m)/m)/m)
+}
+
+// The kth smallest, from 1, of the differences x[j] - x[i], i < j, of the sorted x.
+//
+// The differences form a matrix whose rows, for each i, increase with j. For each row, it
+// keeps the range of columns that may still hold the kth smallest. A random candidate is
+// chosen as the pivot, the differences below and at most the pivot are counted in O(n)
+// by walking the rows, and the ranges are narrowed to the side that holds the kth. Each
+// step removes a random fraction of the candidates, so there are O(log n) steps. When few
+// candidates remain, they're selected directly.
+func kthPairwiseDifference(x []float64, k int) float64 {
+	n := len(x)
+	rnd := rand.New(rand.NewSource(1))
+	left := make([]int, n)  // the first candidate column of each row
+	right := make([]int, n) // one past the last candidate column of each row
+	for i := range x {
+		left[i], right[i] = i+1, n
+	}
+	lt := make([]int, n) // the first column of each row with a difference >= the pivot
+	le := make([]int, n) // the first column of each row with a difference > the pivot
+	for {
+		total := 0
+		for i := range x {
+			total += right[i] - left[i]
+		}
+		if total <= n {
+			// select among the remaining candidates, counting those removed on the left
+			below := 0
+			candidates := make([]float64, 0, total)
+			for i := range x {
+				below += left[i] - (i + 1)
+				for j := left[i]; j < right[i]; j++ {
+					candidates = append(candidates, x[j]-x[i])
+				}
+			}
+			v, _ := orderStatistics(candidates, k-below-1)
+			return v
+		}
+
+		// a random candidate as the pivot
+		r := rnd.Intn(total)
+		var pivot float64
+		for i := range x {
+			if r < right[i]-left[i] {
+				pivot = x[left[i]+r] - x[i]
+				break
+			}
+			r -= right[i] - left[i]
+		}
+
+		// count the differences below and at most the pivot; the boundaries move right
+		// as i increases
+		nlt, nle := 0, 0
+		jlt, jle := 1, 1
+		for i := range x {
+			if jlt < i+1 {
+				jlt = i + 1
+			}
+			for jlt < n && x[jlt]-x[i] < pivot {
+				jlt++
+			}
+			if jle < i+1 {
+				jle = i + 1
+			}
+			for jle < n && x[jle]-x[i] <= pivot {
+				jle++
+			}
+			lt[i], le[i] = jlt, jle
+			nlt += jlt - (i + 1)
+			nle += jle - (i + 1)
+		}
+
+		switch {
+		case k <= nlt:
+			// the kth is below the pivot
+			for i := range x {
+				if right[i] > lt[i] {
+					right[i] = lt[i]
+				}
+			}
+		case k > nle:
+			// the kth is above the pivot
+			for i := range x {
+				if left[i] < le[i] {
+					left[i] = le[i]
+				}
+			}
+		default:
+			return pivot
+		}
+	}
+}
+
+// Huber's M-estimator of location, as MASS::huber(x, k). Starting from the median, with
+// the scale s fixed at the MAD, it iterates
+//
+//	mu = mean(min(max(x, mu - k s), mu + k s))
+//
+// until mu changes by less than 1e-6 s. k = 1.5 is typical; smaller values are more
+// robust, and larger values closer to the mean. It returns the location and the scale.
+// If the MAD is 0, as when more than half of the values are the same, the location is
+// NaN, where MASS::huber() stops with an error.
+func StatsHuberLocation(data []float64, k float64) (location, scale float64) {
+	if !(k > 0.0) {
+		panic("k must be positive in StatsHuberLocation()")
+	}
+	if len(data) == 0 {
+		return math.NaN(), math.NaN()
+	}
+	mu := StatsMedian(data)
+	s := StatsMAD(data, MADNormalConstant)
+	if s == 0.0 {
+		return math.NaN(), s
+	}
+	n := float64(len(data))
+	for {
+		sum := 0.0
+		for _, v := range data {
+			sum += math.Min(math.Max(mu-k*s, v), mu+k*s)
+		}
+		mu1 := sum / n
+		if math.Abs(mu-mu1) < 1e-6*s {
+			break
+		}
+		mu = mu1
+	}
+	return mu, s
+}
@@ -0,0 +1,187 @@
+package stats
+
+//
+// robust_test.go
+//
+// Author:   Gary Boone
+//
+// Test:
+//   go test stats.go stats_test.go quantile.go robust.go robust_test.go
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// R test code:
+// library(robustbase); library(MASS); library(WRS2)
+// x <- c(1.0, -2.0, 13.0, 47.0, 115.0, -0.03, -123.4, 23.0, -23.04, 12.3)
+// for (d in list(x, c(x, 6))) {
+//   print(c(mean(d, trim = 0.1), mean(d, trim = 0.2), mean(d, trim = 0.5)), digits = 17)
+//   print(c(winmean(d, 0.1), winvar(d, 0.1), winmean(d, 0.2), winvar(d, 0.2)), digits = 17)
+//   print(c(mad(d), mad(d, constant = 1), Sn(d), Qn(d)), digits = 17)
+//   print(huber(d), digits = 17)
+// }
+//
+// Qn(d) is as robustbase 0.93 and later, with the constant 2.21914 and the refined
+// small-sample factors. Its expected values were computed from the definition, the kth
+// of the sorted pairwise distances, with those factors.
+//
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+var robustData = []float64{1.0, -2.0, 13.0, 47.0, 115.0, -0.03, -123.4, 23.0, -23.04, 12.3}
+var robustData11 = []float64{1.0, -2.0, 13.0, 47.0, 115.0, -0.03, -123.4, 23.0, -23.04, 12.3, 6.0}
+
+func TestStatsTrimmedMean(t *testing.T) {
+	checkFloat64(StatsTrimmedMean(robustData, 0.0), 6.283, TOL, "StatsTrimmedMean 0", t)
+	checkFloat64(StatsTrimmedMean(robustData, 0.1), 8.90375, TOL, "StatsTrimmedMean 0.1", t)
+	checkFloat64(StatsTrimmedMean(robustData, 0.2), 7.878333333333334, TOL, "StatsTrimmedMean 0.2", t)
+	checkFloat64(StatsTrimmedMean(robustData, 0.5), 6.65, TOL, "StatsTrimmedMean 0.5", t)
+	checkFloat64(StatsTrimmedMean(robustData11, 0.1), 8.581111111111111, TOL, "StatsTrimmedMean 0.1", t)
+	checkFloat64(StatsTrimmedMean(robustData11, 0.2), 7.61, TOL, "StatsTrimmedMean 0.2", t)
+	checkFloat64(StatsTrimmedMean(robustData11, 0.5), 6.0, TOL, "StatsTrimmedMean 0.5", t)
+	checkNaN(StatsTrimmedMean([]float64{}, 0.1), "StatsTrimmedMean empty", t)
+	// the caller's data isn't modified
+	checkFloat64(robustData[0], 1.0, TOL, "StatsTrimmedMean data", t)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Found no panic for test StatsTrimmedMean with trim 0.6")
+		}
+	}()
+	StatsTrimmedMean(robustData, 0.6)
+}
+
+func TestStatsWinsorized(t *testing.T) {
+	checkFloat64(StatsWinsorizedMean(robustData, 0.1), 9.519, TOL, "StatsWinsorizedMean 0.1", t)
+	checkFloat64(StatsWinsorizedVariance(robustData, 0.1), 603.09561, TOL, "StatsWinsorizedVariance 0.1", t)
+	checkFloat64(StatsWinsorizedMean(robustData, 0.2), 8.927, TOL, "StatsWinsorizedMean 0.2", t)
+	checkFloat64(StatsWinsorizedVariance(robustData, 0.2), 124.81973444444445, TOL, "StatsWinsorizedVariance 0.2", t)
+	checkFloat64(StatsWinsorizedMean(robustData11, 0.1), 9.19909090909091, TOL, "StatsWinsorizedMean 0.1", t)
+	checkFloat64(StatsWinsorizedVariance(robustData11, 0.1), 543.911809090909, TOL, "StatsWinsorizedVariance 0.1", t)
+	checkFloat64(StatsWinsorizedMean(robustData11, 0.2), 8.66090909090909, TOL, "StatsWinsorizedMean 0.2", t)
+	checkFloat64(StatsWinsorizedVariance(robustData11, 0.2), 113.11660909090908, TOL, "StatsWinsorizedVariance 0.2", t)
+
+	// with no trimming, they're the mean and variance
+	checkFloat64(StatsWinsorizedMean(robustData, 0.0), StatsMean(robustData), TOL, "StatsWinsorizedMean 0", t)
+	checkFloat64(StatsWinsorizedVariance(robustData, 0.0), StatsSampleVariance(robustData), TOL, "StatsWinsorizedVariance 0", t)
+	// with all trimmed, every value is the median
+	checkFloat64(StatsWinsorizedMean(robustData11, 0.5), 6.0, TOL, "StatsWinsorizedMean 0.5", t)
+	checkFloat64(StatsWinsorizedVariance(robustData11, 0.5), 0.0, TOL, "StatsWinsorizedVariance 0.5", t)
+}
+
+func TestStatsMAD(t *testing.T) {
+	checkFloat64(StatsMAD(robustData, MADNormalConstant), 18.5325, TOL, "StatsMAD", t)
+	checkFloat64(StatsMAD(robustData, 1.0), 12.5, TOL, "StatsMAD 1", t)
+	checkFloat64(StatsMAD(robustData11, MADNormalConstant), 11.8608, TOL, "StatsMAD", t)
+	checkFloat64(StatsMAD(robustData11, 1.0), 8.0, TOL, "StatsMAD 1", t)
+	checkNaN(StatsMAD([]float64{}, 1.0), "StatsMAD empty", t)
+}
+
+func TestStatsSnQn(t *testing.T) {
+	checkFloat64(StatsSn(robustData), 27.441726, TOL, "StatsSn", t)
+	checkFloat64(StatsQn(robustData), 36.772084945596, TOL, "StatsQn", t)
+	checkFloat64(StatsSn(robustData11), 19.483069306930698, TOL, "StatsSn 11", t)
+	checkFloat64(StatsQn(robustData11), 25.707520367452, TOL, "StatsQn 11", t)
+
+	// the small-sample factors
+	data := []float64{1, 2, 4, 8, 16}
+	sn := []float64{0.8861018, 2.2075026, 3.4132212, 4.8336078}
+	qn := []float64{0.88622687384, 2.205048461, 3.4166545182, 5.6189290542}
+	for n := 2; n <= 5; n++ {
+		checkFloat64(StatsSn(data[:n]), sn[n-2], TOL, "StatsSn small", t)
+		checkFloat64(StatsQn(data[:n]), qn[n-2], TOL, "StatsQn small", t)
+	}
+	checkFloat64(StatsSn([]float64{2.3}), 0.0, TOL, "StatsSn 1", t)
+	checkFloat64(StatsQn([]float64{2.3}), 0.0, TOL, "StatsQn 1", t)
+
+	// the last tabulated factor, and the first of each parity from the polynomial
+	y := make([]float64, 14)
+	for i := range y {
+		y[i] = float64(i*i%17) + 0.1*float64(i)
+	}
+	checkFloat64(StatsQn(y[:12]), 7.05954148284, TOL, "StatsQn 12", t)
+	checkFloat64(StatsQn(y[:13]), 6.207253909532957, TOL, "StatsQn 13", t)
+	checkFloat64(StatsQn(y[:14]), 7.320943046860388, TOL, "StatsQn 14", t)
+	checkNaN(StatsSn([]float64{}), "StatsSn empty", t)
+	checkNaN(StatsQn([]float64{}), "StatsQn empty", t)
+}
+
+// The fast algorithms give the same results as the definitions, with many repeated values.
+func TestStatsSnQnDefinitions(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, n := range []int{10, 11, 57, 200, 301} {
+		for _, spread := range []int{3, n, 1000000} {
+			data := make([]float64, n)
+			for i := range data {
+				data[i] = float64(rnd.Intn(spread)) + 0.5*rnd.Float64()*float64(spread%2)
+			}
+			sorted := append([]float64(nil), data...)
+			sort.Float64s(sorted)
+
+			// Sn: the lomed over i of the himed over j of |x_i - x_j|
+			meds := make([]float64, n)
+			for i := range sorted {
+				d := make([]float64, n)
+				for j := range sorted {
+					d[j] = math.Abs(sorted[i] - sorted[j])
+				}
+				sort.Float64s(d)
+				meds[i] = d[n/2]
+			}
+			sort.Float64s(meds)
+			cn := 1.0
+			if n%2 == 1 {
+				cn = float64(n) / (float64(n) - 0.9)
+			}
+			checkFloat64(StatsSn(data), 1.1926*cn*meds[(n+1)/2-1], 1e-12, "StatsSn definition", t)
+
+			// Qn: the kth of the pairwise distances
+			var d []float64
+			for i := range sorted {
+				for j := i + 1; j < n; j++ {
+					d = append(d, sorted[j]-sorted[i])
+				}
+			}
+			sort.Float64s(d)
+			h := n/2 + 1
+			checkFloat64(StatsQn(data), 2.21914*qnCorrection(n)*d[h*(h-1)/2-1], 1e-12,
+				"StatsQn definition", t)
+		}
+	}
+}
+
+func TestStatsHuberLocation(t *testing.T) {
+	mu, s := StatsHuberLocation(robustData, 1.5)
+	checkFloat64(mu, 7.878312725319678, TOL, "StatsHuberLocation", t)
+	checkFloat64(s, 18.5325, TOL, "StatsHuberLocation scale", t)
+	mu, s = StatsHuberLocation(robustData11, 1.5)
+	checkFloat64(mu, 7.609991393361733, TOL, "StatsHuberLocation 11", t)
+	checkFloat64(s, 11.8608, TOL, "StatsHuberLocation 11 scale", t)
+
+	// with a scale of 0, there's no estimate
+	mu, s = StatsHuberLocation([]float64{1, 1, 1, 2}, 1.5)
+	checkNaN(mu, "StatsHuberLocation MAD 0", t)
+	checkFloat64(s, 0.0, TOL, "StatsHuberLocation MAD 0 scale", t)
+}
+
+//
+//
+// Benchmark tests
+//
+//
+
+func BenchmarkStatsQn(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	data := make([]float64, 10000)
+	for i := range data {
+		data[i] = rnd.NormFloat64()
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		StatsQn(data)
+	}
+}
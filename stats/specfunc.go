@@ -0,0 +1,433 @@
+package stats
+
+//
+// specfunc.go
+//
+// Author:   Gary Boone
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// Changes:
+//           20261017   initial version
+//
+// Source:
+// https://github.com/GaryBoone/GoStats
+//
+// Special functions for the probability distributions:
+//
+// 1. The standard normal quantile, by Wichura's algorithm AS 241, accurate to about 1e-16.
+//    M.J. Wichura, Algorithm AS 241: The percentage points of the normal distribution,
+//    Applied Statistics 37(3), 1988.
+// 2. The regularized incomplete gamma functions P(a, x) and Q(a, x) = 1 - P(a, x), by
+//    their series for x < a + 1 and their continued fraction otherwise.
+// 3. The regularized incomplete beta function I_x(a, b), by its continued fraction, on x
+//    or, by symmetry, on 1 - x, whichever converges faster.
+// 4. The inverses of continuous CDFs, by Newton's method safeguarded by bisection.
+// See:
+// W.H. Press et al., Numerical Recipes, 3rd ed., sections 6.2 and 6.4, 2007.
+// 5. The binomial and Poisson probabilities, by Loader's saddle point expansion, as
+//    dbinom() and dpois() in R, which keeps their relative precision far into the tails.
+//    C. Loader, Fast and accurate computation of binomial probabilities, 2000.
+//
+// Each function returns both a probability and its complement, whichever is smaller being
+// computed directly, so that upper tails keep their relative precision rather than being
+// found as 1 - p. The prefactors x^a e^-x / Gamma(a) and x^a (1-x)^b / B(a, b) are
+// computed with Stirling's series for large parameters, which avoids the cancellation of
+// large logarithms. The results are accurate to about 1e-13 relative for parameters up to
+// the thousands.
+//
+
+import (
+	"math"
+)
+
+const (
+	epsilon    = 2.220446049250313e-16 // the spacing of float64 at 1
+	tiny       = 1e-300                // to avoid dividing by 0 in continued fractions
+	lnSqrt2Pi  = 0.9189385332046728    // log(sqrt(2 pi))
+	maxIterate = 1000
+)
+
+// The standard normal quantile at p, by AS 241.
+func normalQuantile(p float64) float64 {
+	switch {
+	case p <= 0.0:
+		return math.Inf(-1)
+	case p >= 1.0:
+		return math.Inf(1)
+	}
+	q := p - 0.5
+	if math.Abs(q) <= 0.425 {
+		r := 0.180625 - q*q
+		return q * (((((((2.5090809287301226727e+3*r+3.3430575583588128105e+4)*r+
+			6.7265770927008700853e+4)*r+4.5921953931549871457e+4)*r+
+			1.3731693765509461125e+4)*r+1.9715909503065514427e+3)*r+
+			1.3314166789178437745e+2)*r + 3.3871328727963666080e0) /
+			(((((((5.2264952788528545610e+3*r+2.8729085735721942674e+4)*r+
+				3.9307895800092710610e+4)*r+2.1213794301586595867e+4)*r+
+				5.3941960214247511077e+3)*r+6.8718700749205790830e+2)*r+
+				4.2313330701600911252e+1)*r + 1.0)
+	}
+	// the tail, from the smaller of p and 1 - p
+	r := p
+	if q > 0.0 {
+		r = 1.0 - p
+	}
+	r = math.Sqrt(-math.Log(r))
+	var x float64
+	if r <= 5.0 {
+		r -= 1.6
+		x = (((((((7.74545014278341407640e-4*r+2.27238449892691845833e-2)*r+
+			2.41780725177450611770e-1)*r+1.27045825245236838258e0)*r+
+			3.64784832476320460504e0)*r+5.76949722146069140550e0)*r+
+			4.63033784615654529590e0)*r + 1.42343711074968357734e0) /
+			(((((((1.05075007164441684324e-9*r+5.47593808499534494600e-4)*r+
+				1.51986665636164571966e-2)*r+1.48103976427480074590e-1)*r+
+				6.89767334985100004550e-1)*r+1.67638483018380384940e0)*r+
+				2.05319162663775882187e0)*r + 1.0)
+	} else {
+		r -= 5.0
+		x = (((((((2.01033439929228813265e-7*r+2.71155556874348757815e-5)*r+
+			1.24266094738807843860e-3)*r+2.65321895265761230930e-2)*r+
+			2.96560571828504891230e-1)*r+1.78482653991729133580e0)*r+
+			5.46378491116411436990e0)*r + 6.65790464350110377720e0) /
+			(((((((2.04426310338993978564e-15*r+1.42151175831644588870e-7)*r+
+				1.84631831751005468180e-5)*r+7.86869131145613259100e-4)*r+
+				1.48753612908506148525e-2)*r+1.36929880922735805310e-1)*r+
+				5.99832206555887937690e-1)*r + 1.0)
+	}
+	if q < 0.0 {
+		return -x
+	}
+	return x
+}
+
+// The standard normal CDF and its complement at x.
+func normalCDF(x float64) (p, q float64) {
+	return 0.5 * math.Erfc(-x/math.Sqrt2), 0.5 * math.Erfc(x/math.Sqrt2)
+}
+
+// The correction to Stirling's approximation,
+//
+//	log Gamma(x) - ((x - 1/2) log x - x + log(sqrt(2 pi))),
+//
+// by its asymptotic series, accurate for x >= 10.
+func stirlingCorrection(x float64) float64 {
+	r := 1.0 / (x * x)
+	return (1.0/12.0 + r*(-1.0/360.0+r*(1.0/1260.0+r*(-1.0/1680.0+r*(1.0/1188.0+
+		r*(-691.0/360360.0+r*(1.0/156.0))))))) / x
+}
+
+func logGamma(x float64) float64 {
+	lg, _ := math.Lgamma(x)
+	return lg
+}
+
+// log B(a, b) = log Gamma(a) + log Gamma(b) - log Gamma(a + b). When the larger parameter
+// is large, the ratio Gamma(a) / Gamma(a + b) is found from Stirling's series, without
+// subtracting large logarithms.
+func logBeta(a, b float64) float64 {
+	if a < b {
+		a, b = b, a
+	}
+	if a < 10.0 {
+		return logGamma(a) + logGamma(b) - logGamma(a+b)
+	}
+	// log Gamma(a) - log Gamma(a + b)
+	ratio := -b*math.Log(a) - (a+b-0.5)*math.Log1p(b/a) + b +
+		stirlingCorrection(a) - stirlingCorrection(a+b)
+	if b < 10.0 {
+		return logGamma(b) + ratio
+	}
+	return lnSqrt2Pi + (b-0.5)*math.Log(b) - b + stirlingCorrection(b) + ratio
+}
+
+// log(x^a e^-x / Gamma(a)), the prefactor of the incomplete gamma functions.
+func logGammaPrefactor(a, x float64) float64 {
+	if a < 10.0 {
+		return a*math.Log(x) - x - logGamma(a)
+	}
+	// a log(x/a) - (x - a) + 1/2 log(a) - log(sqrt(2 pi)) by Stirling's series, with the
+	// first two terms from log1p() near the mode, where they cancel
+	e := (x - a) / a
+	lead := a*math.Log(x/a) - (x - a)
+	if math.Abs(e) < 0.5 {
+		lead = a * (math.Log1p(e) - e)
+	}
+	return lead + 0.5*math.Log(a) - lnSqrt2Pi - stirlingCorrection(a)
+}
+
+// The number of terms of the series and continued fractions with parameters up to a,
+// which need about sqrt(a) of them near the mode.
+func iterationLimit(a float64) int {
+	return maxIterate + int(10.0*math.Sqrt(a))
+}
+
+// The regularized incomplete gamma functions P(a, x) and Q(a, x) = 1 - P(a, x), a > 0.
+func incompleteGamma(a, x float64) (p, q float64) {
+	switch {
+	case x <= 0.0:
+		return 0.0, 1.0
+	case math.IsInf(x, 1):
+		return 1.0, 0.0
+	}
+	prefactor := logGammaPrefactor(a, x)
+	limit := iterationLimit(a)
+	if x < a+1.0 {
+		// the series P = x^a e^-x / Gamma(a + 1) sum(x^n / ((a + 1) ... (a + n)))
+		sum, term := 1.0/a, 1.0/a
+		for n := 1; n < limit; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*epsilon {
+				break
+			}
+		}
+		p = sum * math.Exp(prefactor)
+		return p, 1.0 - p
+	}
+	// the continued fraction for Q by the modified Lentz method
+	b := x + 1.0 - a
+	c := 1.0 / tiny
+	d := 1.0 / b
+	h := d
+	for n := 1; n < limit; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2.0
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1.0 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1.0) < epsilon {
+			break
+		}
+	}
+	q = math.Exp(prefactor) * h
+	return 1.0 - q, q
+}
+
+// The error of Stirling's approximation to n!, log(n!) - log(sqrt(2 pi n) (n/e)^n), n > 0.
+func stirlingError(n float64) float64 {
+	if n >= 10.0 {
+		// the same as the correction of log Gamma(n)
+		return stirlingCorrection(n)
+	}
+	return logGamma(n+1.0) - (n+0.5)*math.Log(n) + n - lnSqrt2Pi
+}
+
+// The deviance term x log(x/np) + np - x, by its series when x is near np, where the terms
+// cancel.
+func bd0(x, np float64) float64 {
+	if math.Abs(x-np) >= 0.1*(x+np) {
+		return x*math.Log(x/np) + np - x
+	}
+	v := (x - np) / (x + np)
+	s := (x - np) * v
+	ej := 2.0 * x * v
+	v *= v
+	for j := 1; j < maxIterate; j++ {
+		ej *= v
+		next := s + ej/float64(2*j+1)
+		if next == s {
+			break
+		}
+		s = next
+	}
+	return s
+}
+
+// The log of the binomial probability of x successes in n trials with the probability p
+// of success and q = 1 - p of failure. n needn't be an integer.
+func logBinomialProbability(x, n, p, q float64) float64 {
+	switch {
+	case x < 0.0 || x > n:
+		return math.Inf(-1)
+	case p == 0.0:
+		if x == 0.0 {
+			return 0.0
+		}
+		return math.Inf(-1)
+	case q == 0.0:
+		if x == n {
+			return 0.0
+		}
+		return math.Inf(-1)
+	case x == 0.0:
+		if p < q {
+			return n * math.Log1p(-p)
+		}
+		return n * math.Log(q)
+	case x == n:
+		if q < p {
+			return n * math.Log1p(-q)
+		}
+		return n * math.Log(p)
+	}
+	lc := stirlingError(n) - stirlingError(x) - stirlingError(n-x) - bd0(x, n*p) - bd0(n-x, n*q)
+	lf := 2.0*lnSqrt2Pi + math.Log(x) + math.Log1p(-x/n)
+	return lc - 0.5*lf
+}
+
+// The log of the Poisson probability of x events with the mean lambda.
+func logPoissonProbability(x, lambda float64) float64 {
+	switch {
+	case x < 0.0:
+		return math.Inf(-1)
+	case x == 0.0:
+		return -lambda
+	}
+	return -stirlingError(x) - bd0(x, lambda) - lnSqrt2Pi - 0.5*math.Log(x)
+}
+
+// The probabilities r/(1 + r) and 1/(1 + r) of the odds r >= 0, without overflow when
+// r is large or +Inf.
+func oddsProbabilities(r float64) (p, q float64) {
+	if r <= 1.0 {
+		return r / (1.0 + r), 1.0 / (1.0 + r)
+	}
+	s := 1.0 / r
+	return 1.0 / (1.0 + s), s / (1.0 + s)
+}
+
+// The regularized incomplete beta function I_x(a, b) and its complement
+// 1 - I_x(a, b) = I_y(b, a), a, b > 0. y = 1 - x is given separately, so that it keeps
+// its precision when x is near 1.
+func incompleteBeta(a, b, x, y float64) (p, q float64) {
+	switch {
+	case x <= 0.0:
+		return 0.0, 1.0
+	case y <= 0.0:
+		return 1.0, 0.0
+	}
+	// the log of the larger of x and y is found from the smaller, which keeps its precision
+	var logX, logY float64
+	if x <= 0.5 {
+		logX, logY = math.Log(x), math.Log1p(-x)
+	} else {
+		logX, logY = math.Log1p(-y), math.Log(y)
+	}
+	prefactor := math.Exp(a*logX + b*logY - logBeta(a, b))
+	if x < (a+1.0)/(a+b+2.0) {
+		p = prefactor * betaContinuedFraction(a, b, x) / a
+		return p, 1.0 - p
+	}
+	q = prefactor * betaContinuedFraction(b, a, y) / b
+	return 1.0 - q, q
+}
+
+// The continued fraction of the incomplete beta function by the modified Lentz method.
+func betaContinuedFraction(a, b, x float64) float64 {
+	qab, qap, qam := a+b, a+1.0, a-1.0
+	c := 1.0
+	d := 1.0 - qab*x/qap
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1.0 / d
+	h := d
+	limit := iterationLimit(math.Max(a, b))
+	for m := 1; m < limit; m++ {
+		fm := float64(m)
+		m2 := 2.0 * fm
+		// the even step
+		aa := fm * (b - fm) * x / ((qam + m2) * (a + m2))
+		d = 1.0 + aa*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1.0 + aa/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1.0 / d
+		h *= d * c
+		// the odd step
+		aa = -(a + fm) * (qab + fm) * x / ((a + m2) * (qap + m2))
+		d = 1.0 + aa*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1.0 + aa/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1.0 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1.0) < epsilon {
+			break
+		}
+	}
+	return h
+}
+
+// The x in [lo, hi] at which a continuous distribution has the CDF p, 0 < p < 1, given
+// its CDF and complement, and its density. hi may be +Inf. Starting from the guess x,
+// Newton steps are taken on the CDF if p <= 1/2 and on the complement otherwise, so the
+// smaller tail keeps its precision. Steps that leave the bracket of the root are replaced
+// by bisection, geometric if the bracket spans orders of magnitude, or by doubling if
+// it's unbounded.
+func invertCDF(p float64, cdf func(float64) (float64, float64), pdf func(float64) float64,
+	lo, hi, x float64) float64 {
+	if p <= 0.5 {
+		return invertTail(p, false, cdf, pdf, lo, hi, x)
+	}
+	return invertTail(1.0-p, true, cdf, pdf, lo, hi, x)
+}
+
+// As invertCDF(), for the x at which the lower tail, or if upper is set, the upper tail,
+// has probability target.
+func invertTail(target float64, upper bool, cdf func(float64) (float64, float64),
+	pdf func(float64) float64, lo, hi, x float64) float64 {
+	// f increases with x and is 0 at the root
+	f := func(x float64) float64 {
+		p, q := cdf(x)
+		if upper {
+			return target - q
+		}
+		return p - target
+	}
+	if !(x > lo && x < hi) {
+		x = lo + 1.0
+		if !math.IsInf(hi, 1) {
+			x = 0.5 * (lo + hi)
+		}
+	}
+	for i := 0; i < maxIterate; i++ {
+		v := f(x)
+		if v == 0.0 {
+			return x
+		}
+		if v < 0.0 {
+			lo = x
+		} else {
+			hi = x
+		}
+		next := x - v/pdf(x)
+		if !(next > lo && next < hi) {
+			switch {
+			case math.IsInf(hi, 1):
+				next = 2.0*math.Abs(x) + 1.0
+			case lo > 0.0 && hi > 4.0*lo:
+				next = math.Sqrt(lo * hi)
+			case lo == 0.0 && hi > 0.0:
+				next = hi / 4.0
+			default:
+				next = 0.5 * (lo + hi)
+			}
+		}
+		if math.Abs(next-x) <= 4.0*epsilon*math.Abs(next) ||
+			(!math.IsInf(hi, 1) && hi-lo <= 4.0*epsilon*math.Abs(hi)) {
+			return next
+		}
+		x = next
+	}
+	return x
+}
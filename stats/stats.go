@@ -11,6 +11,9 @@ package stats
 //           20110618   initial version
 //           20110705   added RandNormal() and tests/benchmarks
 //           20130121	Go1 cleanup; documentation cleanup
+//           20261017   added Merge() and MergeAll()
+//           20261017   added Remove()
+//           20261017   added weighted updates and batch functions
 //
 // Source:
 // https://github.com/GaryBoone/GoStats
@@ -29,6 +32,16 @@ package stats
 // Descriptions of the skew and kurtosis calculations can be found here:
 // http://www.tc3.edu/instruct/sbrown/stat/shape.htm
 //
+// Values may also be given weights. The count, sum and moments are then weighted, with the
+// count being the sum of the weights. Count() truncates it to an int; SumOfWeights() gives
+// it exactly. The sample statistics come in two forms:
+// 1. Frequency weights -- a weight is the number of times the value occurred, as with
+//    pre-aggregated (value, count) pairs. SampleVariance(), etc. treat the weights this way.
+// 2. Reliability weights -- a weight is the relative importance of the value. The
+//    ReliabilitySample*() functions use the effective number of values, sum(w)^2/sum(w^2),
+//    in place of the count. Then the variance is sum(w*(x-mean)^2)/(sum(w) - sum(w^2)/sum(w)).
+// With unit weights the two are the same.
+//
 // For build/test help, see README.md.
 //
 
@@ -39,6 +52,9 @@ import (
 // Data structure to contain accumulating values and moments
 type Stats struct {
 	n, min, max, sum, mean, m2, m3, m4 float64
+	w2                                 float64 // sum of the squared weights
+	values                             float64 // the number of values, whatever their weights
+	minLost, maxLost                   bool    // a Remove() discarded the min or max
 }
 
 // 
@@ -47,19 +63,34 @@ type Stats struct {
 //
 //
 
+// The number of values. With weights, it's the sum of the weights truncated to an int.
 func (d *Stats) Count() int {
 	return int(d.n)
 }
 
+// The sum of the weights, which is the number of values if none were weighted.
+func (d *Stats) SumOfWeights() float64 {
+	return d.n
+}
+
 func (d *Stats) Size() int {
 	return int(d.n)
 }
 
+// Min() and Max() return NaN once a Remove() may have removed the min or max value,
+// respectively, because the remaining min or max can't be known without the data. They
+// stay NaN until all values have been removed.
 func (d *Stats) Min() float64 {
+	if d.minLost {
+		return math.NaN()
+	}
 	return d.min
 }
 
 func (d *Stats) Max() float64 {
+	if d.maxLost {
+		return math.NaN()
+	}
 	return d.max
 }
 
@@ -86,6 +117,8 @@ func (d *Stats) Update(x float64) {
 		d.max = x
 	}
 	d.sum += x
+	d.w2 += 1.0
+	d.values += 1.0
 	nMinus1 := d.n
 	d.n += 1.0
 	delta := x - d.mean
@@ -105,6 +138,125 @@ func (d *Stats) UpdateArray(data []float64) {
 	}
 }
 
+// Update the stats with the given value having weight w. A value with weight 2.0 is the
+// same as two Update()s of it. Negative weights panic.
+func (d *Stats) UpdateWeighted(x, w float64) {
+	if w < 0.0 {
+		panic("negative weight in UpdateWeighted()")
+	}
+	if w == 0.0 {
+		return
+	}
+	if d.n == 0.0 || x < d.min {
+		d.min = x
+	}
+	if d.n == 0.0 || x > d.max {
+		d.max = x
+	}
+	d.sum += w * x
+	d.w2 += w * w
+	d.values += 1.0
+	d.addMoments(x, w)
+}
+
+// Update the stats with the given arrays of values and their weights.
+func (d *Stats) UpdateWeightedArray(data, weights []float64) {
+	if len(data) != len(weights) {
+		panic("array lengths differ in UpdateWeightedArray()")
+	}
+	for i, v := range data {
+		d.UpdateWeighted(v, weights[i])
+	}
+}
+
+// Remove a value that was previously added with Update(), that is, with weight 1. The
+// count, sum, mean and higher moments return to what they would be had the value never
+// been added. If the value is at or below the current min, Min() becomes invalid and
+// returns NaN, and likewise for Max(). Removing the last value resets the Stats. Removing
+// from an empty Stats panics.
+func (d *Stats) Remove(x float64) {
+	if d.values == 0.0 {
+		panic("Remove() called on empty Stats")
+	}
+	if d.values == 1.0 {
+		*d = Stats{}
+		return
+	}
+	if x <= d.min {
+		d.minLost = true
+	}
+	if x >= d.max {
+		d.maxLost = true
+	}
+	d.sum -= x
+	d.w2 -= 1.0
+	d.values -= 1.0
+	d.addMoments(x, -1.0)
+}
+
+// Combine a single value having weight w into the count and moments, using the pairwise
+// merge formulas below. A weight of -1.0 removes the value.
+func (d *Stats) addMoments(x, w float64) {
+	na := d.n
+	n := na + w
+	delta := x - d.mean
+	delta_n := delta / n
+	delta_n2 := delta_n * delta_n
+	term1 := delta * delta_n * na * w
+	d.n = n
+	d.mean += delta_n * w
+	d.m4 += term1*delta_n2*(na*na-na*w+w*w) + 6.0*delta_n2*w*w*d.m2 - 4.0*delta_n*w*d.m3
+	d.m3 += term1*delta_n*(na-w) - 3.0*delta_n*w*d.m2
+	d.m2 += term1
+}
+
+// Merge the values accumulated in another Stats into this one. The result is the same
+// as if all of the other's values had been passed to Update(). The moments are combined
+// with the pairwise formulas of Chan et al. and Pébay, so the skew and kurtosis remain
+// exact. This allows stats accumulated in parallel or on separate machines to be combined.
+func (d *Stats) Merge(other Stats) {
+	if other.n == 0.0 {
+		return
+	}
+	if d.n == 0.0 {
+		*d = other
+		return
+	}
+	if other.min < d.min {
+		d.min = other.min
+	}
+	if other.max > d.max {
+		d.max = other.max
+	}
+	d.minLost = d.minLost || other.minLost
+	d.maxLost = d.maxLost || other.maxLost
+	d.sum += other.sum
+	d.w2 += other.w2
+	d.values += other.values
+	na, nb := d.n, other.n
+	n := na + nb
+	delta := other.mean - d.mean
+	delta_n := delta / n
+	delta_n2 := delta_n * delta_n
+	term1 := delta * delta_n * na * nb
+	m2, m3, m4 := d.m2, d.m3, d.m4
+	d.n = n
+	d.mean += delta_n * nb
+	d.m4 = m4 + other.m4 + term1*delta_n2*(na*na-na*nb+nb*nb) +
+		6.0*delta_n2*(na*na*other.m2+nb*nb*m2) + 4.0*delta_n*(na*other.m3-nb*m3)
+	d.m3 = m3 + other.m3 + term1*delta_n*(na-nb) + 3.0*delta_n*(na*other.m2-nb*m2)
+	d.m2 = m2 + other.m2 + term1
+}
+
+// Merge any number of Stats into a new Stats.
+func MergeAll(stats ...Stats) Stats {
+	var d Stats
+	for _, s := range stats {
+		d.Merge(s)
+	}
+	return d
+}
+
 func (d *Stats) PopulationVariance() float64 {
 	if d.n == 0 || d.n == 1 {
 		return math.NaN()
@@ -113,7 +265,7 @@ func (d *Stats) PopulationVariance() float64 {
 }
 
 func (d *Stats) SampleVariance() float64 {
-	if d.n == 0 || d.n == 1 {
+	if d.n <= 1.0 {
 		return math.NaN()
 	}
 	return d.m2 / (d.n - 1.0)
@@ -127,7 +279,7 @@ func (d *Stats) PopulationStandardDeviation() float64 {
 }
 
 func (d *Stats) SampleStandardDeviation() float64 {
-	if d.n == 0 || d.n == 1 {
+	if d.n <= 1.0 {
 		return math.NaN()
 	}
 	return math.Sqrt(d.SampleVariance())
@@ -138,11 +290,7 @@ func (d *Stats) PopulationSkew() float64 {
 }
 
 func (d *Stats) SampleSkew() float64 {
-	if d.n == 2.0 {
-		return math.NaN()
-	}
-	popSkew := d.PopulationSkew()
-	return math.Sqrt(d.n*(d.n-1.0)) / (d.n - 2.0) * popSkew
+	return sampleSkew(d.PopulationSkew(), d.n)
 }
 
 // The kurtosis functions return _excess_ kurtosis, so that the kurtosis of a normal
@@ -153,11 +301,49 @@ func (d *Stats) PopulationKurtosis() float64 {
 }
 
 func (d *Stats) SampleKurtosis() float64 {
-	if d.n == 2.0 || d.n == 3.0 {
+	return sampleKurtosis(d.PopulationKurtosis(), d.n)
+}
+
+// The effective number of values for reliability weights, sum(w)^2/sum(w^2).
+func (d *Stats) effectiveCount() float64 {
+	return d.n * d.n / d.w2
+}
+
+// The reliability-weighted sample functions treat the weights as measures of importance
+// rather than counts. See the notes at the top of this file.
+func (d *Stats) ReliabilitySampleVariance() float64 {
+	if d.n == 0 || d.effectiveCount() <= 1.0 {
 		return math.NaN()
 	}
-	populationKurtosis := d.PopulationKurtosis()
-	return (d.n - 1.0) / ((d.n - 2.0) * (d.n - 3.0)) * ((d.n+1.0)*populationKurtosis + 6.0)
+	return d.m2 / (d.n - d.w2/d.n)
+}
+
+func (d *Stats) ReliabilitySampleStandardDeviation() float64 {
+	return math.Sqrt(d.ReliabilitySampleVariance())
+}
+
+func (d *Stats) ReliabilitySampleSkew() float64 {
+	return sampleSkew(d.PopulationSkew(), d.effectiveCount())
+}
+
+func (d *Stats) ReliabilitySampleKurtosis() float64 {
+	return sampleKurtosis(d.PopulationKurtosis(), d.effectiveCount())
+}
+
+// Adjust the population skew and kurtosis for a sample of n values. With fewer than 3 or
+// 4 values, or a sum of weights that small, they're undefined.
+func sampleSkew(popSkew, n float64) float64 {
+	if n <= 2.0 {
+		return math.NaN()
+	}
+	return math.Sqrt(n*(n-1.0)) / (n - 2.0) * popSkew
+}
+
+func sampleKurtosis(popKurtosis, n float64) float64 {
+	if n <= 3.0 {
+		return math.NaN()
+	}
+	return (n - 1.0) / ((n - 2.0) * (n - 3.0)) * ((n+1.0)*popKurtosis + 6.0)
 }
 
 //
@@ -279,3 +465,104 @@ func StatsSampleKurtosis(data []float64) float64 {
 	n := float64(len(data))
 	return (n - 1.0) / ((n - 2.0) * (n - 3.0)) * ((n+1.0)*populationKurtosis + 6.0)
 }
+
+// The weighted batch functions take the values and their weights. See the notes at the
+// top of this file for the frequency and reliability weighted sample statistics.
+func StatsWeightedSum(data, weights []float64) (sum float64) {
+	checkWeights(data, weights)
+	for i, v := range data {
+		sum += weights[i] * v
+	}
+	return
+}
+
+func StatsWeightedMean(data, weights []float64) float64 {
+	return StatsWeightedSum(data, weights) / StatsSum(weights)
+}
+
+// Weighted sums of the 2nd, 3rd and 4th powers of the deltas from the weighted mean
+func weightedSumDeltas(data, weights []float64) (sum2, sum3, sum4 float64) {
+	mean := StatsWeightedMean(data, weights)
+	for i, v := range data {
+		delta := v - mean
+		delta2 := delta * delta
+		sum2 += weights[i] * delta2
+		sum3 += weights[i] * delta2 * delta
+		sum4 += weights[i] * delta2 * delta2
+	}
+	return
+}
+
+// The effective number of values for reliability weights, sum(w)^2/sum(w^2).
+func effectiveCount(weights []float64) float64 {
+	w := StatsSum(weights)
+	w2 := 0.0
+	for _, v := range weights {
+		w2 += v * v
+	}
+	return w * w / w2
+}
+
+func checkWeights(data, weights []float64) {
+	if len(data) != len(weights) {
+		panic("array lengths differ for data and weights")
+	}
+}
+
+func StatsWeightedPopulationVariance(data, weights []float64) float64 {
+	ssd, _, _ := weightedSumDeltas(data, weights)
+	return ssd / StatsSum(weights)
+}
+
+// Frequency weighted, matching R's Hmisc::wtd.var(x, w)
+func StatsWeightedSampleVariance(data, weights []float64) float64 {
+	ssd, _, _ := weightedSumDeltas(data, weights)
+	return ssd / (StatsSum(weights) - 1.0)
+}
+
+// Reliability weighted, matching R's cov.wt(cbind(x), w)$cov
+func StatsWeightedReliabilitySampleVariance(data, weights []float64) float64 {
+	ne := effectiveCount(weights)
+	return StatsWeightedPopulationVariance(data, weights) * ne / (ne - 1.0)
+}
+
+func StatsWeightedPopulationStandardDeviation(data, weights []float64) float64 {
+	return math.Sqrt(StatsWeightedPopulationVariance(data, weights))
+}
+
+func StatsWeightedSampleStandardDeviation(data, weights []float64) float64 {
+	return math.Sqrt(StatsWeightedSampleVariance(data, weights))
+}
+
+func StatsWeightedReliabilitySampleStandardDeviation(data, weights []float64) float64 {
+	return math.Sqrt(StatsWeightedReliabilitySampleVariance(data, weights))
+}
+
+func StatsWeightedPopulationSkew(data, weights []float64) float64 {
+	sum2, sum3, _ := weightedSumDeltas(data, weights)
+	w := StatsSum(weights)
+	return math.Sqrt(w/(sum2*sum2*sum2)) * sum3
+}
+
+func StatsWeightedSampleSkew(data, weights []float64) float64 {
+	return sampleSkew(StatsWeightedPopulationSkew(data, weights), StatsSum(weights))
+}
+
+func StatsWeightedReliabilitySampleSkew(data, weights []float64) float64 {
+	return sampleSkew(StatsWeightedPopulationSkew(data, weights), effectiveCount(weights))
+}
+
+// The kurtosis functions return _excess_ kurtosis
+func StatsWeightedPopulationKurtosis(data, weights []float64) float64 {
+	sum2, _, sum4 := weightedSumDeltas(data, weights)
+	w := StatsSum(weights)
+	return w*sum4/(sum2*sum2) - 3.0
+}
+
+func StatsWeightedSampleKurtosis(data, weights []float64) float64 {
+	return sampleKurtosis(StatsWeightedPopulationKurtosis(data, weights), StatsSum(weights))
+}
+
+func StatsWeightedReliabilitySampleKurtosis(data, weights []float64) float64 {
+	return sampleKurtosis(StatsWeightedPopulationKurtosis(data, weights), effectiveCount(weights))
+}
@@ -238,6 +238,266 @@ func TestUpdateArray10(t *testing.T) {
 	checkFloat64(d.SampleKurtosis(), 3.179835417592894, TOL, "SampleKurtosis", t)
 }
 
+// Merge every split of the array into two halves. Each merged result should match the
+// stats accumulated over the whole array.
+func TestMerge10(t *testing.T) {
+	a := []float64{1.0, -2.0, 13.0, 47.0, 115.0, -0.03, -123.4, 23.0, -23.04, 12.3}
+	var whole Stats
+	whole.UpdateArray(a)
+	for i := 0; i <= len(a); i++ {
+		var d, e Stats
+		d.UpdateArray(a[:i])
+		e.UpdateArray(a[i:])
+		d.Merge(e)
+		checkSameStats(&d, &whole, TOL, "Merge", t)
+	}
+}
+
+// Merge every partition of the array into two sets, including non-contiguous ones.
+func TestMergePartitions10(t *testing.T) {
+	a := []float64{1.0, -2.0, 13.0, 47.0, 115.0, -0.03, -123.4, 23.0, -23.04, 12.3}
+	var whole Stats
+	whole.UpdateArray(a)
+	for mask := 0; mask < 1<<uint(len(a)); mask++ {
+		var d, e Stats
+		for i, v := range a {
+			if mask&(1<<uint(i)) != 0 {
+				d.Update(v)
+			} else {
+				e.Update(v)
+			}
+		}
+		e.Merge(d)
+		checkSameStats(&e, &whole, TOL, "MergePartitions", t)
+	}
+}
+
+func TestMergeAll(t *testing.T) {
+	a := []float64{1.0, -2.0, 13.0, 47.0, 115.0, -0.03, -123.4, 23.0, -23.04, 12.3}
+	var whole, d1, d2, d3, empty Stats
+	whole.UpdateArray(a)
+	d1.UpdateArray(a[:2])
+	d2.UpdateArray(a[2:3])
+	d3.UpdateArray(a[3:])
+	d := MergeAll(d1, empty, d2, d3)
+	checkSameStats(&d, &whole, TOL, "MergeAll", t)
+
+	d = MergeAll()
+	checkInt(d.Count(), 0, "Count", t)
+	checkNaN(d.PopulationVariance(), "PopulationVariance", t)
+}
+
+// Update() then Remove() a value between the min and max. The stats return to those of
+// the original values, and the min and max remain valid.
+func TestRemove(t *testing.T) {
+	a := []float64{1.0, -2.0, 13.0, 47.0, 115.0, -0.03, -123.4, 23.0, -23.04, 12.3}
+	var d, whole Stats
+	whole.UpdateArray(a)
+	d.UpdateArray(a[:4])
+	d.Update(3.7)
+	d.UpdateArray(a[4:])
+	d.Update(-50.0)
+	d.Remove(3.7)
+	d.Remove(-50.0)
+	checkSameStats(&d, &whole, 1e-13, "Remove", t)
+}
+
+// Removing the min or max makes that one unknown, but the moments are still exact, apart
+// from the rounding error of removing an outlier.
+func TestRemoveMinMax(t *testing.T) {
+	a := []float64{1.0, -2.0, 13.0, 47.0, 115.0, -0.03, -123.4, 23.0, -23.04, 12.3}
+	var d Stats
+	d.UpdateArray(a)
+	d.Update(500.0)
+	d.Remove(500.0)
+	checkFloat64(d.Min(), -123.4, TOL, "Min", t)
+	checkNaN(d.Max(), "Max", t)
+	checkInt(d.Count(), 10, "Count", t)
+	checkFloat64(d.Sum(), 62.83, 1e-12, "Sum", t)
+	checkFloat64(d.Mean(), 6.283, 1e-12, "Mean", t)
+	checkFloat64(d.PopulationVariance(), 3165.19316100, 1e-12, "PopulationVariance", t)
+	checkFloat64(d.SampleVariance(), 3516.88129, 1e-12, "SampleVariance", t)
+	checkFloat64(d.PopulationSkew(), -0.4770396201629045, 1e-12, "PopulationSkew", t)
+	checkFloat64(d.SampleSkew(), -0.565699400196136, 1e-12, "SampleSkew", t)
+	checkFloat64(d.PopulationKurtosis(), 1.253240236214162, 1e-12, "PopulationKurtosis", t)
+	checkFloat64(d.SampleKurtosis(), 3.179835417592894, 1e-12, "SampleKurtosis", t)
+
+	// merging carries the lost min and max along
+	var e Stats
+	e.Update(1.0)
+	e.Merge(d)
+	checkFloat64(e.Min(), -123.4, TOL, "Min merged", t)
+	checkNaN(e.Max(), "Max merged", t)
+	e.Remove(-123.4)
+	checkNaN(e.Min(), "Min merged", t)
+	checkNaN(e.Max(), "Max merged", t)
+}
+
+// Remove the second half of the array, in reverse order. The result should match the
+// stats of the first half.
+func TestRemoveHalf(t *testing.T) {
+	a := []float64{1.0, -2.0, 13.0, 47.0, 115.0, -0.03, -123.4, 23.0, -23.04, 12.3}
+	var d Stats
+	d.UpdateArray(a)
+	for i := len(a) - 1; i >= 5; i-- {
+		d.Remove(a[i])
+	}
+	checkInt(d.Count(), 5, "Count", t)
+	checkNaN(d.Min(), "Min", t) // -123.4 was removed
+	checkFloat64(d.Sum(), 174, 1e-13, "Sum", t)
+	checkFloat64(d.Mean(), 34.8, 1e-13, "Mean", t)
+	checkFloat64(d.PopulationVariance(), 1910.56, 1e-13, "PopulationVariance", t)
+	checkFloat64(d.SampleVariance(), 2388.2, 1e-13, "SampleVariance", t)
+	checkFloat64(d.PopulationSkew(), 1.003118841855798, 1e-13, "PopulationSkew", t)
+	checkFloat64(d.SampleSkew(), 1.495361279933617, 1e-13, "SampleSkew", t)
+	checkFloat64(d.PopulationKurtosis(), -0.5476524250400354, 1e-13, "PopulationKurtosis", t)
+	checkFloat64(d.SampleKurtosis(), 1.809390299839858, 1e-13, "SampleKurtosis", t)
+}
+
+// Removing every value resets the Stats, so the min and max are valid again.
+func TestRemoveAll(t *testing.T) {
+	var d Stats
+	d.UpdateArray([]float64{2.3, 0.4, -3.4})
+	d.Remove(-3.4)
+	d.Remove(2.3)
+	d.Remove(0.4)
+	checkInt(d.Count(), 0, "Count", t)
+	checkFloat64(d.Sum(), 0.0, TOL, "Sum", t)
+	checkFloat64(d.Mean(), 0.0, TOL, "Mean", t)
+	checkNaN(d.PopulationVariance(), "PopulationVariance", t)
+	d.Update(2.3)
+	d.Update(0.4)
+	checkFloat64(d.Min(), 0.4, TOL, "Min", t)
+	checkFloat64(d.Max(), 2.3, TOL, "Max", t)
+	checkFloat64(d.Mean(), 1.35, TOL, "Mean", t)
+	checkFloat64(d.PopulationVariance(), 0.9025, TOL, "PopulationVariance", t)
+
+}
+
+// A value added with Update() can be removed from weighted stats. The weighted values
+// remain, even when their weights sum to less than 1.
+func TestRemoveWeighted(t *testing.T) {
+	a := []float64{1.0, -2.0, 13.0, 47.0, 115.0, -0.03, -123.4, 23.0, -23.04, 12.3}
+	w := []float64{0.25, 1.5, 3.0, 0.5, 1.0, 2.0, 0.75, 0.1, 1.0, 4.0}
+	var d, whole Stats
+	whole.UpdateWeightedArray(a, w)
+	d.UpdateWeightedArray(a[:5], w[:5])
+	d.Update(3.7)
+	d.UpdateWeightedArray(a[5:], w[5:])
+	d.Remove(3.7)
+	checkSameStats(&d, &whole, 1e-13, "RemoveWeighted", t)
+	checkFloat64(d.ReliabilitySampleVariance(), whole.ReliabilitySampleVariance(), 1e-13,
+		"ReliabilitySampleVariance", t)
+
+	var s Stats
+	s.UpdateWeighted(2.3, 0.25)
+	s.Update(0.4)
+	s.Remove(0.4)
+	checkInt(s.Count(), 0, "Count weighted", t)
+	checkFloat64(s.SumOfWeights(), 0.25, TOL, "SumOfWeights weighted", t)
+	checkFloat64(s.Mean(), 2.3, TOL, "Mean weighted", t)
+	checkNaN(s.Min(), "Min weighted", t) // 0.4 was the min
+	checkFloat64(s.Max(), 2.3, TOL, "Max weighted", t)
+}
+
+// Integer weights are frequency weights: the stats should match updating each value as
+// many times as its weight.
+func TestUpdateWeightedFrequency(t *testing.T) {
+	a := []float64{1.0, -2.0, 13.0, 47.0, 115.0, -0.03, -123.4, 23.0, -23.04, 12.3}
+	w := []float64{2.0, 1.0, 3.0, 1.0, 1.0, 2.0, 1.0, 0.0, 1.0, 4.0}
+	var d, expanded Stats
+	d.UpdateWeightedArray(a, w)
+	for i, v := range a {
+		for j := 0; j < int(w[i]); j++ {
+			expanded.Update(v)
+		}
+	}
+	checkSameStats(&d, &expanded, 1e-13, "UpdateWeighted", t)
+	checkFloat64(d.ReliabilitySampleVariance(), 2322.0886440366973548, 1e-13, "ReliabilitySampleVariance", t)
+
+	// unit weights are the same as Update(), for both kinds of sample statistics
+	var u Stats
+	for _, v := range a {
+		u.UpdateWeighted(v, 1.0)
+	}
+	checkFloat64(u.ReliabilitySampleVariance(), 3516.88129, 1e-13, "ReliabilitySampleVariance", t)
+	checkFloat64(u.ReliabilitySampleSkew(), -0.565699400196136, 1e-13, "ReliabilitySampleSkew", t)
+	checkFloat64(u.ReliabilitySampleKurtosis(), 3.179835417592894, 1e-13, "ReliabilitySampleKurtosis", t)
+}
+
+// Non-integer weights. The expected values can be checked in R with:
+//   a=c(1,-2,13,47,115,-0.03,-123.4,23,-23.04,12.3)
+//   w=c(0.5,1.2,2,0.3,0.9,1.5,0.25,3,0.8,1.1)
+//   m=weighted.mean(a,w); Hmisc::wtd.var(a,w); cov.wt(cbind(a),w)$cov
+//   W=sum(w); ne=W^2/sum(w^2); v=sum(w*(a-m)^2)/W
+//   sk=sum(w*(a-m)^3)/W/v^1.5; ku=sum(w*(a-m)^4)/W/v^2-3
+//   sqrt(W*(W-1))/(W-2)*sk; sqrt(ne*(ne-1))/(ne-2)*sk
+//   (W-1)/(W-2)/(W-3)*((W+1)*ku+6); (ne-1)/(ne-2)/(ne-3)*((ne+1)*ku+6)
+func TestUpdateWeighted(t *testing.T) {
+	a := []float64{1.0, -2.0, 13.0, 47.0, 115.0, -0.03, -123.4, 23.0, -23.04, 12.3}
+	w := []float64{0.5, 1.2, 2.0, 0.3, 0.9, 1.5, 0.25, 3.0, 0.8, 1.1}
+	var d Stats
+	for i, v := range a {
+		d.UpdateWeighted(v, w[i])
+	}
+	checkWeightedStats(&d, 1e-13, "UpdateWeighted", t)
+
+	// merge weighted halves
+	var d1, d2 Stats
+	d1.UpdateWeightedArray(a[:4], w[:4])
+	d2.UpdateWeightedArray(a[4:], w[4:])
+	d1.Merge(d2)
+	checkWeightedStats(&d1, 1e-13, "Merge", t)
+}
+
+func checkWeightedStats(d *Stats, tol float64, test string, t *testing.T) {
+	// the sum of the weights, 11.55, truncated
+	checkInt(d.Count(), 11, test+" Count", t)
+	checkFloat64(d.SumOfWeights(), 11.55, tol, test+" SumOfWeights", t)
+	checkFloat64(d.Min(), -123.4, tol, test+" Min", t)
+	checkFloat64(d.Max(), 115.0, tol, test+" Max", t)
+	checkFloat64(d.Sum(), 174.903, tol, test+" Sum", t)
+	checkFloat64(d.Mean(), 15.143116883116883179, tol, test+" Mean", t)
+	checkFloat64(d.PopulationVariance(), 1406.4854595491089395, tol, test+" PopulationVariance", t)
+	checkFloat64(d.SampleVariance(), 1539.8016168523420131, tol, test+" SampleVariance", t)
+	checkFloat64(d.ReliabilitySampleVariance(), 1650.9342412450506384, tol, test+" ReliabilitySampleVariance", t)
+	checkFloat64(d.PopulationStandardDeviation(), 37.503139329249610694, tol, test+" PopulationStandardDeviation", t)
+	checkFloat64(d.SampleStandardDeviation(), 39.240306023938473014, tol, test+" SampleStandardDeviation", t)
+	checkFloat64(d.ReliabilitySampleStandardDeviation(), 40.631690110615022735, tol,
+		test+" ReliabilitySampleStandardDeviation", t)
+	checkFloat64(d.PopulationSkew(), 0.30400300015927634673, tol, test+" PopulationSkew", t)
+	checkFloat64(d.SampleSkew(), 0.35139187871338580493, tol, test+" SampleSkew", t)
+	checkFloat64(d.ReliabilitySampleSkew(), 0.39864887647809729958, tol, test+" ReliabilitySampleSkew", t)
+	checkFloat64(d.PopulationKurtosis(), 5.0450161756565910516, tol, test+" PopulationKurtosis", t)
+	checkFloat64(d.SampleKurtosis(), 8.9559138323673101590, tol, test+" SampleKurtosis", t)
+	checkFloat64(d.ReliabilitySampleKurtosis(), 14.547927976890659112, tol, test+" ReliabilitySampleKurtosis", t)
+}
+
+// With a sum of weights of at most 1, 2 or 3, the sample variance, skew or kurtosis is
+// undefined, even if it's fractional.
+func TestUpdateWeightedSmall(t *testing.T) {
+	var d Stats
+	d.UpdateWeighted(1.0, 0.5)
+	d.UpdateWeighted(4.0, 0.25)
+	checkFloat64(d.SumOfWeights(), 0.75, TOL, "SumOfWeights", t)
+	checkInt(d.Count(), 0, "Count", t)
+	checkNaN(d.SampleVariance(), "SampleVariance 0.75", t)
+	checkNaN(d.SampleStandardDeviation(), "SampleStandardDeviation 0.75", t)
+	d.UpdateWeighted(2.0, 0.75)
+	d.UpdateWeighted(-3.0, 0.5)
+	checkFloat64(d.SampleVariance(), d.PopulationVariance()*2.0, TOL, "SampleVariance 2", t)
+	checkNaN(d.SampleSkew(), "SampleSkew 2", t)
+	d.UpdateWeighted(7.0, 0.5)
+	checkFloat64(d.SumOfWeights(), 2.5, TOL, "SumOfWeights 2.5", t)
+	checkFloat64(d.SampleSkew(), math.Sqrt(2.5*1.5)/0.5*d.PopulationSkew(), TOL, "SampleSkew 2.5", t)
+	checkNaN(d.SampleKurtosis(), "SampleKurtosis 2.5", t)
+	d.UpdateWeighted(5.0, 0.5)
+	checkNaN(d.SampleKurtosis(), "SampleKurtosis 3", t)
+	d.UpdateWeighted(-1.0, 0.25)
+	checkFloat64(d.SampleKurtosis(), 2.25/(1.25*0.25)*(4.25*d.PopulationKurtosis()+6.0), TOL,
+		"SampleKurtosis 3.25", t)
+}
+
 // Test the batch functions. Calculate the descriptive stats on the whole array.
 func TestArrayStats(t *testing.T) {
 	a := []float64{1.0, 2.0, 3.0, 4.0, 5.0}
@@ -273,6 +533,32 @@ func TestArrayStats2(t *testing.T) {
 	checkFloat64(StatsSampleKurtosis(a), 3.179835417592894, TOL, "SampleKurtosis", t)
 }
 
+// The weighted batch functions, on the same values and weights as TestUpdateWeighted.
+func TestArrayWeightedStats(t *testing.T) {
+	a := []float64{1.0, -2.0, 13.0, 47.0, 115.0, -0.03, -123.4, 23.0, -23.04, 12.3}
+	w := []float64{0.5, 1.2, 2.0, 0.3, 0.9, 1.5, 0.25, 3.0, 0.8, 1.1}
+	checkFloat64(StatsWeightedSum(a, w), 174.903, TOL, "WeightedSum", t)
+	checkFloat64(StatsWeightedMean(a, w), 15.143116883116883179, TOL, "WeightedMean", t)
+	checkFloat64(StatsWeightedPopulationVariance(a, w), 1406.4854595491089395, TOL, "WeightedPopulationVariance", t)
+	checkFloat64(StatsWeightedSampleVariance(a, w), 1539.8016168523420131, TOL, "WeightedSampleVariance", t)
+	checkFloat64(StatsWeightedReliabilitySampleVariance(a, w), 1650.9342412450506384, TOL,
+		"WeightedReliabilitySampleVariance", t)
+	checkFloat64(StatsWeightedPopulationStandardDeviation(a, w), 37.503139329249610694, TOL,
+		"WeightedPopulationStandardDeviation", t)
+	checkFloat64(StatsWeightedSampleStandardDeviation(a, w), 39.240306023938473014, TOL,
+		"WeightedSampleStandardDeviation", t)
+	checkFloat64(StatsWeightedReliabilitySampleStandardDeviation(a, w), 40.631690110615022735, TOL,
+		"WeightedReliabilitySampleStandardDeviation", t)
+	checkFloat64(StatsWeightedPopulationSkew(a, w), 0.30400300015927634673, 1e-13, "WeightedPopulationSkew", t)
+	checkFloat64(StatsWeightedSampleSkew(a, w), 0.35139187871338580493, 1e-13, "WeightedSampleSkew", t)
+	checkFloat64(StatsWeightedReliabilitySampleSkew(a, w), 0.39864887647809729958, 1e-13,
+		"WeightedReliabilitySampleSkew", t)
+	checkFloat64(StatsWeightedPopulationKurtosis(a, w), 5.0450161756565910516, 1e-13, "WeightedPopulationKurtosis", t)
+	checkFloat64(StatsWeightedSampleKurtosis(a, w), 8.9559138323673101590, 1e-13, "WeightedSampleKurtosis", t)
+	checkFloat64(StatsWeightedReliabilitySampleKurtosis(a, w), 14.547927976890659112, 1e-13,
+		"WeightedReliabilitySampleKurtosis", t)
+}
+
 //
 //
 // Benchmark tests
@@ -504,11 +790,26 @@ func checkInt(x, y int, test string, t *testing.T) {
 }
 
 func checkFloat64(x, y, tol float64, test string, t *testing.T) {
-	if math.Abs(x-y) > math.Abs(x*tol) {
+	if math.IsNaN(x) || math.IsInf(x, 0) || math.Abs(x-y) > math.Abs(x*tol) {
 		t.Errorf("Found %v, but expected %v for test %v", x, y, test)
 	}
 }
 
+// check that two Stats report the same descriptive statistics
+func checkSameStats(x, y *Stats, tol float64, test string, t *testing.T) {
+	checkInt(x.Count(), y.Count(), test+" Count", t)
+	checkFloat64(x.Min(), y.Min(), tol, test+" Min", t)
+	checkFloat64(x.Max(), y.Max(), tol, test+" Max", t)
+	checkFloat64(x.Sum(), y.Sum(), tol, test+" Sum", t)
+	checkFloat64(x.Mean(), y.Mean(), tol, test+" Mean", t)
+	checkFloat64(x.PopulationVariance(), y.PopulationVariance(), tol, test+" PopulationVariance", t)
+	checkFloat64(x.SampleVariance(), y.SampleVariance(), tol, test+" SampleVariance", t)
+	checkFloat64(x.PopulationSkew(), y.PopulationSkew(), tol, test+" PopulationSkew", t)
+	checkFloat64(x.SampleSkew(), y.SampleSkew(), tol, test+" SampleSkew", t)
+	checkFloat64(x.PopulationKurtosis(), y.PopulationKurtosis(), tol, test+" PopulationKurtosis", t)
+	checkFloat64(x.SampleKurtosis(), y.SampleKurtosis(), tol, test+" SampleKurtosis", t)
+}
+
 func checkNaN(x float64, test string, t *testing.T) {
 	if !math.IsNaN(x) {
 		t.Errorf("Found %v, but expected NaN for test %v", x, test)
@@ -521,6 +822,16 @@ func checkFloat64Abs(x, y, tol float64, test string, t *testing.T) {
 	}
 }
 
+func checkFloat64Array(x, y []float64, test string, t *testing.T) {
+	if len(x) != len(y) {
+		t.Errorf("Found %v, but expected %v for test %v", x, y, test)
+		return
+	}
+	for i := range x {
+		checkFloat64(x[i], y[i], TOL, test, t)
+	}
+}
+
 func checkInf(x float64, test string, t *testing.T) {
 	if !math.IsInf(x, 1) {
 		t.Errorf("Found %v, but expected Inf for test %v", x, test)
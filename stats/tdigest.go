@@ -0,0 +1,358 @@
+package stats
+
+//
+// tdigest.go
+//
+// Author:   Gary Boone
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// Changes:
+//           20261017   initial version
+//
+// Source:
+// https://github.com/GaryBoone/GoStats
+//
+// TDigest is a mergeable sketch of a distribution that gives accurate quantiles, especially
+// in the tails, in bounded memory. See:
+// T. Dunning and O. Ertl, Computing extremely accurate quantiles using t-digests, 2019.
+// https://arxiv.org/abs/1902.04023
+//
+// The digest summarizes the values as centroids, each a mean and a weight, sorted by mean.
+// New values are buffered and then merged into the centroids in one sorted pass. A
+// centroid may absorb its neighbor only while it spans at most 1 on the scale
+//   k(q) = compression / Z * log(q / (1 - q)),  Z = 4 log(n / compression) + 24
+// where q is the fraction of the total weight n to its left. This is the paper's k2
+// scale. It's steep near q = 0 and q = 1, so a centroid's weight is at most about
+// proportional to q(1 - q): the centroids in the tails stay small, and the extreme ones
+// are single values, while those near the median are large. So the quantiles have
+// roughly constant relative error in q and 1 - q, which is what's needed for p99.9. The
+// number of centroids grows only with the log of n, and stays below about the
+// compression. The price is large centroids near the median, where the rank error can be
+// a few tenths of a percent. Larger compressions give more accuracy for more memory; 100
+// is typical.
+//
+// Quantiles and the CDF interpolate linearly between the centroid means, placing each
+// centroid at the middle of its weight, with the exact min and max at the ends. With few
+// values, every centroid is a single value, and the quantiles are those of R's
+// quantile(type = 5).
+//
+// Digests merge by combining their centroids, so digests of shards or of time intervals
+// can be summarized together. Merging is not quite exact, but its error is like that of
+// adding the values directly.
+//
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+)
+
+// a centroid of the digest
+type centroid struct {
+	mean, weight float64
+}
+
+// structure to contain the centroids and the buffer of values not yet merged into them
+type TDigest struct {
+	compression float64
+	centroids   []centroid // sorted by mean
+	buffer      []centroid // unsorted values not yet merged into the centroids
+	w           float64    // the total weight of the centroids and buffer
+	min, max    float64
+}
+
+// Create a TDigest with the given compression, which must be at least 10. A compression
+// of 100 is typical.
+func NewTDigest(compression float64) *TDigest {
+	if !(compression >= 10.0) {
+		panic("compression must be at least 10 in NewTDigest()")
+	}
+	return &TDigest{compression: compression}
+}
+
+//
+//
+// Accessor Functions
+//
+//
+
+// The total weight of the values, which for unweighted values is their number.
+func (t *TDigest) Count() int {
+	return int(t.w)
+}
+
+func (t *TDigest) Size() int {
+	return int(t.w)
+}
+
+func (t *TDigest) Compression() float64 {
+	return t.compression
+}
+
+// The min of the values. It's exact.
+func (t *TDigest) Min() float64 {
+	if t.w == 0 {
+		return math.NaN()
+	}
+	return t.min
+}
+
+// The max of the values. It's exact.
+func (t *TDigest) Max() float64 {
+	if t.w == 0 {
+		return math.NaN()
+	}
+	return t.max
+}
+
+// The number of centroids summarizing the values.
+func (t *TDigest) Centroids() int {
+	t.compress()
+	return len(t.centroids)
+}
+
+//
+//
+// Incremental Functions
+//
+//
+
+// Update the digest with a new value.
+func (t *TDigest) Update(x float64) {
+	t.UpdateWeighted(x, 1.0)
+}
+
+// Update the digest with the given array of values.
+func (t *TDigest) UpdateArray(data []float64) {
+	for _, v := range data {
+		t.Update(v)
+	}
+}
+
+// Update the digest with a value having weight w. A value with weight 2.0 is the same as
+// two Update()s of it. Values with zero weight are ignored. Negative weights panic.
+func (t *TDigest) UpdateWeighted(x, w float64) {
+	if w < 0.0 {
+		panic("negative weight in UpdateWeighted()")
+	}
+	if w == 0.0 {
+		return
+	}
+	t.add(centroid{x, w}, x, x)
+}
+
+// Merge the values summarized by another TDigest into this one. The compression of this
+// digest is kept.
+func (t *TDigest) Merge(other *TDigest) {
+	if other.w == 0 {
+		return
+	}
+	// copy first, in case other is t
+	cs := append(append([]centroid(nil), other.centroids...), other.buffer...)
+	min, max := other.min, other.max
+	for _, c := range cs {
+		t.add(c, min, max)
+	}
+}
+
+// Buffer the centroid c, drawn from values in [min, max], compressing when the buffer is
+// full.
+func (t *TDigest) add(c centroid, min, max float64) {
+	if t.w == 0 || min < t.min {
+		t.min = min
+	}
+	if t.w == 0 || max > t.max {
+		t.max = max
+	}
+	t.w += c.weight
+	t.buffer = append(t.buffer, c)
+	if len(t.buffer) >= int(5.0*t.compression) {
+		t.compress()
+	}
+}
+
+// The scale function k(q), which is infinite at q = 0 and q = 1.
+func (t *TDigest) scale(q, z float64) float64 {
+	return t.compression / z * math.Log(q/(1.0-q))
+}
+
+// Merge the buffer into the centroids in one sorted pass.
+func (t *TDigest) compress() {
+	if len(t.buffer) == 0 {
+		return
+	}
+	all := append(t.centroids, t.buffer...)
+	sort.SliceStable(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+
+	z := 4.0*math.Log(math.Max(t.w/t.compression, 1.0)) + 24.0
+	merged := make([]centroid, 0, int(t.compression))
+	cur := all[0]
+	wSoFar := 0.0
+	kLeft := t.scale(0.0, z)
+	for _, next := range all[1:] {
+		q := (wSoFar + cur.weight + next.weight) / t.w
+		if t.scale(math.Min(q, 1.0), z)-kLeft <= 1.0 {
+			cur.weight += next.weight
+			cur.mean += (next.mean - cur.mean) * next.weight / cur.weight
+		} else {
+			merged = append(merged, cur)
+			wSoFar += cur.weight
+			kLeft = t.scale(wSoFar/t.w, z)
+			cur = next
+		}
+	}
+	merged = append(merged, cur)
+
+	t.centroids = merged
+	t.buffer = t.buffer[:0]
+}
+
+// The knots of the piecewise linear CDF: each centroid's mean at the middle of its
+// weight, with the min and max at the ends.
+func (t *TDigest) knots() (pos, val []float64) {
+	t.compress()
+	pos = make([]float64, 0, len(t.centroids)+2)
+	val = make([]float64, 0, len(t.centroids)+2)
+	pos = append(pos, 0.0)
+	val = append(val, t.min)
+	wSoFar := 0.0
+	for _, c := range t.centroids {
+		pos = append(pos, wSoFar+c.weight/2.0)
+		val = append(val, math.Max(t.min, math.Min(t.max, c.mean)))
+		wSoFar += c.weight
+	}
+	pos = append(pos, t.w)
+	val = append(val, t.max)
+	return
+}
+
+// The estimate of the quantile at probability q, 0 <= q <= 1. With no values, it's NaN.
+func (t *TDigest) Quantile(q float64) float64 {
+	if !(q >= 0.0 && q <= 1.0) {
+		panic("quantile must be in [0, 1] in Quantile()")
+	}
+	if t.w == 0 {
+		return math.NaN()
+	}
+	pos, val := t.knots()
+	index := q * t.w
+	i := sort.SearchFloat64s(pos, index)
+	if i == 0 {
+		return val[0]
+	}
+	if i == len(pos) {
+		return val[len(val)-1]
+	}
+	if pos[i] == pos[i-1] {
+		return val[i]
+	}
+	return val[i-1] + (index-pos[i-1])/(pos[i]-pos[i-1])*(val[i]-val[i-1])
+}
+
+// The estimate of the fraction of the weight at or below x, the inverse of Quantile().
+// Where centroids equal x, as when the values are all the same, it's the middle of their
+// weight. With no values, it's NaN.
+func (t *TDigest) CDF(x float64) float64 {
+	if t.w == 0 {
+		return math.NaN()
+	}
+	if x < t.min {
+		return 0.0
+	}
+	if x > t.max {
+		return 1.0
+	}
+	t.compress()
+
+	// the weight of the centroids whose means equal x, from the start of the first to the
+	// end of the last
+	wSoFar, start, end := 0.0, -1.0, 0.0
+	for _, c := range t.centroids {
+		if c.mean == x {
+			if start < 0.0 {
+				start = wSoFar
+			}
+			end = wSoFar + c.weight
+		}
+		wSoFar += c.weight
+	}
+	if start >= 0.0 {
+		return (start + end) / 2.0 / t.w
+	}
+	if x == t.max {
+		return 1.0
+	}
+	pos, val := t.knots()
+	i := sort.Search(len(val), func(i int) bool { return val[i] > x }) // first knot > x
+	f := (x - val[i-1]) / (val[i] - val[i-1])
+	return (pos[i-1] + f*(pos[i]-pos[i-1])) / t.w
+}
+
+//
+//
+// Serialization
+//
+//
+
+// the version of the binary encoding
+const tdigestEncodingVersion = 1
+
+// Encode the digest. The encoding is a version byte, then the compression, min, max and
+// number of centroids, then the mean and weight of each centroid, all big-endian, with
+// the floats in IEEE 754 binary64 and the number of centroids as a uint32. It won't change
+// for a given version, so digests may be stored and exchanged.
+func (t *TDigest) MarshalBinary() ([]byte, error) {
+	t.compress()
+	buf := make([]byte, 1+8*3+4+16*len(t.centroids))
+	buf[0] = tdigestEncodingVersion
+	binary.BigEndian.PutUint64(buf[1:], math.Float64bits(t.compression))
+	binary.BigEndian.PutUint64(buf[9:], math.Float64bits(t.min))
+	binary.BigEndian.PutUint64(buf[17:], math.Float64bits(t.max))
+	binary.BigEndian.PutUint32(buf[25:], uint32(len(t.centroids)))
+	b := buf[29:]
+	for _, c := range t.centroids {
+		binary.BigEndian.PutUint64(b, math.Float64bits(c.mean))
+		binary.BigEndian.PutUint64(b[8:], math.Float64bits(c.weight))
+		b = b[16:]
+	}
+	return buf, nil
+}
+
+// Decode a digest encoded by MarshalBinary(), replacing the contents of this one.
+func (t *TDigest) UnmarshalBinary(data []byte) error {
+	if len(data) < 29 {
+		return errors.New("tdigest: encoding too short")
+	}
+	if data[0] != tdigestEncodingVersion {
+		return errors.New("tdigest: unknown encoding version")
+	}
+	compression := math.Float64frombits(binary.BigEndian.Uint64(data[1:]))
+	min := math.Float64frombits(binary.BigEndian.Uint64(data[9:]))
+	max := math.Float64frombits(binary.BigEndian.Uint64(data[17:]))
+	n := binary.BigEndian.Uint32(data[25:])
+	if uint64(len(data)) != 29+16*uint64(n) {
+		return errors.New("tdigest: encoding has the wrong length")
+	}
+	if !(compression >= 10.0) {
+		return errors.New("tdigest: invalid compression")
+	}
+	centroids := make([]centroid, n)
+	w := 0.0
+	b := data[29:]
+	for i := range centroids {
+		c := centroid{
+			mean:   math.Float64frombits(binary.BigEndian.Uint64(b)),
+			weight: math.Float64frombits(binary.BigEndian.Uint64(b[8:])),
+		}
+		if !(c.weight > 0.0) || (i > 0 && c.mean < centroids[i-1].mean) {
+			return errors.New("tdigest: invalid centroid")
+		}
+		centroids[i] = c
+		w += c.weight
+		b = b[16:]
+	}
+	*t = TDigest{compression: compression, centroids: centroids, w: w, min: min, max: max}
+	return nil
+}
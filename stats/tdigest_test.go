@@ -0,0 +1,227 @@
+package stats
+
+//
+// tdigest_test.go
+//
+// Author:   Gary Boone
+//
+// Test:
+//   go test stats.go stats_test.go tdigest.go tdigest_test.go
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// With few values, the quantiles are compared against R's quantile(x, p, type = 5). With
+// many, the estimates are checked by rank, as for P2Quantile: the fraction of the values
+// below the estimate should be close to p, and in the tails, close relative to 1 - p.
+//
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// With no updates, these are the results on initialization
+func TestTDigest0(t *testing.T) {
+	d := NewTDigest(100)
+	checkInt(d.Count(), 0, "Count", t)
+	checkFloat64(d.Compression(), 100.0, TOL, "Compression", t)
+	checkNaN(d.Quantile(0.5), "Quantile", t)
+	checkNaN(d.CDF(0.0), "CDF", t)
+	checkNaN(d.Min(), "Min", t)
+	checkNaN(d.Max(), "Max", t)
+}
+
+func TestTDigest1(t *testing.T) {
+	d := NewTDigest(100)
+	d.Update(2.3)
+	checkInt(d.Count(), 1, "Count", t)
+	checkFloat64(d.Quantile(0.0), 2.3, TOL, "Quantile 0", t)
+	checkFloat64(d.Quantile(0.5), 2.3, TOL, "Quantile 0.5", t)
+	checkFloat64(d.Quantile(1.0), 2.3, TOL, "Quantile 1", t)
+	checkFloat64Abs(d.CDF(2.2), 0.0, TOL, "CDF below", t)
+	checkFloat64(d.CDF(2.3), 0.5, TOL, "CDF at", t)
+	checkFloat64(d.CDF(2.4), 1.0, TOL, "CDF above", t)
+}
+
+// With few values, each is its own centroid.
+func TestTDigest10(t *testing.T) {
+	d := NewTDigest(100)
+	for i := 10; i >= 1; i-- {
+		d.Update(float64(i))
+	}
+	checkInt(d.Count(), 10, "Count", t)
+	checkInt(d.Centroids(), 10, "Centroids", t)
+	// R: quantile(1:10, c(0.1, 0.25, 0.5, 0.9), type = 5)
+	checkFloat64(d.Quantile(0.1), 1.5, TOL, "Quantile 0.1", t)
+	checkFloat64(d.Quantile(0.25), 3.0, TOL, "Quantile 0.25", t)
+	checkFloat64(d.Quantile(0.5), 5.5, TOL, "Quantile 0.5", t)
+	checkFloat64(d.Quantile(0.9), 9.5, TOL, "Quantile 0.9", t)
+	checkFloat64(d.Quantile(0.0), 1.0, TOL, "Quantile 0", t)
+	checkFloat64(d.Quantile(1.0), 10.0, TOL, "Quantile 1", t)
+	// the CDF is the inverse of the quantiles
+	checkFloat64(d.CDF(3.0), 0.25, TOL, "CDF 3", t)
+	checkFloat64(d.CDF(3.5), 0.3, TOL, "CDF 3.5", t)
+	checkFloat64(d.CDF(10.0), 0.95, TOL, "CDF 10", t)
+}
+
+func TestTDigestNormal(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	data := make([]float64, 100000)
+	for i := range data {
+		data[i] = rnd.NormFloat64()*3.0 + 100.0
+	}
+	d := NewTDigest(100)
+	d.UpdateArray(data)
+	checkTDigestAccuracy(d, data, "Normal", t)
+}
+
+// A skewed, heavy-tailed distribution, like latencies.
+func TestTDigestLogNormal(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	data := make([]float64, 100000)
+	for i := range data {
+		data[i] = math.Exp(rnd.NormFloat64() * 2.0)
+	}
+	d := NewTDigest(100)
+	d.UpdateArray(data)
+	checkTDigestAccuracy(d, data, "LogNormal", t)
+}
+
+// Sorted input is the hardest case for the buffer.
+func TestTDigestSorted(t *testing.T) {
+	data := make([]float64, 100000)
+	for i := range data {
+		data[i] = float64(i)
+	}
+	d := NewTDigest(100)
+	d.UpdateArray(data)
+	checkTDigestAccuracy(d, data, "Sorted", t)
+}
+
+// Merging the digests of shards gives a digest about as accurate as one of all of the
+// values.
+func TestTDigestMerge(t *testing.T) {
+	rnd := rand.New(rand.NewSource(3))
+	data := make([]float64, 100000)
+	all := NewTDigest(100)
+	for shard := 0; shard < 10; shard++ {
+		d := NewTDigest(100)
+		for i := shard * 10000; i < (shard+1)*10000; i++ {
+			// the shards have different distributions
+			data[i] = rnd.ExpFloat64() * float64(shard+1)
+			d.Update(data[i])
+		}
+		all.Merge(d)
+	}
+	checkTDigestAccuracy(all, data, "Merge", t)
+}
+
+// A value with weight 3 is about the same as 3 updates of it.
+func TestTDigestUpdateWeighted(t *testing.T) {
+	rnd := rand.New(rand.NewSource(4))
+	d1, d2 := NewTDigest(100), NewTDigest(100)
+	for i := 0; i < 10000; i++ {
+		x := rnd.NormFloat64()
+		d1.UpdateWeighted(x, 3.0)
+		d2.UpdateArray([]float64{x, x, x})
+		d1.UpdateWeighted(x+1.0, 0.0)
+	}
+	checkInt(d1.Count(), 30000, "Count", t)
+	for _, p := range []float64{0.001, 0.01, 0.5, 0.99, 0.999} {
+		checkFloat64Abs(d2.CDF(d1.Quantile(p)), p, math.Min(0.005, 0.2*math.Min(p, 1.0-p)), "Quantile", t)
+	}
+	checkFloat64(d1.Max(), d2.Max(), TOL, "Max", t)
+}
+
+// The encoding round-trips, and its format doesn't change.
+func TestTDigestMarshalBinary(t *testing.T) {
+	d := NewTDigest(100)
+	d.UpdateArray([]float64{1.5, -2.0})
+	b, _ := d.MarshalBinary()
+	// version 1, compression 100, min -2, max 1.5, 2 centroids, (-2, 1), (1.5, 1)
+	want := "01" + "4059000000000000" + "c000000000000000" + "3ff8000000000000" + "00000002" +
+		"c000000000000000" + "3ff0000000000000" + "3ff8000000000000" + "3ff0000000000000"
+	if hex.EncodeToString(b) != want {
+		t.Errorf("Found %x, but expected %v for test MarshalBinary", b, want)
+	}
+
+	rnd := rand.New(rand.NewSource(5))
+	d = NewTDigest(50)
+	for i := 0; i < 10000; i++ {
+		d.Update(rnd.ExpFloat64())
+	}
+	b, _ = d.MarshalBinary()
+	var e TDigest
+	if err := e.UnmarshalBinary(b); err != nil {
+		t.Fatalf("Found error %v for test UnmarshalBinary", err)
+	}
+	checkInt(e.Count(), d.Count(), "Count", t)
+	checkFloat64(e.Compression(), 50.0, TOL, "Compression", t)
+	checkFloat64(e.Min(), d.Min(), TOL, "Min", t)
+	checkFloat64(e.Max(), d.Max(), TOL, "Max", t)
+	for _, p := range []float64{0.0, 0.001, 0.5, 0.999, 1.0} {
+		checkFloat64(e.Quantile(p), d.Quantile(p), TOL, "Quantile", t)
+	}
+	b2, _ := e.MarshalBinary()
+	if !bytes.Equal(b, b2) {
+		t.Errorf("Found different encodings for test MarshalBinary round trip")
+	}
+
+	// the decoded digest can be updated and merged
+	e.Update(100.0)
+	e.Merge(d)
+	checkInt(e.Count(), 20001, "Count", t)
+	checkFloat64(e.Max(), 100.0, TOL, "Max", t)
+
+	for _, bad := range [][]byte{nil, b[:len(b)-1], append([]byte{2}, b[1:]...)} {
+		if err := e.UnmarshalBinary(bad); err == nil {
+			t.Errorf("Found no error for test UnmarshalBinary of a bad encoding")
+		}
+	}
+}
+
+//
+//
+// Benchmark tests
+//
+//
+
+func BenchmarkTDigestUpdate(b *testing.B) {
+	d := NewTDigest(100)
+	for i := 0; i < b.N; i++ {
+		d.Update(float64(i % 1000))
+	}
+}
+
+//
+//
+// Assertion functions used for tests
+//
+//
+
+// check the quantiles and CDF of the digest against the fraction of the values below
+// them. The rank error must be within 0.01 near the median, and in the tails, within a
+// fifth of p or 1 - p.
+func checkTDigestAccuracy(d *TDigest, data []float64, test string, t *testing.T) {
+	data = append([]float64(nil), data...)
+	sort.Float64s(data)
+	checkInt(d.Count(), len(data), test+" Count", t)
+	checkFloat64(d.Min(), data[0], TOL, test+" Min", t)
+	checkFloat64(d.Max(), data[len(data)-1], TOL, test+" Max", t)
+	for _, p := range []float64{0.001, 0.01, 0.1, 0.5, 0.9, 0.99, 0.999} {
+		tol := math.Min(0.01, 0.2*math.Min(p, 1.0-p))
+		q := d.Quantile(p)
+		rank := float64(sort.SearchFloat64s(data, q)) / float64(len(data))
+		if math.Abs(rank-p) > tol {
+			t.Errorf("Found rank %v, but expected %v for test %v Quantile %v", rank, p, test, p)
+		}
+		x := data[int(p*float64(len(data)))]
+		if math.Abs(d.CDF(x)-p) > tol {
+			t.Errorf("Found %v, but expected %v for test %v CDF", d.CDF(x), p, test)
+		}
+	}
+}
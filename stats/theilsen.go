@@ -0,0 +1,373 @@
+package stats
+
+//
+// theilsen.go
+//
+// Author:   Gary Boone
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// Changes:
+//           20261017   initial version
+//
+// Source:
+// https://github.com/GaryBoone/GoStats
+//
+// Rank-based robust regression lines, which tolerate a large fraction of outliers:
+//
+// 1. Theil-Sen -- the slope is the median of the slopes between all pairs of points, and
+//    the intercept is the median of y - slope*x. Up to 29% of the points can be
+//    arbitrarily bad. See:
+//    P.K. Sen, Estimates of the regression coefficient based on Kendall's tau, JASA
+//    63(324), 1968.
+// 2. Repeated median -- for each point, the median of the slopes to the other points,
+//    and the slope is the median of those. The intercept is found the same way from the
+//    intercepts of the lines through each pair, as in R's mblm(repeated = TRUE). Up to
+//    50% of the points can be bad. See:
+//    A.F. Siegel, Robust regression using repeated medians, Biometrika 69(1), 1982.
+//
+// Pairs of points with the same x have no slope and are left out. Medians of an even
+// number of values are the mean of the middle two.
+//
+// There are O(n^2) pairs, so Theil-Sen doesn't list them. Instead, the slopes are
+// selected by rank. Ordering the points by y - t*x, the pairs whose slope is at most t are
+// the inversions of that order relative to the order at t = -Inf, so they can be counted
+// in O(n log n) by merge sort. A random slope is chosen as the pivot from those that may
+// still hold the wanted ranks, which are narrowed to its side, until at most 4n remain to
+// list them, again as inversions. This takes O(n log n) expected time per step and
+// O(log n) steps. See:
+// J. Matousek, Randomized optimal algorithm for slope selection, Information Processing
+// Letters 39(4), 1991.
+// The repeated median is O(n^2), so it's for smaller data.
+//
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// The 0.975 quantile of the standard normal distribution, for 95% intervals.
+const normal975 = 1.959963984540054
+
+// The Theil-Sen regression line through the points, with Sen's 95% confidence interval for
+// the slope. The interval is given by the slopes whose ranks are (N -+ C)/2, N being the
+// number of slopes and C = 1.96 sqrt(n(n-1)(2n+5)/18), the standard deviation of
+// Kendall's S for n points without ties. As in Gilbert's Statistical Methods for
+// Environmental Pollution Monitoring, 1987, the lower limit is the slope of rank
+// (N - C)/2 and the upper the slope of rank (N + C)/2 + 1, interpolating between the
+// slopes for fractional ranks. With few points, the ranks are clipped to the slopes. With
+// no slopes, as when all of the x are the same, the results are NaN.
+func TheilSenRegression(xData, yData []float64) (slope, intercept, slopeLower, slopeUpper float64,
+	count int) {
+	if len(xData) != len(yData) {
+		panic("array lengths differ in TheilSenRegression()")
+	}
+	count = len(xData)
+	s := newSlopeSelector(xData, yData)
+	if s.total == 0 {
+		nan := math.NaN()
+		return nan, nan, nan, nan, count
+	}
+
+	// the ranks, from 1, of the median and the interval limits
+	N := float64(s.total)
+	n := float64(count)
+	c := normal975 * math.Sqrt(n*(n-1.0)*(2.0*n+5.0)/18.0)
+	ranks := []float64{(N + 1.0) / 2.0, (N - c) / 2.0, (N+c)/2.0 + 1.0}
+	var ks []int
+	for i, r := range ranks {
+		ranks[i] = math.Max(1.0, math.Min(N, r))
+		k := int(ranks[i])
+		ks = append(ks, k, k+1)
+	}
+	values := s.selectRanks(ks)
+	atRank := func(r float64) float64 {
+		k := int(r)
+		v := values[k]
+		if f := r - float64(k); f > 0.0 {
+			v += f * (values[k+1] - v)
+		}
+		return v
+	}
+	slope = atRank(ranks[0])
+	slopeLower = atRank(ranks[1])
+	slopeUpper = atRank(ranks[2])
+
+	residuals := make([]float64, count)
+	for i := range xData {
+		residuals[i] = yData[i] - slope*xData[i]
+	}
+	intercept = StatsMedian(residuals)
+	return
+}
+
+// Siegel's repeated median regression line through the points. For each point, it takes
+// the median of the slopes and of the intercepts of the lines to the other points with
+// different x, and the slope and intercept are the medians of those. It takes O(n^2)
+// time.
+func RepeatedMedianRegression(xData, yData []float64) (slope, intercept float64, count int) {
+	if len(xData) != len(yData) {
+		panic("array lengths differ in RepeatedMedianRegression()")
+	}
+	count = len(xData)
+	var slopes, intercepts []float64
+	pointSlopes := make([]float64, 0, count)
+	pointIntercepts := make([]float64, 0, count)
+	for i := range xData {
+		pointSlopes, pointIntercepts = pointSlopes[:0], pointIntercepts[:0]
+		for j := range xData {
+			dx := xData[j] - xData[i]
+			if dx == 0.0 {
+				continue
+			}
+			pointSlopes = append(pointSlopes, (yData[j]-yData[i])/dx)
+			pointIntercepts = append(pointIntercepts, (xData[j]*yData[i]-xData[i]*yData[j])/dx)
+		}
+		if len(pointSlopes) > 0 {
+			slopes = append(slopes, StatsMedian(pointSlopes))
+			intercepts = append(intercepts, StatsMedian(pointIntercepts))
+		}
+	}
+	return StatsMedian(slopes), StatsMedian(intercepts), count
+}
+
+//
+//
+// Slope selection
+//
+//
+
+// A slope t = dy/dx, dx >= 0, as the direction (dx, dy). (0, -1) and (0, 1) are -Inf and
+// +Inf. If below is set, it's just below t, so that slopes equal to t are above it.
+type slopeBound struct {
+	dx, dy float64
+	below  bool
+}
+
+// structure to select the slopes between the points by rank
+type slopeSelector struct {
+	x, y  []float64
+	total int        // the number of pairs with different x
+	order []int      // the order of the points at -Inf
+	rank  []int      // the position of each point in order
+	rnd   *rand.Rand // for choosing the pivots
+}
+
+func newSlopeSelector(x, y []float64) *slopeSelector {
+	s := &slopeSelector{x: x, y: y, rnd: rand.New(rand.NewSource(1))}
+	s.order, _ = s.orderAt(slopeBound{0.0, -1.0, false})
+	s.rank = make([]int, len(x))
+	for i, p := range s.order {
+		s.rank[p] = i
+	}
+	// all pairs, less those with the same x, which are adjacent in the order
+	n := len(x)
+	s.total = n * (n - 1) / 2
+	for i := 0; i < n; {
+		j := i + 1
+		for j < n && x[s.order[j]] == x[s.order[i]] {
+			j++
+		}
+		s.total -= (j - i) * (j - i - 1) / 2
+		i = j
+	}
+	return s
+}
+
+// The key of point i at slope t, (y - t*x)dx.
+func (s *slopeSelector) key(i int, t slopeBound) float64 {
+	return math.FMA(s.y[i], t.dx, -s.x[i]*t.dy)
+}
+
+// Whether point i comes before point j in the order at slope t: by the key, then by
+// decreasing x, so that a pair whose slope is t comes after its crossing, or increasing x
+// if just below t, then by increasing y and index, so that pairs with the same x never
+// cross.
+func (s *slopeSelector) before(i, j int, t slopeBound) bool {
+	ki, kj := s.key(i, t), s.key(j, t)
+	switch {
+	case ki != kj:
+		return ki < kj
+	case s.x[i] != s.x[j]:
+		return (s.x[i] > s.x[j]) != t.below
+	case s.y[i] != s.y[j]:
+		return s.y[i] < s.y[j]
+	}
+	return i < j
+}
+
+// The points in their order at slope t, and their keys.
+func (s *slopeSelector) orderAt(t slopeBound) ([]int, []float64) {
+	n := len(s.x)
+	order := make([]int, n)
+	keys := make([]float64, n)
+	for i := range order {
+		order[i] = i
+		keys[i] = s.key(i, t)
+	}
+	sort.Slice(order, func(a, b int) bool {
+		i, j := order[a], order[b]
+		switch {
+		case keys[i] != keys[j]:
+			return keys[i] < keys[j]
+		case s.x[i] != s.x[j]:
+			return (s.x[i] > s.x[j]) != t.below
+		case s.y[i] != s.y[j]:
+			return s.y[i] < s.y[j]
+		}
+		return i < j
+	})
+	return order, keys
+}
+
+// The number of slopes at most t, the inversions of the order at t relative to the order
+// at -Inf, and the number equal to t, the pairs with the same key and different x.
+func (s *slopeSelector) countAtMost(t slopeBound) (atMost, equal int) {
+	order, keys := s.orderAt(t)
+	seq := make([]int, len(order))
+	for i, p := range order {
+		seq[i] = s.rank[p]
+	}
+	// the runs of the same key, and within them, of the same x, are adjacent
+	for i := 0; i < len(order); {
+		j := i + 1
+		for j < len(order) && keys[order[j]] == keys[order[i]] {
+			j++
+		}
+		equal += (j - i) * (j - i - 1) / 2
+		for a := i; a < j; {
+			b := a + 1
+			for b < j && s.x[order[b]] == s.x[order[a]] {
+				b++
+			}
+			equal -= (b - a) * (b - a - 1) / 2
+			a = b
+		}
+		i = j
+	}
+	return inversions(seq, make([]int, len(seq)), nil), equal
+}
+
+// The slopes in (lo, hi]: the pairs that are in one order at lo and the other at hi.
+func (s *slopeSelector) slopesBetween(lo, hi slopeBound) []float64 {
+	orderLo, _ := s.orderAt(lo)
+	orderHi, _ := s.orderAt(hi)
+	rankLo := make([]int, len(orderLo))
+	for i, p := range orderLo {
+		rankLo[p] = i
+	}
+	seq := make([]int, len(orderHi))
+	for i, p := range orderHi {
+		seq[i] = rankLo[p]
+	}
+	var slopes []float64
+	inversions(seq, make([]int, len(seq)), func(a, b int) {
+		i, j := orderLo[a], orderLo[b]
+		slopes = append(slopes, (s.y[j]-s.y[i])/(s.x[j]-s.x[i]))
+	})
+	return slopes
+}
+
+// The slopes of the given ranks, from 1 to total, by rank.
+func (s *slopeSelector) selectRanks(ks []int) map[int]float64 {
+	ks = append([]int(nil), ks...)
+	sort.Ints(ks)
+	for len(ks) > 0 && ks[len(ks)-1] > s.total {
+		ks = ks[:len(ks)-1]
+	}
+	values := make(map[int]float64)
+	s.selectBetween(slopeBound{0.0, -1.0, false}, slopeBound{0.0, 1.0, false}, 0, s.total, ks, values)
+	return values
+}
+
+// Find the slopes of the ranks ks, which are in (countLo, countHi], the number of slopes
+// at most lo and hi.
+func (s *slopeSelector) selectBetween(lo, hi slopeBound, countLo, countHi int, ks []int,
+	values map[int]float64) {
+	if len(ks) == 0 {
+		return
+	}
+	n := len(s.x)
+	if countHi-countLo <= 4*n {
+		slopes := s.slopesBetween(lo, hi)
+		sort.Float64s(slopes)
+		if len(slopes) == 0 {
+			slopes = []float64{math.NaN()}
+		}
+		for _, k := range ks {
+			i := k - countLo - 1
+			// guard against rounding making the counts slightly inconsistent
+			if i >= len(slopes) {
+				i = len(slopes) - 1
+			}
+			if i < 0 {
+				i = 0
+			}
+			values[k] = slopes[i]
+		}
+		return
+	}
+
+	// a random pair with its slope in (lo, hi], by rejection, as they're at least 4n of the
+	// O(n^2) pairs
+	var t slopeBound
+	for {
+		i, j := s.rnd.Intn(n), s.rnd.Intn(n)
+		if s.x[i] == s.x[j] {
+			continue
+		}
+		if s.x[i] > s.x[j] {
+			i, j = j, i
+		}
+		if s.before(i, j, lo) && s.before(j, i, hi) {
+			t = slopeBound{s.x[j] - s.x[i], s.y[j] - s.y[i], false}
+			break
+		}
+	}
+	// the ranks below, at and above the pivot
+	countAt, equal := s.countAtMost(t)
+	countBelow := countAt - equal
+	below := slopeBound{t.dx, t.dy, true}
+	i := sort.SearchInts(ks, countBelow+1)
+	j := sort.SearchInts(ks, countAt+1)
+	for _, k := range ks[i:j] {
+		values[k] = t.dy / t.dx
+	}
+	s.selectBetween(lo, below, countLo, countBelow, ks[:i], values)
+	s.selectBetween(t, hi, countAt, countHi, ks[j:], values)
+}
+
+// The number of inversions in seq, the pairs a before b with a > b, counted by merge
+// sort. seq is sorted, and tmp is scratch of the same length. If found isn't nil, it's
+// called with each inversion.
+func inversions(seq, tmp []int, found func(a, b int)) int {
+	n := len(seq)
+	if n < 2 {
+		return 0
+	}
+	mid := n / 2
+	count := inversions(seq[:mid], tmp[:mid], found) + inversions(seq[mid:], tmp[mid:], found)
+	i, j, k := 0, mid, 0
+	for i < mid && j < n {
+		if seq[i] <= seq[j] {
+			tmp[k] = seq[i]
+			i++
+		} else {
+			// seq[j] is less than each of seq[i:mid]
+			count += mid - i
+			if found != nil {
+				for _, a := range seq[i:mid] {
+					found(seq[j], a)
+				}
+			}
+			tmp[k] = seq[j]
+			j++
+		}
+		k++
+	}
+	k += copy(tmp[k:], seq[i:mid])
+	copy(tmp[k:], seq[j:])
+	copy(seq, tmp)
+	return count
+}
@@ -0,0 +1,160 @@
+package stats
+
+//
+// theilsen_test.go
+//
+// Author:   Gary Boone
+//
+// Test:
+//   go test stats.go stats_test.go quantile.go specfunc.go distributions.go regression.go theilsen.go theilsen_test.go
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// R test code:
+// library(mblm)
+// x <- 1:12
+// y <- c(2.1, 3.9, 6.2, 7.8, 10.1, 12.2, 13.8, 16.1, 50.0, 19.9, 22.2, -30.0)
+// coef(mblm(y ~ x, repeated = TRUE))
+// s <- sort(outer(y, y, "-")[upper.tri(diag(12))] / outer(x, x, "-")[upper.tri(diag(12))])
+// median(s); median(y - median(s) * x)
+//
+// The confidence limits are the interpolated order statistics of s at the ranks given in
+// theilsen.go.
+//
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+var theilX = []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+var theilY = []float64{2.1, 3.9, 6.2, 7.8, 10.1, 12.2, 13.8, 16.1, 50.0, 19.9, 22.2, -30.0}
+
+func TestTheilSenRegression(t *testing.T) {
+	slope, intercept, lower, upper, count := TheilSenRegression(theilX, theilY)
+	checkFloat64(slope, 2.0, TOL, "Slope", t)
+	checkFloat64Abs(intercept, 0.1, 1e-12, "Intercept", t)
+	checkFloat64(lower, 1.9, TOL, "SlopeLower", t)
+	checkFloat64(upper, 2.0599159116474985, TOL, "SlopeUpper", t)
+	checkInt(count, 12, "Count", t)
+
+	// the outliers pull the least squares line far off
+	lsSlope, _, _, _, _, _ := LinearRegression(theilX, theilY)
+	if math.Abs(lsSlope-2.0) < 0.5 {
+		t.Errorf("Found least squares slope %v, expected it far from 2", lsSlope)
+	}
+
+	x := []float64{2000, 2001, 2002, 2003, 2004}
+	y := []float64{9.34, 8.50, 7.62, 6.93, 6.60}
+	slope, intercept, lower, upper, _ = TheilSenRegression(x, y)
+	checkFloat64(slope, -0.7375, TOL, "Slope", t)
+	checkFloat64(intercept, 1484.2375, TOL, "Intercept", t)
+	checkFloat64(lower, -0.88, TOL, "SlopeLower", t)
+	checkFloat64(upper, -0.33, TOL, "SlopeUpper", t)
+}
+
+func TestTheilSenDegenerate(t *testing.T) {
+	// no slopes
+	slope, intercept, lower, upper, count := TheilSenRegression([]float64{1, 1, 1}, []float64{1, 2, 3})
+	checkNaN(slope, "Slope", t)
+	checkNaN(intercept, "Intercept", t)
+	checkNaN(lower, "SlopeLower", t)
+	checkNaN(upper, "SlopeUpper", t)
+	checkInt(count, 3, "Count", t)
+	slope, _, _, _, _ = TheilSenRegression([]float64{}, []float64{})
+	checkNaN(slope, "Slope empty", t)
+
+	// a line, all of whose slopes are the same
+	n := 1000
+	x := make([]float64, n)
+	y := make([]float64, n)
+	for i := range x {
+		x[i] = float64(i % 100)
+		y[i] = 3.0 - x[i]/3.0
+	}
+	slope, intercept, lower, upper, _ = TheilSenRegression(x, y)
+	checkFloat64(slope, -1.0/3.0, TOL, "Slope line", t)
+	checkFloat64(intercept, 3.0, TOL, "Intercept line", t)
+	checkFloat64(lower, -1.0/3.0, TOL, "SlopeLower line", t)
+	checkFloat64(upper, -1.0/3.0, TOL, "SlopeUpper line", t)
+}
+
+// The slopes selected by rank are those of the sorted list of all slopes, with many ties.
+func TestTheilSenSelection(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, n := range []int{2, 3, 10, 101, 1500} {
+		for _, spread := range []int{5, 1000000} {
+			x := make([]float64, n)
+			y := make([]float64, n)
+			for i := range x {
+				x[i] = float64(rnd.Intn(spread))
+				y[i] = 0.5*x[i] + float64(rnd.Intn(spread))
+				if spread > n {
+					x[i] += rnd.Float64()
+				}
+			}
+			var slopes []float64
+			for i := range x {
+				for j := i + 1; j < n; j++ {
+					if x[i] != x[j] {
+						slopes = append(slopes, (y[j]-y[i])/(x[j]-x[i]))
+					}
+				}
+			}
+			sort.Float64s(slopes)
+			s := newSlopeSelector(x, y)
+			checkInt(s.total, len(slopes), "total", t)
+			if len(slopes) == 0 {
+				continue
+			}
+			ks := []int{1, len(slopes), (len(slopes) + 1) / 2, (len(slopes) + 2) / 2}
+			for i := 0; i < 10; i++ {
+				ks = append(ks, 1+rnd.Intn(len(slopes)))
+			}
+			values := s.selectRanks(ks)
+			for _, k := range ks {
+				checkFloat64(values[k], slopes[k-1], 1e-12, "selectRanks", t)
+			}
+		}
+	}
+}
+
+func TestRepeatedMedianRegression(t *testing.T) {
+	slope, intercept, count := RepeatedMedianRegression(theilX, theilY)
+	checkFloat64(slope, 2.0, TOL, "Slope", t)
+	checkFloat64Abs(intercept, 0.1, 1e-12, "Intercept", t)
+	checkInt(count, 12, "Count", t)
+
+	x := []float64{2000, 2001, 2002, 2003, 2004}
+	y := []float64{9.34, 8.50, 7.62, 6.93, 6.60}
+	slope, intercept, _ = RepeatedMedianRegression(x, y)
+	checkFloat64(slope, -0.775, TOL, "Slope", t)
+	checkFloat64(intercept, 1559.17, TOL, "Intercept", t)
+
+	slope, intercept, _ = RepeatedMedianRegression([]float64{1, 1}, []float64{1, 2})
+	checkNaN(slope, "Slope no slopes", t)
+	checkNaN(intercept, "Intercept no slopes", t)
+}
+
+//
+//
+// Benchmark tests
+//
+//
+
+func BenchmarkTheilSenRegression(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	n := 100000
+	x := make([]float64, n)
+	y := make([]float64, n)
+	for i := range x {
+		x[i] = rnd.Float64()
+		y[i] = 2.0*x[i] + rnd.NormFloat64()
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		TheilSenRegression(x, y)
+	}
+}
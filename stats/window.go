@@ -0,0 +1,176 @@
+package stats
+
+//
+// window.go
+//
+// Author:   Gary Boone
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// Changes:
+//           20261017   initial version
+//
+// Source:
+// https://github.com/GaryBoone/GoStats
+//
+// WindowStats provides the descriptive statistics of the last N values. Each Update()
+// adds the new value to the moments and Remove()s the oldest one. Because repeated
+// removals accumulate rounding error, the moments are recomputed from the window after
+// every N evictions, which keeps the cost O(1) amortized per update.
+//
+// The min and max are kept exactly with monotonic deques: the min deque holds the values
+// that could still become the window min, in increasing order, and the max deque the
+// values that could become the max, in decreasing order.
+//
+
+// Data structure to contain the window of values and their accumulating moments
+type WindowStats struct {
+	values     []float64     // ring buffer of the values in the window
+	seq        int           // number of values ever added
+	evictions  int           // evictions since the moments were recomputed
+	stats      Stats         // moments of the values in the window
+	mins, maxs []windowEntry // monotonic deques for the min and max
+}
+
+type windowEntry struct {
+	seq int
+	x   float64
+}
+
+// Create a WindowStats holding the last size values.
+func NewWindowStats(size int) *WindowStats {
+	if size < 1 {
+		panic("window size must be at least 1 in NewWindowStats()")
+	}
+	return &WindowStats{values: make([]float64, size)}
+}
+
+//
+//
+// Accessor Functions
+//
+//
+
+func (w *WindowStats) Count() int {
+	return w.stats.Count()
+}
+
+func (w *WindowStats) Size() int {
+	return w.stats.Size()
+}
+
+// The maximum number of values in the window.
+func (w *WindowStats) Capacity() int {
+	return len(w.values)
+}
+
+func (w *WindowStats) Min() float64 {
+	if len(w.mins) == 0 {
+		return 0.0
+	}
+	return w.mins[0].x
+}
+
+func (w *WindowStats) Max() float64 {
+	if len(w.maxs) == 0 {
+		return 0.0
+	}
+	return w.maxs[0].x
+}
+
+func (w *WindowStats) Sum() float64 {
+	return w.stats.Sum()
+}
+
+func (w *WindowStats) Mean() float64 {
+	return w.stats.Mean()
+}
+
+//
+//
+// Incremental Functions
+//
+//
+
+// Update the stats with the given value, evicting the oldest value if the window is full.
+func (w *WindowStats) Update(x float64) {
+	size := len(w.values)
+	i := w.seq % size
+	if w.seq >= size {
+		w.stats.Remove(w.values[i])
+		w.evictions++
+	}
+	w.values[i] = x
+	w.seq++
+	if w.evictions == size {
+		w.recompute()
+	} else {
+		w.stats.Update(x)
+	}
+
+	oldest := w.seq - size
+	for len(w.mins) > 0 && w.mins[len(w.mins)-1].x >= x {
+		w.mins = w.mins[:len(w.mins)-1]
+	}
+	w.mins = append(w.mins, windowEntry{w.seq - 1, x})
+	if w.mins[0].seq < oldest {
+		w.mins = w.mins[1:]
+	}
+	for len(w.maxs) > 0 && w.maxs[len(w.maxs)-1].x <= x {
+		w.maxs = w.maxs[:len(w.maxs)-1]
+	}
+	w.maxs = append(w.maxs, windowEntry{w.seq - 1, x})
+	if w.maxs[0].seq < oldest {
+		w.maxs = w.maxs[1:]
+	}
+}
+
+// Update the stats with the given array of values.
+func (w *WindowStats) UpdateArray(data []float64) {
+	for _, v := range data {
+		w.Update(v)
+	}
+}
+
+// Recompute the moments from the full window, oldest value first.
+func (w *WindowStats) recompute() {
+	size := len(w.values)
+	start := w.seq % size
+	w.stats = Stats{}
+	w.stats.UpdateArray(w.values[start:])
+	w.stats.UpdateArray(w.values[:start])
+	w.evictions = 0
+}
+
+func (w *WindowStats) PopulationVariance() float64 {
+	return w.stats.PopulationVariance()
+}
+
+func (w *WindowStats) SampleVariance() float64 {
+	return w.stats.SampleVariance()
+}
+
+func (w *WindowStats) PopulationStandardDeviation() float64 {
+	return w.stats.PopulationStandardDeviation()
+}
+
+func (w *WindowStats) SampleStandardDeviation() float64 {
+	return w.stats.SampleStandardDeviation()
+}
+
+func (w *WindowStats) PopulationSkew() float64 {
+	return w.stats.PopulationSkew()
+}
+
+func (w *WindowStats) SampleSkew() float64 {
+	return w.stats.SampleSkew()
+}
+
+// The kurtosis functions return _excess_ kurtosis, as for Stats.
+func (w *WindowStats) PopulationKurtosis() float64 {
+	return w.stats.PopulationKurtosis()
+}
+
+func (w *WindowStats) SampleKurtosis() float64 {
+	return w.stats.SampleKurtosis()
+}
@@ -0,0 +1,153 @@
+package stats
+
+//
+// window_test.go
+//
+// Author:   Gary Boone
+//
+// Test:
+//   go test stats.go stats_test.go window.go window_test.go
+//
+// Copyright (c) 2011-2013 Gary Boone <gary.boone@gmail.com>.
+//
+// The window stats are checked against a Stats updated with only the values in the
+// window, which in turn was compared against the R stats package.
+//
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// With no updates, these are the results on initialization
+func TestWindowStats0(t *testing.T) {
+	w := NewWindowStats(4)
+	checkInt(w.Count(), 0, "Count", t)
+	checkInt(w.Capacity(), 4, "Capacity", t)
+	checkFloat64(w.Min(), 0.0, TOL, "Min", t)
+	checkFloat64(w.Max(), 0.0, TOL, "Max", t)
+	checkFloat64(w.Sum(), 0.0, TOL, "Sum", t)
+	checkFloat64(w.Mean(), 0.0, TOL, "Mean", t)
+	checkNaN(w.PopulationVariance(), "PopulationVariance", t)
+	checkNaN(w.SampleVariance(), "SampleVariance", t)
+	checkNaN(w.PopulationStandardDeviation(), "PopulationStandardDeviation", t)
+	checkNaN(w.SampleStandardDeviation(), "SampleStandardDeviation", t)
+	checkNaN(w.PopulationSkew(), "PopulationSkew", t)
+	checkNaN(w.SampleSkew(), "SampleSkew", t)
+	checkNaN(w.PopulationKurtosis(), "PopulationKurtosis", t)
+	checkNaN(w.SampleKurtosis(), "SampleKurtosis", t)
+}
+
+// Until the window fills, the window stats are the stats of all of the values.
+func TestWindowStatsPartial(t *testing.T) {
+	w := NewWindowStats(20)
+	a := []float64{1.0, -2.0, 13.0, 47.0, 115.0, -0.03, -123.4, 23.0, -23.04, 12.3}
+	w.UpdateArray(a)
+	checkInt(w.Count(), 10, "Count", t)
+	checkFloat64(w.Min(), -123.4, TOL, "Min", t)
+	checkFloat64(w.Max(), 115.0, TOL, "Max", t)
+	checkFloat64(w.Sum(), 62.83, TOL, "Sum", t)
+	checkFloat64(w.Mean(), 6.283, TOL, "Mean", t)
+	checkFloat64(w.PopulationVariance(), 3165.19316100, TOL, "PopulationVariance", t)
+	checkFloat64(w.SampleVariance(), 3516.88129, TOL, "SampleVariance", t)
+	checkFloat64(w.PopulationStandardDeviation(), 56.2600494223032, TOL, "PopulationStandardDeviation", t)
+	checkFloat64(w.SampleStandardDeviation(), 59.3032991493728, TOL, "SampleStandardDeviation", t)
+	checkFloat64(w.PopulationSkew(), -0.4770396201629045, TOL, "PopulationSkew", t)
+	checkFloat64(w.SampleSkew(), -0.565699400196136, TOL, "SampleSkew", t)
+	checkFloat64(w.PopulationKurtosis(), 1.253240236214162, TOL, "PopulationKurtosis", t)
+	checkFloat64(w.SampleKurtosis(), 3.179835417592894, TOL, "SampleKurtosis", t)
+}
+
+// Slide a window of 5 over the array. After the last update, the window holds the second
+// half of the array.
+func TestWindowStatsSlide(t *testing.T) {
+	w := NewWindowStats(5)
+	a := []float64{1.0, -2.0, 13.0, 47.0, 115.0, -0.03, -123.4, 23.0, -23.04, 12.3}
+	for i, v := range a {
+		w.Update(v)
+		checkWindowStats(w, a[:i+1], 1e-12, "Slide", t)
+	}
+	checkInt(w.Count(), 5, "Count", t)
+	checkFloat64(w.Min(), -123.4, TOL, "Min", t)
+	checkFloat64(w.Max(), 23.0, TOL, "Max", t)
+	checkFloat64(w.Sum(), -111.17, 1e-12, "Sum", t)
+}
+
+// A window of 1 holds only the latest value.
+func TestWindowStats1(t *testing.T) {
+	w := NewWindowStats(1)
+	w.UpdateArray([]float64{2.3, 0.4, -3.4})
+	checkInt(w.Count(), 1, "Count", t)
+	checkFloat64(w.Min(), -3.4, TOL, "Min", t)
+	checkFloat64(w.Max(), -3.4, TOL, "Max", t)
+	checkFloat64(w.Mean(), -3.4, TOL, "Mean", t)
+	checkNaN(w.SampleVariance(), "SampleVariance", t)
+}
+
+// Slide a window over a long stream of values, including runs of repeated and monotonic
+// values that exercise the min and max deques. Removing values across the jumps in level
+// costs some digits in the higher moments until the next recompute.
+func TestWindowStatsLong(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	a := make([]float64, 5000)
+	for i := range a {
+		switch {
+		case i%1000 < 100:
+			a[i] = float64(i % 1000) // increasing run
+		case i%1000 < 200:
+			a[i] = float64(-i % 1000) // decreasing run
+		case i%1000 < 250:
+			a[i] = 7.0
+		default:
+			a[i] = rnd.NormFloat64()*10.0 + 1000.0
+		}
+	}
+	w := NewWindowStats(37)
+	for i, v := range a {
+		w.Update(v)
+		checkWindowStats(w, a[:i+1], 1e-7, "Long", t)
+	}
+}
+
+//
+//
+// Benchmark tests
+//
+//
+
+func BenchmarkWindowStatsUpdate(b *testing.B) {
+	w := NewWindowStats(100)
+	for i := 0; i < b.N; i++ {
+		w.Update(float64(i % 1000))
+	}
+}
+
+//
+//
+// Assertion functions used for tests
+//
+//
+
+// check the window stats against a Stats of the last Capacity() values of a
+func checkWindowStats(w *WindowStats, a []float64, tol float64, test string, t *testing.T) {
+	if len(a) > w.Capacity() {
+		a = a[len(a)-w.Capacity():]
+	}
+	var d Stats
+	d.UpdateArray(a)
+	checkInt(w.Count(), d.Count(), test+" Count", t)
+	if w.Min() != d.Min() {
+		t.Errorf("Found %v, but expected %v for test %v", w.Min(), d.Min(), test+" Min")
+	}
+	if w.Max() != d.Max() {
+		t.Errorf("Found %v, but expected %v for test %v", w.Max(), d.Max(), test+" Max")
+	}
+	checkFloat64Abs(w.Mean(), d.Mean(), tol*(math.Abs(d.Mean())+math.Abs(d.Max()-d.Min())), test+" Mean", t)
+	if d.Count() > 1 && d.PopulationVariance() > 0.0 {
+		checkFloat64(w.PopulationVariance(), d.PopulationVariance(), tol, test+" PopulationVariance", t)
+		checkFloat64(w.SampleVariance(), d.SampleVariance(), tol, test+" SampleVariance", t)
+		checkFloat64Abs(w.PopulationSkew(), d.PopulationSkew(), tol, test+" PopulationSkew", t)
+		checkFloat64Abs(w.PopulationKurtosis(), d.PopulationKurtosis(), tol, test+" PopulationKurtosis", t)
+	}
+}